@@ -18,10 +18,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"path"
+	"strconv"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 )
@@ -31,11 +34,34 @@ type Backup interface {
 	// Or it returns an error.
 	Request(ctx context.Context) error
 
+	// Rollback tells the backup service that the etcd cluster was rolled back to an older revision.
+	// The backups taken after that revision are retired, and a backup of the cluster is taken.
+	// Rollback returns nil once the backup is made successfully.
+	Rollback(ctx context.Context) error
+
 	// Exist checks if there is a backup available for the specific version of etcd cluster.
 	Exist(ctx context.Context, v string) (bool, error)
 
+	// Find returns the name of the backup an etcd cluster of the specific version is restored from
+	// to the target t, the newest backup at or before t, and the revision it is restored to.
+	// It returns an empty name if there is no such backup.
+	Find(ctx context.Context, v string, t api.RestoreTarget) (name string, rev int64, err error)
+
 	// ServiceStatus returns the backup service status.
 	ServiceStatus(ctx context.Context) (*backupapi.ServiceStatus, error)
+
+	// List lists the backups in the backup storage, sorted by revision.
+	List(ctx context.Context) ([]backupapi.BackupInfo, error)
+
+	// Inspect returns the information of the backup ref refers to, by name or revision.
+	Inspect(ctx context.Context, ref string) (*backupapi.BackupInfo, error)
+
+	// Download downloads the backup ref refers to, by name or revision.
+	// The caller must close the returned reader.
+	Download(ctx context.Context, ref string) (io.ReadCloser, error)
+
+	// Delete deletes the backup ref refers to, by name or revision, with its manifest and delta chain.
+	Delete(ctx context.Context, ref string) error
 }
 
 type backupClient struct {
@@ -88,6 +114,15 @@ func (b backupClient) Request(ctx context.Context) error {
 	return fmt.Errorf("request backup (%s) failed: unexpected status code (%v), response (%s)", b.addr, resp.Status, errmsg)
 }
 
+func (b backupClient) Rollback(ctx context.Context) error {
+	resp, err := b.do(ctx, http.MethodPost, fmt.Sprintf("%s://%s/rollback", b.scheme, path.Join(b.addr, backupapi.APIV1)))
+	if err != nil {
+		return fmt.Errorf("roll back backups (%s) failed: %v", b.addr, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
 func (b backupClient) Exist(ctx context.Context, v string) (bool, error) {
 	req := &http.Request{
 		Method: http.MethodHead,
@@ -116,6 +151,39 @@ func (b backupClient) Exist(ctx context.Context, v string) (bool, error) {
 	return false, fmt.Errorf("check backup existence (%s) failed: unexpected status code (%v), response (%s)", b.addr, resp.Status, errmsg)
 }
 
+func (b backupClient) Find(ctx context.Context, v string, t api.RestoreTarget) (string, int64, error) {
+	req := &http.Request{
+		Method: http.MethodHead,
+		URL:    backupapi.NewBackupURLAt(b.scheme, b.addr, v, t),
+	}
+
+	resp, err := b.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", 0, fmt.Errorf("find backup (%s) failed: %v", b.addr, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		rev, err := strconv.ParseInt(resp.Header.Get(backupapi.HTTPHeaderRevision), 10, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("find backup (%s) failed: invalid revision: %v", b.addr, err)
+		}
+		return resp.Header.Get(backupapi.HTTPHeaderBackup), rev, nil
+	case http.StatusNotFound:
+		return "", 0, nil
+	}
+
+	var errmsg string
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		errmsg = fmt.Sprintf("fail to read response body: %v", err)
+	} else {
+		errmsg = string(body)
+	}
+	return "", 0, fmt.Errorf("find backup (%s) failed: unexpected status code (%v), response (%s)", b.addr, resp.Status, errmsg)
+}
+
 func (b backupClient) ServiceStatus(ctx context.Context) (*backupapi.ServiceStatus, error) {
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s://%s/status", b.scheme, path.Join(b.addr, backupapi.APIV1)), nil)
 	if err != nil {
@@ -148,3 +216,73 @@ func (b backupClient) ServiceStatus(ctx context.Context) (*backupapi.ServiceStat
 	}
 	return nil, fmt.Errorf("get service status (%s) failed: unexpected status code (%v), response (%s)", b.addr, resp.Status, errmsg)
 }
+
+func (b backupClient) List(ctx context.Context) ([]backupapi.BackupInfo, error) {
+	resp, err := b.do(ctx, http.MethodGet, backupapi.NewCatalogURL(b.scheme, b.addr, "").String())
+	if err != nil {
+		return nil, fmt.Errorf("list backups (%s) failed: %v", b.addr, err)
+	}
+	defer resp.Body.Close()
+
+	var bis []backupapi.BackupInfo
+	if err := json.NewDecoder(resp.Body).Decode(&bis); err != nil {
+		return nil, err
+	}
+	return bis, nil
+}
+
+func (b backupClient) Inspect(ctx context.Context, ref string) (*backupapi.BackupInfo, error) {
+	resp, err := b.do(ctx, http.MethodGet, backupapi.NewCatalogURL(b.scheme, b.addr, ref).String())
+	if err != nil {
+		return nil, fmt.Errorf("inspect backup %s (%s) failed: %v", ref, b.addr, err)
+	}
+	defer resp.Body.Close()
+
+	var bi backupapi.BackupInfo
+	if err := json.NewDecoder(resp.Body).Decode(&bi); err != nil {
+		return nil, err
+	}
+	return &bi, nil
+}
+
+func (b backupClient) Download(ctx context.Context, ref string) (io.ReadCloser, error) {
+	resp, err := b.do(ctx, http.MethodGet, backupapi.NewBackupDataURL(b.scheme, b.addr, ref).String())
+	if err != nil {
+		return nil, fmt.Errorf("download backup %s (%s) failed: %v", ref, b.addr, err)
+	}
+	return resp.Body, nil
+}
+
+func (b backupClient) Delete(ctx context.Context, ref string) error {
+	resp, err := b.do(ctx, http.MethodDelete, backupapi.NewCatalogURL(b.scheme, b.addr, ref).String())
+	if err != nil {
+		return fmt.Errorf("delete backup %s (%s) failed: %v", ref, b.addr, err)
+	}
+	return resp.Body.Close()
+}
+
+// do sends a request to the backup service. It fails if the response status is not 200,
+// with the response body as the error message.
+func (b backupClient) do(ctx context.Context, method, u string) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var errmsg string
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		errmsg = fmt.Sprintf("fail to read response body: %v", err)
+	} else {
+		errmsg = string(body)
+	}
+	return nil, fmt.Errorf("unexpected status code (%v), response (%s)", resp.Status, errmsg)
+}
@@ -67,7 +67,7 @@ func main() {
 		panic("clusterName not set")
 	}
 
-	bp, tls, err := parseSpecsFromEnv()
+	bp, cs, err := parseSpecsFromEnv()
 	if err != nil {
 		logrus.Fatalf("failed to parse specs from environment: %v", err)
 	}
@@ -75,8 +75,10 @@ func main() {
 		Kubecli:      k8sutil.MustNewKubeClient(),
 		ListenAddr:   listenAddr,
 		ClusterName:  clusterName,
+		ClusterUID:   os.Getenv(env.ClusterUID),
 		Namespace:    namespace,
-		TLS:          tls,
+		BaseImage:    cs.BaseImage,
+		TLS:          cs.TLS,
 		BackupPolicy: bp,
 	}
 
@@ -95,7 +97,7 @@ func main() {
 }
 
 // parseSpecsFromEnv parses ClusterSpec and BackupSpec from env if any.
-func parseSpecsFromEnv() (*api.BackupPolicy, *api.TLSPolicy, error) {
+func parseSpecsFromEnv() (*api.BackupPolicy, *api.ClusterSpec, error) {
 	var (
 		bp api.BackupPolicy
 		cs api.ClusterSpec
@@ -127,5 +129,6 @@ func parseSpecsFromEnv() (*api.BackupPolicy, *api.TLSPolicy, error) {
 		bp = *cs.Backup
 	}
 
-	return &bp, cs.TLS, nil
+	cs.Cleanup()
+	return &bp, &cs, nil
 }
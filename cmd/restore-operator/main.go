@@ -40,6 +40,9 @@ var (
 	// This is the address of k8s service to restore operator itself for accessing
 	// backup HTTP endpoints. For example, "restore-operator:19999"
 	serviceAddrForSelf string
+	// tlsSecret and clientCASecret name the secrets the backup HTTP endpoints are secured with.
+	tlsSecret      string
+	clientCASecret string
 )
 
 func main() {
@@ -55,6 +58,11 @@ func main() {
 	if len(serviceAddrForSelf) == 0 {
 		logrus.Fatalf("must set env %s", constants.EnvRestoreOperatorServiceName)
 	}
+	tlsSecret = os.Getenv(constants.EnvRestoreOperatorTLSSecret)
+	clientCASecret = os.Getenv(constants.EnvRestoreOperatorClientCASecret)
+	if len(tlsSecret) == 0 && len(clientCASecret) != 0 {
+		logrus.Fatalf("must set env %s with env %s", constants.EnvRestoreOperatorTLSSecret, constants.EnvRestoreOperatorClientCASecret)
+	}
 	id, err := os.Hostname()
 	if err != nil {
 		logrus.Fatalf("failed to get hostname: %v", err)
@@ -102,7 +110,7 @@ func createRecorder(kubecli kubernetes.Interface, name, namespace string) record
 }
 
 func run(stop <-chan struct{}) {
-	c := controller.New(namespace, serviceAddrForSelf)
+	c := controller.New(namespace, serviceAddrForSelf, tlsSecret, clientCASecret)
 	err := c.Start(context.TODO())
 	if err != nil {
 		logrus.Fatalf("etcd restore operator stopped with error: %v", err)
@@ -14,19 +14,42 @@
 
 package v1beta2
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coreos/etcd-operator/pkg/util/cronutil"
+)
 
 type BackupStorageType string
 
+// BackupCompression is the codec backups are compressed with.
+type BackupCompression string
+
+// BackupDeletionPolicy is what happens to the backup saved by an EtcdBackup when the EtcdBackup is deleted.
+type BackupDeletionPolicy string
+
 const (
 	BackupStorageTypeDefault          = ""
 	BackupStorageTypePersistentVolume = "PersistentVolume"
 	BackupStorageTypeS3               = "S3"
 	BackupStorageTypeABS              = "ABS"
 	BackupStorageTypeSwift            = "Swift"
+	BackupStorageTypeGCS              = "GCS"
+
+	BackupCompressionNone = ""
+	BackupCompressionGzip = "gzip"
+	BackupCompressionZstd = "zstd"
+
+	// BackupDeletionPolicyRetain keeps the backup in its storage when the EtcdBackup is deleted.
+	BackupDeletionPolicyRetain = "Retain"
+	// BackupDeletionPolicyDelete deletes the backup from its storage before the EtcdBackup is released.
+	BackupDeletionPolicyDelete = "Delete"
 
 	AWSSecretCredentialsFileName = "credentials"
 	AWSSecretConfigFileName      = "config"
+	// S3CABundleFileName defines the key for the CA bundle in the secret referenced by S3Endpoint.CASecret
+	S3CABundleFileName = "ca.crt"
 
 	// ABSStorageAccount defines the key for the Azure Storage Account value in the ABS Kubernetes secret
 	ABSStorageAccount = "storage-account"
@@ -38,11 +61,64 @@ const (
 	SwiftUsername         = "username"
 	SwiftPassword         = "password"
 	SwiftDomainName       = "domainName"
+
+	// GCSServiceAccountJSON defines the key for the service account JSON key file in the GCS Kubernetes secret
+	GCSServiceAccountJSON = "service-account.json"
+
+	// HTTPCABundleFileName defines the key for the CA bundle in the secret referenced by HTTPRestoreSource.CASecret
+	HTTPCABundleFileName = "ca.crt"
+	// HTTPAuthToken defines the key for the bearer token in the secret referenced by HTTPRestoreSource.AuthSecret
+	HTTPAuthToken = "token"
+	// HTTPAuthUsername and HTTPAuthPassword define the keys for the basic auth credentials
+	// in the secret referenced by HTTPRestoreSource.AuthSecret
+	HTTPAuthUsername = "username"
+	HTTPAuthPassword = "password"
+
+	// BackupAPICertFileName and BackupAPIKeyFileName define the keys for the serving certificate and its key
+	// in the secret referenced by BackupAPIPolicy.ServerSecret
+	BackupAPICertFileName = "tls.crt"
+	BackupAPIKeyFileName  = "tls.key"
+	// BackupAPICAFileName defines the key for the CA bundle in the secret referenced by BackupAPIPolicy.ServerSecret,
+	// which verifies the serving certificate, and in the secret referenced by BackupAPIPolicy.ClientCASecret,
+	// which verifies the client certificates
+	BackupAPICAFileName = "ca.crt"
+	// BackupAPIAuthToken defines the key for the bearer token in the auth secrets of the backup APIs
+	BackupAPIAuthToken = "token"
 )
 
 var (
 	errPVZeroSize       = errors.New("PV backup should not have 0 size volume")
 	errPVNoStorageClass = errors.New("PV backup must have a storage class set")
+
+	errEncryptionNoSecret = errors.New("encryption must have a secret set")
+	errEncryptionNoKeyID  = errors.New("encryption must have a key ID set")
+	errUnknownCompression = errors.New("compression must be one of '', 'gzip' or 'zstd'")
+
+	errVerificationNoInterval = errors.New("verification interval must be > 0")
+	errVerificationTimeout    = errors.New("verification timeout must be >= 0")
+
+	errDeltaInterval = errors.New("delta interval must be >= 0")
+
+	errAPINoServerSecret = errors.New("backup API must have a server secret set")
+
+	errUploadPartSize    = errors.New("upload part size must be between 5 and 100 MB")
+	errUploadConcurrency = errors.New("upload concurrency must be >= 0")
+	errUploadMaxRetries  = errors.New("upload max retries must be >= 0")
+
+	errDestinationNoName      = errors.New("destination must have a name")
+	errDestinationStorageType = errors.New("destination storage type must be one of 'S3', 'ABS', 'Swift' or 'GCS'")
+	errDestinationNoSource    = errors.New("destination must have the source of its storage type set")
+
+	errBackupStorageType = errors.New("backup storage type must be one of 'S3', 'ABS', 'Swift', 'GCS' or 'PersistentVolume'")
+	errBackupNoSource    = errors.New("backup must have the source of its storage type set")
+	errDeletionPolicy    = errors.New("deletion policy must be one of '', 'Retain' or 'Delete'")
+
+	errScheduleInterval = errors.New("schedule and backup interval are mutually exclusive")
+	errJitter           = errors.New("jitter must be >= 0")
+
+	errRetentionNegative   = errors.New("retention counts must be >= 0")
+	errRetentionEmpty      = errors.New("retention must keep at least one backup")
+	errRetentionMaxBackups = errors.New("retention and MaxBackups are mutually exclusive")
 )
 
 type BackupPolicy struct {
@@ -59,20 +135,99 @@ type BackupPolicy struct {
 	// The default interval is 1800 seconds.
 	BackupIntervalInSecond int `json:"backupIntervalInSecond"`
 
+	// Schedule is a cron expression in UTC, e.g. "0 */6 * * *", telling when to back up.
+	// It replaces BackupIntervalInSecond.
+	Schedule string `json:"schedule,omitempty"`
+
+	// JitterInSecond is the maximum delay added to the scheduled backup times.
+	// The delay is derived from the cluster UID, so it is the same for every backup of a cluster
+	// and spreads the backups of clusters sharing a schedule.
+	// It defaults to the backup interval if Schedule is not set, and to no delay otherwise.
+	JitterInSecond int `json:"jitterInSecond,omitempty"`
+
 	// If greater than 0, MaxBackups is the maximum number of backup files to retain.
 	// If equal to 0, it means unlimited backups.
 	// Otherwise, it is invalid.
 	MaxBackups int `json:"maxBackups"`
 
+	// Retention selects the backups to keep by generation.
+	// It replaces MaxBackups, which is the same as a retention keeping the last MaxBackups backups.
+	Retention *BackupRetentionPolicy `json:"retention,omitempty"`
+
+	// DeltaIntervalInSecond enables incremental backups: the changes made since the latest
+	// backup are watched and written as delta chunks at this interval, so that a restore
+	// can replay them on top of the backup.
+	// If equal to 0, only full backups are taken.
+	DeltaIntervalInSecond int `json:"deltaIntervalInSecond,omitempty"`
+
+	// Destinations are secondary storages every backup is replicated to,
+	// besides the storage of StorageType. The backups are restored from them
+	// when the primary storage is unreachable.
+	Destinations []BackupDestination `json:"destinations,omitempty"`
+
 	// AutoDelete tells whether to cleanup backup data if cluster is deleted.
 	// By default (false), operator will keep the backup data.
 	AutoDelete bool `json:"autoDelete"`
+
+	// Encryption enables client-side encryption of the backups.
+	// If not set, backups are stored in plaintext.
+	Encryption *EncryptionPolicy `json:"encryption,omitempty"`
+
+	// Compression is the codec to compress backups with: "gzip" or "zstd".
+	// If not set, backups are stored uncompressed.
+	Compression BackupCompression `json:"compression,omitempty"`
+
+	// Verification enables the periodic verification of the latest backup
+	// by restoring it into a throwaway etcd.
+	// If not set, backups are not verified.
+	Verification *BackupVerificationPolicy `json:"verification,omitempty"`
+
+	// Upload tunes the multipart uploads of the backups to S3, ABS and Swift.
+	// If not set, the defaults apply.
+	Upload *BackupUploadPolicy `json:"upload,omitempty"`
+
+	// API secures the HTTP API of the backup sidecar with TLS and authentication.
+	// If not set, the API is served over plain HTTP to anyone reaching the backup service.
+	API *BackupAPIPolicy `json:"api,omitempty"`
 }
 
 func (bp *BackupPolicy) Validate() error {
 	if bp.MaxBackups < 0 {
 		return errors.New("MaxBackups value should be >= 0")
 	}
+	if bp.DeltaIntervalInSecond < 0 {
+		return errDeltaInterval
+	}
+	if len(bp.Schedule) != 0 {
+		if bp.BackupIntervalInSecond != 0 {
+			return errScheduleInterval
+		}
+		if err := cronutil.Validate(bp.Schedule); err != nil {
+			return err
+		}
+	}
+	if bp.JitterInSecond < 0 {
+		return errJitter
+	}
+	names := make(map[string]bool, len(bp.Destinations))
+	for i := range bp.Destinations {
+		d := &bp.Destinations[i]
+		if err := d.Validate(); err != nil {
+			return err
+		}
+		if names[d.Name] {
+			return fmt.Errorf("duplicate destination name %q", d.Name)
+		}
+		names[d.Name] = true
+	}
+	if bp.Retention != nil {
+		if bp.MaxBackups != 0 {
+			return errRetentionMaxBackups
+		}
+		if err := bp.Retention.Validate(); err != nil {
+			return err
+		}
+	}
 	if bp.StorageType == BackupStorageTypePersistentVolume {
 		pv := bp.StorageSource.PV
 		if pv == nil || pv.VolumeSizeInMB <= 0 {
@@ -82,6 +237,236 @@ func (bp *BackupPolicy) Validate() error {
 			return errPVNoStorageClass
 		}
 	}
+	if err := bp.Compression.Validate(); err != nil {
+		return err
+	}
+	if bp.Verification != nil {
+		if err := bp.Verification.Validate(); err != nil {
+			return err
+		}
+	}
+	if bp.Upload != nil {
+		if err := bp.Upload.Validate(); err != nil {
+			return err
+		}
+	}
+	if bp.API != nil {
+		if err := bp.API.Validate(); err != nil {
+			return err
+		}
+	}
+	if bp.Encryption != nil {
+		return bp.Encryption.Validate()
+	}
+	return nil
+}
+
+// BackupDestination is a secondary storage backups are replicated to.
+// Its credentials are read from the secret of its source.
+type BackupDestination struct {
+	// Name identifies the destination in the backup status.
+	Name string `json:"name"`
+
+	// StorageType is the type of the storage: "S3", "ABS", "Swift" or "GCS".
+	StorageType BackupStorageType `json:"storageType"`
+
+	StorageSource `json:",inline"`
+
+	// Retention selects the backups the destination keeps.
+	// If not set, the retention of the backup policy applies.
+	Retention *BackupRetentionPolicy `json:"retention,omitempty"`
+}
+
+func (d *BackupDestination) Validate() error {
+	if len(d.Name) == 0 {
+		return errDestinationNoName
+	}
+	var ok bool
+	switch d.StorageType {
+	case BackupStorageTypeS3:
+		ok = d.S3 != nil && len(d.S3.S3Bucket) != 0 && len(d.S3.AWSSecret) != 0
+	case BackupStorageTypeABS:
+		ok = d.ABS != nil
+	case BackupStorageTypeSwift:
+		ok = d.Swift != nil
+	case BackupStorageTypeGCS:
+		ok = d.GCS != nil
+	default:
+		return errDestinationStorageType
+	}
+	if !ok {
+		return errDestinationNoSource
+	}
+	if d.Retention != nil {
+		return d.Retention.Validate()
+	}
+	return nil
+}
+
+// RetentionPolicy returns the retention of the backups, or nil if all backups are kept.
+func (bp *BackupPolicy) RetentionPolicy() *BackupRetentionPolicy {
+	if bp.Retention != nil {
+		return bp.Retention
+	}
+	if bp.MaxBackups > 0 {
+		return &BackupRetentionPolicy{Last: bp.MaxBackups}
+	}
+	return nil
+}
+
+// BackupRetentionPolicy selects the backups to keep by generation
+// (grandfather-father-son): the newest backups, and the newest backup of each
+// of the most recent hours, days, weeks and months that have backups.
+// A backup is kept if any of the counts selects it, and purged otherwise.
+// Periods are in UTC and weeks are ISO weeks.
+type BackupRetentionPolicy struct {
+	// Last is the number of newest backups to keep.
+	Last int `json:"last,omitempty"`
+	// Hourly is the number of hours to keep the newest backup of.
+	Hourly int `json:"hourly,omitempty"`
+	// Daily is the number of days to keep the newest backup of.
+	Daily int `json:"daily,omitempty"`
+	// Weekly is the number of weeks to keep the newest backup of.
+	Weekly int `json:"weekly,omitempty"`
+	// Monthly is the number of months to keep the newest backup of.
+	Monthly int `json:"monthly,omitempty"`
+
+	// DryRun only logs the backups that would be purged and why, without deleting them.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+func (rp *BackupRetentionPolicy) Validate() error {
+	if rp.Last < 0 || rp.Hourly < 0 || rp.Daily < 0 || rp.Weekly < 0 || rp.Monthly < 0 {
+		return errRetentionNegative
+	}
+	if rp.Last == 0 && !rp.IsGenerational() {
+		return errRetentionEmpty
+	}
+	return nil
+}
+
+// IsGenerational returns true if the retention keeps backups by the time they were taken,
+// not only the newest ones.
+func (rp *BackupRetentionPolicy) IsGenerational() bool {
+	return rp.Hourly > 0 || rp.Daily > 0 || rp.Weekly > 0 || rp.Monthly > 0
+}
+
+// BackupVerificationPolicy defines how often the latest backup is verified.
+// A backup is verified by restoring it into a throwaway single-node etcd
+// and checking its revision and key count against the manifest of the backup.
+type BackupVerificationPolicy struct {
+	// IntervalInSecond specifies the interval between two verifications.
+	IntervalInSecond int `json:"intervalInSecond"`
+
+	// TimeoutInSecond is the time a verification may take before it fails.
+	// The default timeout is 600 seconds.
+	TimeoutInSecond int `json:"timeoutInSecond,omitempty"`
+}
+
+func (vp *BackupVerificationPolicy) Validate() error {
+	if vp.IntervalInSecond <= 0 {
+		return errVerificationNoInterval
+	}
+	if vp.TimeoutInSecond < 0 {
+		return errVerificationTimeout
+	}
+	return nil
+}
+
+// BackupUploadPolicy defines how backups are uploaded to object stores:
+// in parts, as they are taken, without a local copy.
+// Every part is held in memory until it is uploaded.
+type BackupUploadPolicy struct {
+	// PartSizeInMB is the size of the parts. It must be between 5 and 100.
+	// The default size is 16 MB.
+	PartSizeInMB int `json:"partSizeInMB,omitempty"`
+
+	// Concurrency is the number of parts uploaded at once.
+	// The default concurrency is 4.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// MaxRetries is the number of times the upload of a part is retried before the backup fails.
+	// The default is 3 retries.
+	MaxRetries int `json:"maxRetries,omitempty"`
+}
+
+func (up *BackupUploadPolicy) Validate() error {
+	// S3 requires parts of at least 5 MB, and ABS allows blocks of at most 100 MB.
+	if up.PartSizeInMB != 0 && (up.PartSizeInMB < 5 || up.PartSizeInMB > 100) {
+		return errUploadPartSize
+	}
+	if up.Concurrency < 0 {
+		return errUploadConcurrency
+	}
+	if up.MaxRetries < 0 {
+		return errUploadMaxRetries
+	}
+	return nil
+}
+
+func (c BackupCompression) Validate() error {
+	switch c {
+	case BackupCompressionNone, BackupCompressionGzip, BackupCompressionZstd:
+		return nil
+	}
+	return errUnknownCompression
+}
+
+func (p BackupDeletionPolicy) Validate() error {
+	switch p {
+	case "", BackupDeletionPolicyRetain, BackupDeletionPolicyDelete:
+		return nil
+	}
+	return errDeletionPolicy
+}
+
+// EncryptionPolicy defines how backups are encrypted with AES-256-GCM
+// before they leave the operator.
+type EncryptionPolicy struct {
+	// Secret is the name of the secret object that stores the AES-256 keys.
+	// Every entry of the secret is a key: the entry name is the key ID
+	// and the value is the 32 bytes key, raw or base64 encoded.
+	Secret string `json:"secret"`
+
+	// KeyID is the ID of the key to encrypt new backups with.
+	// The other keys in the secret are only used to decrypt existing backups,
+	// so a key is rotated by adding a new entry to the secret and pointing KeyID at it.
+	KeyID string `json:"keyID"`
+
+	// AllowUnencrypted allows reading the backups that are not encrypted,
+	// e.g. the ones taken before encryption was enabled.
+	// By default, they fail to be read, so that a tampered storage cannot substitute a plaintext backup.
+	AllowUnencrypted bool `json:"allowUnencrypted,omitempty"`
+}
+
+func (ep *EncryptionPolicy) Validate() error {
+	if len(ep.Secret) == 0 {
+		return errEncryptionNoSecret
+	}
+	if len(ep.KeyID) == 0 {
+		return errEncryptionNoKeyID
+	}
+	return nil
+}
+
+// BackupAPIPolicy defines how the HTTP API of the backup sidecar is secured.
+// The sidecar serves HTTPS and rejects the requests that authenticate with neither
+// the bearer token the operator provisions nor a verified client certificate.
+type BackupAPIPolicy struct {
+	// ServerSecret is the name of the secret object that stores the serving certificate of the sidecar
+	// and its key, valid for the "<cluster-name>-backup-sidecar" service, and the CA bundle that verifies it.
+	ServerSecret string `json:"serverSecret"`
+
+	// ClientCASecret is the name of the secret object that stores the CA bundle verifying
+	// the client certificates the sidecar accepts.
+	// If not set, only the bearer token authenticates requests.
+	ClientCASecret string `json:"clientCASecret,omitempty"`
+}
+
+func (ap *BackupAPIPolicy) Validate() error {
+	if len(ap.ServerSecret) == 0 {
+		return errAPINoServerSecret
+	}
 	return nil
 }
 
@@ -96,6 +481,8 @@ type StorageSource struct {
 	ABS *ABSSource `json:"abs,omitempty"`
 	// Swift represents an Openstack Swift Object Storage resource for storing etcd backups
 	Swift *SwiftSource `json:"swift,omitempty"`
+	// GCS represents a Google Cloud Storage resource for storing etcd backups
+	GCS *GCSSource `json:"gcs,omitempty"`
 }
 
 // TODO: support per cluster S3 Source configuration.
@@ -117,6 +504,29 @@ type S3Source struct {
 	//
 	// AWSSecret overwrites the default etcd operator wide AWS credential and config.
 	AWSSecret string `json:"awsSecret,omitempty"`
+
+	S3Endpoint `json:",inline"`
+}
+
+// S3Endpoint describes an S3-compatible object store (e.g. MinIO, Ceph RGW)
+// to use instead of AWS S3. Fields left empty fall back to the AWS config.
+type S3Endpoint struct {
+	// Endpoint is the URL of the S3-compatible object store,
+	// e.g. "https://minio.example.com:9000".
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region to sign requests for.
+	// It overwrites the region in the AWS config.
+	Region string `json:"region,omitempty"`
+
+	// ForcePathStyle addresses buckets as "<endpoint>/<bucket>" instead of
+	// "<bucket>.<endpoint>". Most S3-compatible stores require it.
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+
+	// CASecret is the name of the secret object that stores the CA bundle
+	// to verify the endpoint's certificate with.
+	// The file name of the CA bundle MUST be 'ca.crt'.
+	CASecret string `json:"caSecret,omitempty"`
 }
 
 // ABSSource represents an Azure Blob Storage (ABS) backup storage source
@@ -150,6 +560,23 @@ type SwiftSource struct {
 	SwiftRegion string `json:"swiftRegion,omitempty"`
 }
 
+// GCSSource represents a Google Cloud Storage (GCS) backup storage source
+type GCSSource struct {
+	// GCSBucket is the name of the GCS bucket to store backups in.
+	GCSBucket string `json:"gcsBucket,omitempty"`
+
+	// Prefix is the GCS prefix used to prefix the bucket path.
+	// After that, it will have version and cluster specific paths.
+	Prefix string `json:"prefix,omitempty"`
+
+	// GCSSecret is the name of the secret object that stores the GCS credentials.
+	//
+	// Within the secret object, the following field MUST be provided:
+	// 'service-account.json' holding a JSON key of a service account
+	// with read and write access to the bucket
+	GCSSecret string `json:"gcsSecret,omitempty"`
+}
+
 type BackupServiceStatus struct {
 	// RecentBackup is status of the most recent backup created by
 	// the backup service
@@ -160,6 +587,39 @@ type BackupServiceStatus struct {
 
 	// BackupSize is the total size of existing backups in MB.
 	BackupSize float64 `json:"backupSize"`
+
+	// LastVerifiedBackup is status of the most recent backup that passed verification.
+	LastVerifiedBackup *BackupStatus `json:"lastVerifiedBackup,omitempty"`
+
+	// LastVerificationError is the error of the last verification, if it failed.
+	LastVerificationError string `json:"lastVerificationError,omitempty"`
+
+	// NextScheduledBackup is the time of the next scheduled backup in RFC3339 format.
+	NextScheduledBackup string `json:"nextScheduledBackup,omitempty"`
+
+	// Destinations are the statuses of the secondary storages backups are replicated to.
+	Destinations []BackupDestinationStatus `json:"destinations,omitempty"`
+}
+
+type BackupDestinationStatus struct {
+	// Name is the name of the destination.
+	Name string `json:"name"`
+
+	// RecentBackup is status of the most recent backup in the destination.
+	RecentBackup *BackupStatus `json:"recentBackup,omitempty"`
+
+	// Backups is the total number of backups in the destination.
+	Backups int `json:"backups"`
+
+	// LagInRevisions is the number of revisions the most recent backup in the destination
+	// is behind the most recent backup in the primary storage.
+	LagInRevisions int64 `json:"lagInRevisions"`
+
+	// LastReplicationTime is the time of the last successful replication in RFC3339 format.
+	LastReplicationTime string `json:"lastReplicationTime,omitempty"`
+
+	// LastReplicationError is the error of the last replication, if it failed.
+	LastReplicationError string `json:"lastReplicationError,omitempty"`
 }
 
 type BackupStatus struct {
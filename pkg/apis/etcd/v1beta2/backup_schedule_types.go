@@ -0,0 +1,95 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta2
+
+import (
+	"github.com/coreos/etcd-operator/pkg/util/cronutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EtcdBackupScheduleList is a list of EtcdBackupSchedule.
+type EtcdBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []EtcdBackupSchedule `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// EtcdBackupSchedule represents a Kubernetes EtcdBackupSchedule Custom Resource.
+// The backup operator creates an EtcdBackup from it at every scheduled time.
+type EtcdBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              BackupScheduleSpec   `json:"spec"`
+	Status            BackupScheduleStatus `json:"status,omitempty"`
+}
+
+// AsOwner returns the owner reference of the EtcdBackup resources created from the schedule.
+func (s *EtcdBackupSchedule) AsOwner() metav1.OwnerReference {
+	trueVar := true
+	return metav1.OwnerReference{
+		APIVersion: SchemeGroupVersion.String(),
+		Kind:       EtcdBackupScheduleResourceKind,
+		Name:       s.Name,
+		UID:        s.UID,
+		Controller: &trueVar,
+	}
+}
+
+// BackupScheduleSpec contains the schedule of the backups of an etcd cluster.
+type BackupScheduleSpec struct {
+	// Schedule is the cron expression of the times to take backups at, in UTC,
+	// e.g. "0 */6 * * *" or "@daily".
+	Schedule string `json:"schedule"`
+	// BackupSpec is the spec of the EtcdBackup created at every scheduled time:
+	// the cluster to back up and the storage to save the backups to.
+	BackupSpec BackupSpec `json:"backupSpec"`
+	// Retention selects the successful backups of the schedule to keep.
+	// The EtcdBackup of every other backup is deleted with the backup it saved.
+	// If not set, all the backups are kept.
+	Retention *BackupRetentionPolicy `json:"retention,omitempty"`
+}
+
+func (ss *BackupScheduleSpec) Validate() error {
+	if err := cronutil.Validate(ss.Schedule); err != nil {
+		return err
+	}
+	if err := ss.BackupSpec.Validate(); err != nil {
+		return err
+	}
+	if ss.Retention != nil {
+		return ss.Retention.Validate()
+	}
+	return nil
+}
+
+// BackupScheduleStatus represents the status of the EtcdBackupSchedule Custom Resource.
+type BackupScheduleStatus struct {
+	// Reason indicates why the schedule is invalid. No backup is taken while it is set.
+	Reason string `json:"reason,omitempty"`
+	// LastScheduleTime is the scheduled time of the last EtcdBackup created, in RFC3339 format.
+	LastScheduleTime string `json:"lastScheduleTime,omitempty"`
+	// LastSuccessfulBackup is the name of the last EtcdBackup that succeeded.
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+	// LastSuccessfulTime is the creation time of the last EtcdBackup that succeeded, in RFC3339 format.
+	LastSuccessfulTime string `json:"lastSuccessfulTime,omitempty"`
+	// NextScheduleTime is the time of the next backup in RFC3339 format.
+	NextScheduleTime string `json:"nextScheduleTime,omitempty"`
+}
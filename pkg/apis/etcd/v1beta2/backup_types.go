@@ -40,15 +40,63 @@ type EtcdBackup struct {
 type BackupSpec struct {
 	// ClusterName is the etcd cluster name.
 	ClusterName string `json:"clusterName,omitempty"`
-	// StorageType is the etcd backup storage type.
+	// StorageType is the etcd backup storage type:
+	// "S3", "ABS", "Swift", "GCS" or "PersistentVolume".
 	StorageType string `json:"storageType"`
 	// BackupStorageSource is the backup storage source.
 	BackupStorageSource `json:",inline"`
+	// Encryption enables client-side encryption of the backup.
+	Encryption *EncryptionPolicy `json:"encryption,omitempty"`
+	// Compression is the codec to compress the backup with: "gzip" or "zstd".
+	Compression BackupCompression `json:"compression,omitempty"`
+	// DeletionPolicy is what happens to the backup when the EtcdBackup is deleted:
+	// "Retain" keeps it in its storage, "Delete" deletes it.
+	// If not set, the default is "Retain".
+	DeletionPolicy BackupDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// Validate checks that the storage type is supported and has its source set,
+// and that the encryption, compression and deletion policies are valid.
+func (bs *BackupSpec) Validate() error {
+	var ok bool
+	switch bs.StorageType {
+	case BackupStorageTypeS3:
+		ok = bs.S3 != nil
+	case BackupStorageTypeABS:
+		ok = bs.ABS != nil
+	case BackupStorageTypeSwift:
+		ok = bs.Swift != nil
+	case BackupStorageTypeGCS:
+		ok = bs.GCS != nil
+	case BackupStorageTypePersistentVolume:
+		// The backup is saved to the volume mounted in the backup operator.
+		ok = true
+	default:
+		return errBackupStorageType
+	}
+	if !ok {
+		return errBackupNoSource
+	}
+	if bs.Encryption != nil {
+		if err := bs.Encryption.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := bs.Compression.Validate(); err != nil {
+		return err
+	}
+	return bs.DeletionPolicy.Validate()
 }
 
 // BackupStorageSource contains the supported backup sources.
 type BackupStorageSource struct {
 	S3 *S3Source `json:"s3,omitempty"`
+	// ABS represents an Azure Blob Storage resource for storing etcd backups
+	ABS *ABSSource `json:"abs,omitempty"`
+	// Swift represents an Openstack Swift Object Storage resource for storing etcd backups
+	Swift *SwiftSource `json:"swift,omitempty"`
+	// GCS represents a Google Cloud Storage resource for storing etcd backups
+	GCS *GCSSource `json:"gcs,omitempty"`
 }
 
 // BackupCRStatus represents the status of the EtcdBackup Custom Resource.
@@ -60,4 +108,8 @@ type BackupCRStatus struct {
 	// If S3Source is used to store the backup, this field reports the
 	// S3 path where the backup is saved.
 	S3Path string `json:"s3Path,omitempty"`
+	// Path is where the backup is saved, whatever the storage type:
+	// "<bucket>/<object>" for object stores, or the path of the backup file
+	// on the volume of the backup operator for PersistentVolume.
+	Path string `json:"path,omitempty"`
 }
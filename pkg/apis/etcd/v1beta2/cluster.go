@@ -16,6 +16,7 @@ package v1beta2
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"k8s.io/api/core/v1"
@@ -27,6 +28,9 @@ const (
 	defaultVersion   = "3.1.8"
 
 	minPodPVSizeInMB = 512 // 512MiB
+
+	defaultQuotaHeadroomPercent = 25
+	defaultNearlyFullPercent    = 80
 )
 
 var (
@@ -77,6 +81,35 @@ type PVSource struct {
 	StorageClass string `json:"storageClass,omitempty"`
 }
 
+// StorageQuotaPolicy defines how the etcd backend quota is derived from the size of the data PV.
+type StorageQuotaPolicy struct {
+	// HeadroomPercent is the percentage of the data volume kept free for the WAL,
+	// snapshots and compaction. The backend quota is set to the rest of the volume.
+	// If not set, the default is 25.
+	HeadroomPercent int `json:"headroomPercent,omitempty"`
+
+	// NearlyFullPercent is the percentage of the backend quota above which the
+	// database of a member is reported as nearly full.
+	// If not set, the default is 80.
+	NearlyFullPercent int `json:"nearlyFullPercent,omitempty"`
+}
+
+// Headroom returns the headroom percentage, falling back to the default.
+func (sq *StorageQuotaPolicy) Headroom() int {
+	if sq == nil || sq.HeadroomPercent == 0 {
+		return defaultQuotaHeadroomPercent
+	}
+	return sq.HeadroomPercent
+}
+
+// NearlyFull returns the nearly full percentage, falling back to the default.
+func (sq *StorageQuotaPolicy) NearlyFull() int {
+	if sq == nil || sq.NearlyFullPercent == 0 {
+		return defaultNearlyFullPercent
+	}
+	return sq.NearlyFullPercent
+}
+
 type ClusterSpec struct {
 	// Size is the expected size of the etcd cluster.
 	// The etcd-operator will eventually make the size of the running
@@ -137,6 +170,11 @@ type RestorePolicy struct {
 	// StorageType specifies the type of storage device to store backup files.
 	// If not set, the default is "PersistentVolume".
 	StorageType BackupStorageType `json:"storageType"`
+
+	// RestoreTarget tells the point to restore the cluster to.
+	// It only applies to the restore of the cluster on creation.
+	// Later disaster recoveries restore the latest backup.
+	RestoreTarget `json:",inline"`
 }
 
 // PodPolicy defines the policy to create pod for the etcd container.
@@ -174,6 +212,16 @@ type PodPolicy struct {
 	// If defined new pods will use a persistent volume to store etcd data.
 	PV *PVSource `json:"pv,omitempty"`
 
+	// WAL represents a Persistent Volume resource dedicated to the etcd write-ahead log.
+	// If defined new pods will use a second persistent volume for the WAL, e.g. on a
+	// faster storage class. It requires PV to be set.
+	WAL *PVSource `json:"wal,omitempty"`
+
+	// StorageQuota tunes the etcd backend quota that the operator derives from the size
+	// of the PV. It only takes effect if PV is set and ETCD_QUOTA_BACKEND_BYTES is not
+	// set in EtcdEnv.
+	StorageQuota *StorageQuotaPolicy `json:"storageQuota,omitempty"`
+
 	// By default, kubernetes will mount a service account token into the etcd pods.
 	// AutomountServiceAccountToken indicates whether pods running with the service account should have an API token automatically mounted.
 	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
@@ -188,6 +236,11 @@ func (c *ClusterSpec) Validate() error {
 			return errors.New("spec: backup and restore storage types are different")
 		}
 	}
+	if c.Restore != nil {
+		if err := c.Restore.RestoreTarget.Validate(); err != nil {
+			return fmt.Errorf("spec: restore: %v", err)
+		}
+	}
 	if c.Backup != nil {
 		if err := c.Backup.Validate(); err != nil {
 			return err
@@ -210,6 +263,22 @@ func (c *ClusterSpec) Validate() error {
 				c.Pod.PV.VolumeSizeInMB = minPodPVSizeInMB
 			}
 		}
+		if sq := c.Pod.StorageQuota; sq != nil {
+			if sq.HeadroomPercent < 0 || sq.HeadroomPercent >= 100 {
+				return errors.New("spec: storage quota headroomPercent must be in [0, 100)")
+			}
+			if sq.NearlyFullPercent < 0 || sq.NearlyFullPercent > 100 {
+				return errors.New("spec: storage quota nearlyFullPercent must be in [0, 100]")
+			}
+		}
+		if c.Pod.WAL != nil {
+			if c.Pod.PV == nil {
+				return errors.New("spec: pod WAL volume requires pod PV to be set")
+			}
+			if c.Pod.WAL.VolumeSizeInMB < minPodPVSizeInMB {
+				c.Pod.WAL.VolumeSizeInMB = minPodPVSizeInMB
+			}
+		}
 	}
 	return nil
 }
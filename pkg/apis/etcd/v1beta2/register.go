@@ -28,6 +28,9 @@ const (
 	EtcdBackupResourceKind   = "EtcdBackup"
 	EtcdBackupResourcePlural = "etcdbackups"
 
+	EtcdBackupScheduleResourceKind   = "EtcdBackupSchedule"
+	EtcdBackupScheduleResourcePlural = "etcdbackupschedules"
+
 	EtcdRestoreResourceKind   = "EtcdRestore"
 	EtcdRestoreResourcePlural = "etcdrestores"
 )
@@ -36,10 +39,11 @@ var (
 	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
 	AddToScheme   = SchemeBuilder.AddToScheme
 
-	SchemeGroupVersion = schema.GroupVersion{Group: groupName, Version: "v1beta2"}
-	EtcdClusterCRDName = EtcdClusterResourcePlural + "." + groupName
-	EtcdBackupCRDName  = EtcdBackupResourcePlural + "." + groupName
-	EtcdRestoreCRDName = EtcdRestoreResourcePlural + "." + groupName
+	SchemeGroupVersion        = schema.GroupVersion{Group: groupName, Version: "v1beta2"}
+	EtcdClusterCRDName        = EtcdClusterResourcePlural + "." + groupName
+	EtcdBackupCRDName         = EtcdBackupResourcePlural + "." + groupName
+	EtcdBackupScheduleCRDName = EtcdBackupScheduleResourcePlural + "." + groupName
+	EtcdRestoreCRDName        = EtcdRestoreResourcePlural + "." + groupName
 )
 
 // Resource gets an EtcdCluster GroupResource for a specified resource
@@ -54,6 +58,8 @@ func addKnownTypes(s *runtime.Scheme) error {
 		&EtcdClusterList{},
 		&EtcdBackup{},
 		&EtcdBackupList{},
+		&EtcdBackupSchedule{},
+		&EtcdBackupScheduleList{},
 		&EtcdRestore{},
 		&EtcdRestoreList{},
 	)
@@ -14,7 +14,14 @@
 
 package v1beta2
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -45,11 +52,93 @@ type RestoreSpec struct {
 	ClusterSpec ClusterSpec `json:"clusterSpec"`
 	// RestoreSource tells the where to get the backup and restore from.
 	RestoreSource `json:",inline"`
+	// RestoreTarget tells the point to restore the cluster to.
+	// If it is set, the path of the restore source is the directory of the backups
+	// to pick the backup to restore from, e.g. "etcd-backups/v1/default/example-etcd-cluster".
+	RestoreTarget `json:",inline"`
+	// InPlace restores the existing EtcdCluster named after the restore CR in place, instead of creating it.
+	// The cluster keeps its services, so that its clients do not need to be re-created.
+	// The spec of the existing cluster is used and ClusterSpec is ignored.
+	// The cluster must have a backup policy to take a safety backup before its members are stopped.
+	InPlace bool `json:"inPlace,omitempty"`
+}
+
+var (
+	errTargetRevisionNegative = errors.New("targetRevision must be positive")
+	errTargetBothSet          = errors.New("targetRevision and targetTime cannot be both set")
+)
+
+// RestoreTarget is the point in the history of a cluster to restore it to.
+// The newest backup at or before the target is restored, with the deltas recorded
+// after it up to the target replayed.
+// At most one of its fields can be set. If none is, the backup is restored up to its latest revision.
+type RestoreTarget struct {
+	// TargetRevision is the etcd revision to restore the cluster to.
+	TargetRevision int64 `json:"targetRevision,omitempty"`
+	// TargetTime is the time to restore the cluster to in RFC3339 format,
+	// e.g. "2017-11-21T10:00:00Z".
+	TargetTime string `json:"targetTime,omitempty"`
+}
+
+// IsSet returns true if the target is set.
+func (t *RestoreTarget) IsSet() bool {
+	return t.TargetRevision != 0 || len(t.TargetTime) != 0
+}
+
+// Time returns the target time, or the zero time if it is not set.
+func (t *RestoreTarget) Time() (time.Time, error) {
+	if len(t.TargetTime) == 0 {
+		return time.Time{}, nil
+	}
+	tt, err := time.Parse(time.RFC3339, t.TargetTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid targetTime: %v", err)
+	}
+	return tt, nil
+}
+
+func (t *RestoreTarget) Validate() error {
+	if t.TargetRevision < 0 {
+		return errTargetRevisionNegative
+	}
+	if t.TargetRevision != 0 && len(t.TargetTime) != 0 {
+		return errTargetBothSet
+	}
+	_, err := t.Time()
+	return err
+}
+
+// RestoredSnapshot describes the snapshot a cluster was restored to.
+type RestoredSnapshot struct {
+	// Backup is the backup the cluster was restored from.
+	Backup string `json:"backup"`
+	// Revision is the etcd revision the cluster was restored to,
+	// after the deltas of the backup up to the target were replayed.
+	Revision int64 `json:"revision"`
 }
 
 type RestoreSource struct {
 	// S3 tells where on S3 the backup is saved and how to fetch the backup.
 	S3 *S3RestoreSource `json:"s3,omitempty"`
+
+	// ABS tells where on ABS the backup is saved and how to fetch the backup.
+	ABS *ABSRestoreSource `json:"abs,omitempty"`
+
+	// Swift tells where on Swift the backup is saved and how to fetch the backup.
+	Swift *SwiftRestoreSource `json:"swift,omitempty"`
+
+	// GCS tells where on GCS the backup is saved and how to fetch the backup.
+	GCS *GCSRestoreSource `json:"gcs,omitempty"`
+
+	// PV tells where on the backup volume of the restore operator the backup is saved.
+	PV *PVRestoreSource `json:"pv,omitempty"`
+
+	// HTTP tells the HTTPS URL the backup is served at and how to fetch the backup.
+	HTTP *HTTPRestoreSource `json:"http,omitempty"`
+
+	// Encryption holds the keys to decrypt the backup with
+	// if it was taken with encryption enabled.
+	Encryption *EncryptionPolicy `json:"encryption,omitempty"`
 }
 
 type S3RestoreSource struct {
@@ -65,6 +154,82 @@ type S3RestoreSource struct {
 	//
 	// AWSSecret overwrites the default etcd operator wide AWS credential and config.
 	AWSSecret string `json:"awsSecret"`
+
+	S3Endpoint `json:",inline"`
+}
+
+type ABSRestoreSource struct {
+	// Path is the full ABS path where the backup is saved.
+	// The format of the path must be: "<abs-container-name>/<path-to-backup-file>"
+	// e.g: "etcd-backups/v1/default/example-etcd-cluster/3.1.8_0000000000000001_etcd.backup"
+	Path string `json:"path"`
+
+	// The name of the secret object that stores the ABS credentials.
+	//
+	// Within the secret object, the following fields MUST be provided:
+	// 'storage-account' holding the Azure Storage account name
+	// 'storage-key' holding the Azure Storage account key
+	ABSSecret string `json:"absSecret"`
+}
+
+type SwiftRestoreSource struct {
+	// Path is the full Swift path where the backup is saved.
+	// The format of the path must be: "<swift-container-name>/<path-to-backup-file>"
+	// e.g: "etcd-backups/default/example-etcd-cluster/3.1.8_0000000000000001_etcd.backup"
+	Path string `json:"path"`
+
+	// The name of the secret object that stores the Openstack credentials.
+	//
+	// Within the secret object, the following fields MUST be provided:
+	// 'identityEndpoint' holding a valid Keystone identity URL
+	// 'tenantID' holding the tenantID
+	// 'username' holding user name
+	// 'password' holding user password
+	SwiftSecret string `json:"swiftSecret"`
+
+	// SwiftRegion is the name of region of openstack deployment.
+	SwiftRegion string `json:"swiftRegion,omitempty"`
+}
+
+type GCSRestoreSource struct {
+	// Path is the full GCS path where the backup is saved.
+	// The format of the path must be: "<gcs-bucket-name>/<path-to-backup-file>"
+	// e.g: "etcd-backups/v1/default/example-etcd-cluster/3.1.8_0000000000000001_etcd.backup"
+	Path string `json:"path"`
+
+	// The name of the secret object that stores the GCS credentials.
+	//
+	// Within the secret object, the following field MUST be provided:
+	// 'service-account.json' holding a JSON key of a service account
+	// with read access to the bucket
+	GCSSecret string `json:"gcsSecret"`
+}
+
+type PVRestoreSource struct {
+	// Path is the path of the backup file on the backup volume
+	// mounted at "/var/etcd-backup" in the restore operator pod.
+	// e.g: "/var/etcd-backup/v1/default/example-etcd-cluster/3.1.8_0000000000000001_etcd.backup"
+	Path string `json:"path"`
+}
+
+type HTTPRestoreSource struct {
+	// URL is the HTTPS URL the backup is served at.
+	// The manifest and delta chain of the backup are fetched next to it.
+	// e.g: "https://backups.example.com/v1/default/example-etcd-cluster/3.1.8_0000000000000001_etcd.backup"
+	URL string `json:"url"`
+
+	// CASecret is the name of the secret object that stores the CA bundle
+	// to verify the server's certificate with.
+	// The file name of the CA bundle MUST be 'ca.crt'.
+	CASecret string `json:"caSecret,omitempty"`
+
+	// AuthSecret is the name of the secret object that stores the credentials
+	// to fetch the backup with.
+	//
+	// Within the secret object, either of the following MUST be provided:
+	// 'token' holding a bearer token
+	// 'username' and 'password' holding the basic auth credentials
+	AuthSecret string `json:"authSecret,omitempty"`
 }
 
 // RestoreStatus reports the status of this restore operation.
@@ -73,4 +238,68 @@ type RestoreStatus struct {
 	Succeeded bool `json:"succeeded"`
 	// Reason indicates the reason for any backup related failures.
 	Reason string `json:"reason,omitempty"`
+	// Snapshot is the snapshot picked for the target of the restore.
+	// It is only set if the restore has a target.
+	Snapshot *RestoredSnapshot `json:"snapshot,omitempty"`
+	// Conditions are the phases an in-place restore went through.
+	Conditions []RestoreCondition `json:"conditions,omitempty"`
+}
+
+type RestoreConditionType string
+
+// The phases of an in-place restore, in the order they are gone through.
+const (
+	// RestoreConditionPaused is set once the etcd operator stopped managing the cluster.
+	RestoreConditionPaused RestoreConditionType = "Paused"
+	// RestoreConditionSafetyBackupTaken is set once a backup of the cluster before the restore is taken.
+	RestoreConditionSafetyBackupTaken RestoreConditionType = "SafetyBackupTaken"
+	// RestoreConditionMembersStopped is set once the members of the cluster are deleted.
+	RestoreConditionMembersStopped RestoreConditionType = "MembersStopped"
+	// RestoreConditionSeedRestored is set once the seed member restored from the backup is running.
+	RestoreConditionSeedRestored RestoreConditionType = "SeedRestored"
+	// RestoreConditionBackupsRolledBack is set once the backup sidecar retired the backups taken after the restored revision
+	// and took a backup of the restored seed.
+	RestoreConditionBackupsRolledBack RestoreConditionType = "BackupsRolledBack"
+	// RestoreConditionResumed is set once the etcd operator manages the cluster again.
+	RestoreConditionResumed RestoreConditionType = "Resumed"
+	// RestoreConditionMembersRegrown is set once the etcd operator grew the cluster back to its size.
+	RestoreConditionMembersRegrown RestoreConditionType = "MembersRegrown"
+)
+
+type RestoreCondition struct {
+	// Type of restore condition.
+	Type RestoreConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status v1.ConditionStatus `json:"status"`
+	// Last time the condition transitioned from one status to another.
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+	// A human readable message indicating details about the transition.
+	Message string `json:"message,omitempty"`
+}
+
+// HasCondition returns true if the restore went through the phase of the given condition type.
+func (rs *RestoreStatus) HasCondition(t RestoreConditionType) bool {
+	for _, c := range rs.Conditions {
+		if c.Type == t && c.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCondition records that the restore went through the phase of the given condition type.
+func (rs *RestoreStatus) SetCondition(t RestoreConditionType, message string) {
+	c := RestoreCondition{
+		Type:               t,
+		Status:             v1.ConditionTrue,
+		LastTransitionTime: time.Now().Format(time.RFC3339),
+		Message:            message,
+	}
+	for i := range rs.Conditions {
+		if rs.Conditions[i].Type == t {
+			rs.Conditions[i] = c
+			return
+		}
+	}
+	rs.Conditions = append(rs.Conditions, c)
 }
@@ -31,10 +31,12 @@ const (
 	ClusterPhaseFailed                = "Failed"
 
 	// See ./doc/user/conditions_and_events.md
-	ClusterConditionAvailable  ClusterConditionType = "Available"
-	ClusterConditionRecovering                      = "Recovering"
-	ClusterConditionScaling                         = "Scaling"
-	ClusterConditionUpgrading                       = "Upgrading"
+	ClusterConditionAvailable         ClusterConditionType = "Available"
+	ClusterConditionRecovering                             = "Recovering"
+	ClusterConditionScaling                                = "Scaling"
+	ClusterConditionUpgrading                              = "Upgrading"
+	ClusterConditionStorageNearlyFull                      = "StorageNearlyFull"
+	ClusterConditionMigrating                              = "Migrating"
 )
 
 type ClusterStatus struct {
@@ -70,6 +72,10 @@ type ClusterStatus struct {
 	// BackupServiceStatus only exists when backup is enabled in the
 	// cluster spec.
 	BackupServiceStatus *BackupServiceStatus `json:"backupServiceStatus,omitempty"`
+
+	// RestoredSnapshot is the snapshot the cluster was restored to on creation.
+	// It is only set if the restore policy of the cluster has a target.
+	RestoredSnapshot *RestoredSnapshot `json:"restoredSnapshot,omitempty"`
 }
 
 // ClusterCondition represents one current condition of an etcd cluster.
@@ -157,6 +163,18 @@ func (cs *ClusterStatus) SetUpgradingCondition(to string) {
 	cs.setClusterCondition(*c)
 }
 
+func (cs *ClusterStatus) SetStorageNearlyFullCondition(members []string, percent int) {
+	c := newClusterCondition(ClusterConditionStorageNearlyFull, v1.ConditionTrue, "Database nearly full",
+		fmt.Sprintf("database size of members %v is above %d%% of the backend quota", members, percent))
+	cs.setClusterCondition(*c)
+}
+
+func (cs *ClusterStatus) SetMigratingCondition(migrated, size int) {
+	c := newClusterCondition(ClusterConditionMigrating, v1.ConditionTrue, "Migrating to persistent volumes",
+		fmt.Sprintf("%d of %d members migrated", migrated, size))
+	cs.setClusterCondition(*c)
+}
+
 func (cs *ClusterStatus) SetReadyCondition() {
 	c := newClusterCondition(ClusterConditionAvailable, v1.ConditionTrue, "Cluster available", "")
 	cs.setClusterCondition(*c)
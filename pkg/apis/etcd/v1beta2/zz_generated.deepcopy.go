@@ -32,18 +32,46 @@ import (
 // Deprecated: deepcopy registration will go away when static deepcopy is fully implemented.
 func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 	return []conversion.GeneratedDeepCopyFunc{
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*ABSRestoreSource).DeepCopyInto(out.(*ABSRestoreSource))
+			return nil
+		}, InType: reflect.TypeOf(&ABSRestoreSource{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*ABSSource).DeepCopyInto(out.(*ABSSource))
 			return nil
 		}, InType: reflect.TypeOf(&ABSSource{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupAPIPolicy).DeepCopyInto(out.(*BackupAPIPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&BackupAPIPolicy{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*BackupCRStatus).DeepCopyInto(out.(*BackupCRStatus))
 			return nil
 		}, InType: reflect.TypeOf(&BackupCRStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupDestination).DeepCopyInto(out.(*BackupDestination))
+			return nil
+		}, InType: reflect.TypeOf(&BackupDestination{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupDestinationStatus).DeepCopyInto(out.(*BackupDestinationStatus))
+			return nil
+		}, InType: reflect.TypeOf(&BackupDestinationStatus{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*BackupPolicy).DeepCopyInto(out.(*BackupPolicy))
 			return nil
 		}, InType: reflect.TypeOf(&BackupPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupRetentionPolicy).DeepCopyInto(out.(*BackupRetentionPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&BackupRetentionPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupScheduleSpec).DeepCopyInto(out.(*BackupScheduleSpec))
+			return nil
+		}, InType: reflect.TypeOf(&BackupScheduleSpec{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupScheduleStatus).DeepCopyInto(out.(*BackupScheduleStatus))
+			return nil
+		}, InType: reflect.TypeOf(&BackupScheduleStatus{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*BackupServiceStatus).DeepCopyInto(out.(*BackupServiceStatus))
 			return nil
@@ -60,6 +88,14 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*BackupStorageSource).DeepCopyInto(out.(*BackupStorageSource))
 			return nil
 		}, InType: reflect.TypeOf(&BackupStorageSource{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupUploadPolicy).DeepCopyInto(out.(*BackupUploadPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&BackupUploadPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*BackupVerificationPolicy).DeepCopyInto(out.(*BackupVerificationPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&BackupVerificationPolicy{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*ClusterCondition).DeepCopyInto(out.(*ClusterCondition))
 			return nil
@@ -72,6 +108,10 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*ClusterStatus).DeepCopyInto(out.(*ClusterStatus))
 			return nil
 		}, InType: reflect.TypeOf(&ClusterStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*EncryptionPolicy).DeepCopyInto(out.(*EncryptionPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&EncryptionPolicy{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*EtcdBackup).DeepCopyInto(out.(*EtcdBackup))
 			return nil
@@ -80,6 +120,14 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*EtcdBackupList).DeepCopyInto(out.(*EtcdBackupList))
 			return nil
 		}, InType: reflect.TypeOf(&EtcdBackupList{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*EtcdBackupSchedule).DeepCopyInto(out.(*EtcdBackupSchedule))
+			return nil
+		}, InType: reflect.TypeOf(&EtcdBackupSchedule{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*EtcdBackupScheduleList).DeepCopyInto(out.(*EtcdBackupScheduleList))
+			return nil
+		}, InType: reflect.TypeOf(&EtcdBackupScheduleList{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*EtcdCluster).DeepCopyInto(out.(*EtcdCluster))
 			return nil
@@ -96,6 +144,18 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*EtcdRestoreList).DeepCopyInto(out.(*EtcdRestoreList))
 			return nil
 		}, InType: reflect.TypeOf(&EtcdRestoreList{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*GCSRestoreSource).DeepCopyInto(out.(*GCSRestoreSource))
+			return nil
+		}, InType: reflect.TypeOf(&GCSRestoreSource{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*GCSSource).DeepCopyInto(out.(*GCSSource))
+			return nil
+		}, InType: reflect.TypeOf(&GCSSource{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*HTTPRestoreSource).DeepCopyInto(out.(*HTTPRestoreSource))
+			return nil
+		}, InType: reflect.TypeOf(&HTTPRestoreSource{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*MemberSecret).DeepCopyInto(out.(*MemberSecret))
 			return nil
@@ -104,6 +164,10 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*MembersStatus).DeepCopyInto(out.(*MembersStatus))
 			return nil
 		}, InType: reflect.TypeOf(&MembersStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*PVRestoreSource).DeepCopyInto(out.(*PVRestoreSource))
+			return nil
+		}, InType: reflect.TypeOf(&PVRestoreSource{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*PVSource).DeepCopyInto(out.(*PVSource))
 			return nil
@@ -112,6 +176,10 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*PodPolicy).DeepCopyInto(out.(*PodPolicy))
 			return nil
 		}, InType: reflect.TypeOf(&PodPolicy{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*RestoreCondition).DeepCopyInto(out.(*RestoreCondition))
+			return nil
+		}, InType: reflect.TypeOf(&RestoreCondition{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*RestorePolicy).DeepCopyInto(out.(*RestorePolicy))
 			return nil
@@ -128,6 +196,18 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*RestoreStatus).DeepCopyInto(out.(*RestoreStatus))
 			return nil
 		}, InType: reflect.TypeOf(&RestoreStatus{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*RestoreTarget).DeepCopyInto(out.(*RestoreTarget))
+			return nil
+		}, InType: reflect.TypeOf(&RestoreTarget{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*RestoredSnapshot).DeepCopyInto(out.(*RestoredSnapshot))
+			return nil
+		}, InType: reflect.TypeOf(&RestoredSnapshot{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*S3Endpoint).DeepCopyInto(out.(*S3Endpoint))
+			return nil
+		}, InType: reflect.TypeOf(&S3Endpoint{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*S3RestoreSource).DeepCopyInto(out.(*S3RestoreSource))
 			return nil
@@ -144,10 +224,18 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 			in.(*StaticTLS).DeepCopyInto(out.(*StaticTLS))
 			return nil
 		}, InType: reflect.TypeOf(&StaticTLS{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*StorageQuotaPolicy).DeepCopyInto(out.(*StorageQuotaPolicy))
+			return nil
+		}, InType: reflect.TypeOf(&StorageQuotaPolicy{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*StorageSource).DeepCopyInto(out.(*StorageSource))
 			return nil
 		}, InType: reflect.TypeOf(&StorageSource{})},
+		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
+			in.(*SwiftRestoreSource).DeepCopyInto(out.(*SwiftRestoreSource))
+			return nil
+		}, InType: reflect.TypeOf(&SwiftRestoreSource{})},
 		{Fn: func(in interface{}, out interface{}, c *conversion.Cloner) error {
 			in.(*TLSPolicy).DeepCopyInto(out.(*TLSPolicy))
 			return nil
@@ -155,6 +243,22 @@ func GetGeneratedDeepCopyFuncs() []conversion.GeneratedDeepCopyFunc {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ABSRestoreSource) DeepCopyInto(out *ABSRestoreSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ABSRestoreSource.
+func (in *ABSRestoreSource) DeepCopy() *ABSRestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ABSRestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ABSSource) DeepCopyInto(out *ABSSource) {
 	*out = *in
@@ -171,6 +275,22 @@ func (in *ABSSource) DeepCopy() *ABSSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupAPIPolicy) DeepCopyInto(out *BackupAPIPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupAPIPolicy.
+func (in *BackupAPIPolicy) DeepCopy() *BackupAPIPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupAPIPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupCRStatus) DeepCopyInto(out *BackupCRStatus) {
 	*out = *in
@@ -187,6 +307,57 @@ func (in *BackupCRStatus) DeepCopy() *BackupCRStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupDestination) DeepCopyInto(out *BackupDestination) {
+	*out = *in
+	in.StorageSource.DeepCopyInto(&out.StorageSource)
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupRetentionPolicy)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupDestination.
+func (in *BackupDestination) DeepCopy() *BackupDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupDestinationStatus) DeepCopyInto(out *BackupDestinationStatus) {
+	*out = *in
+	if in.RecentBackup != nil {
+		in, out := &in.RecentBackup, &out.RecentBackup
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupStatus)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupDestinationStatus.
+func (in *BackupDestinationStatus) DeepCopy() *BackupDestinationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupDestinationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupPolicy) DeepCopyInto(out *BackupPolicy) {
 	*out = *in
@@ -200,6 +371,58 @@ func (in *BackupPolicy) DeepCopyInto(out *BackupPolicy) {
 		}
 	}
 	in.StorageSource.DeepCopyInto(&out.StorageSource)
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupRetentionPolicy)
+			**out = **in
+		}
+	}
+	if in.Destinations != nil {
+		in, out := &in.Destinations, &out.Destinations
+		*out = make([]BackupDestination, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(EncryptionPolicy)
+			**out = **in
+		}
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupVerificationPolicy)
+			**out = **in
+		}
+	}
+	if in.Upload != nil {
+		in, out := &in.Upload, &out.Upload
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupUploadPolicy)
+			**out = **in
+		}
+	}
+	if in.API != nil {
+		in, out := &in.API, &out.API
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupAPIPolicy)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -213,6 +436,64 @@ func (in *BackupPolicy) DeepCopy() *BackupPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRetentionPolicy) DeepCopyInto(out *BackupRetentionPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRetentionPolicy.
+func (in *BackupRetentionPolicy) DeepCopy() *BackupRetentionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRetentionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupScheduleSpec) DeepCopyInto(out *BackupScheduleSpec) {
+	*out = *in
+	in.BackupSpec.DeepCopyInto(&out.BackupSpec)
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupRetentionPolicy)
+			**out = **in
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupScheduleSpec.
+func (in *BackupScheduleSpec) DeepCopy() *BackupScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupScheduleStatus) DeepCopyInto(out *BackupScheduleStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupScheduleStatus.
+func (in *BackupScheduleStatus) DeepCopy() *BackupScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupServiceStatus) DeepCopyInto(out *BackupServiceStatus) {
 	*out = *in
@@ -225,6 +506,22 @@ func (in *BackupServiceStatus) DeepCopyInto(out *BackupServiceStatus) {
 			**out = **in
 		}
 	}
+	if in.LastVerifiedBackup != nil {
+		in, out := &in.LastVerifiedBackup, &out.LastVerifiedBackup
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(BackupStatus)
+			**out = **in
+		}
+	}
+	if in.Destinations != nil {
+		in, out := &in.Destinations, &out.Destinations
+		*out = make([]BackupDestinationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -242,6 +539,15 @@ func (in *BackupServiceStatus) DeepCopy() *BackupServiceStatus {
 func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
 	*out = *in
 	in.BackupStorageSource.DeepCopyInto(&out.BackupStorageSource)
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(EncryptionPolicy)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -283,6 +589,33 @@ func (in *BackupStorageSource) DeepCopyInto(out *BackupStorageSource) {
 			**out = **in
 		}
 	}
+	if in.ABS != nil {
+		in, out := &in.ABS, &out.ABS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ABSSource)
+			**out = **in
+		}
+	}
+	if in.Swift != nil {
+		in, out := &in.Swift, &out.Swift
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(SwiftSource)
+			**out = **in
+		}
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(GCSSource)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -296,6 +629,38 @@ func (in *BackupStorageSource) DeepCopy() *BackupStorageSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupUploadPolicy) DeepCopyInto(out *BackupUploadPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupUploadPolicy.
+func (in *BackupUploadPolicy) DeepCopy() *BackupUploadPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupUploadPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupVerificationPolicy) DeepCopyInto(out *BackupVerificationPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupVerificationPolicy.
+func (in *BackupVerificationPolicy) DeepCopy() *BackupVerificationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupVerificationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
 	*out = *in
@@ -391,6 +756,15 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 			(*in).DeepCopyInto(*out)
 		}
 	}
+	if in.RestoredSnapshot != nil {
+		in, out := &in.RestoredSnapshot, &out.RestoredSnapshot
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RestoredSnapshot)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -404,6 +778,22 @@ func (in *ClusterStatus) DeepCopy() *ClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionPolicy) DeepCopyInto(out *EncryptionPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionPolicy.
+func (in *EncryptionPolicy) DeepCopy() *EncryptionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EtcdBackup) DeepCopyInto(out *EtcdBackup) {
 	*out = *in
@@ -467,6 +857,69 @@ func (in *EtcdBackupList) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackupSchedule) DeepCopyInto(out *EtcdBackupSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackupSchedule.
+func (in *EtcdBackupSchedule) DeepCopy() *EtcdBackupSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackupSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdBackupSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackupScheduleList) DeepCopyInto(out *EtcdBackupScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdBackupSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackupScheduleList.
+func (in *EtcdBackupScheduleList) DeepCopy() *EtcdBackupScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackupScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdBackupScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	} else {
+		return nil
+	}
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EtcdCluster) DeepCopyInto(out *EtcdCluster) {
 	*out = *in
@@ -536,7 +989,7 @@ func (in *EtcdRestore) DeepCopyInto(out *EtcdRestore) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -593,6 +1046,54 @@ func (in *EtcdRestoreList) DeepCopyObject() runtime.Object {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCSRestoreSource) DeepCopyInto(out *GCSRestoreSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCSRestoreSource.
+func (in *GCSRestoreSource) DeepCopy() *GCSRestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GCSRestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCSSource) DeepCopyInto(out *GCSSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCSSource.
+func (in *GCSSource) DeepCopy() *GCSSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GCSSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRestoreSource) DeepCopyInto(out *HTTPRestoreSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRestoreSource.
+func (in *HTTPRestoreSource) DeepCopy() *HTTPRestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemberSecret) DeepCopyInto(out *MemberSecret) {
 	*out = *in
@@ -635,6 +1136,22 @@ func (in *MembersStatus) DeepCopy() *MembersStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVRestoreSource) DeepCopyInto(out *PVRestoreSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVRestoreSource.
+func (in *PVRestoreSource) DeepCopy() *PVRestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PVRestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PVSource) DeepCopyInto(out *PVSource) {
 	*out = *in
@@ -692,6 +1209,24 @@ func (in *PodPolicy) DeepCopyInto(out *PodPolicy) {
 			**out = **in
 		}
 	}
+	if in.WAL != nil {
+		in, out := &in.WAL, &out.WAL
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PVSource)
+			**out = **in
+		}
+	}
+	if in.StorageQuota != nil {
+		in, out := &in.StorageQuota, &out.StorageQuota
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(StorageQuotaPolicy)
+			**out = **in
+		}
+	}
 	if in.AutomountServiceAccountToken != nil {
 		in, out := &in.AutomountServiceAccountToken, &out.AutomountServiceAccountToken
 		if *in == nil {
@@ -714,6 +1249,22 @@ func (in *PodPolicy) DeepCopy() *PodPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreCondition) DeepCopyInto(out *RestoreCondition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreCondition.
+func (in *RestoreCondition) DeepCopy() *RestoreCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestorePolicy) DeepCopyInto(out *RestorePolicy) {
 	*out = *in
@@ -742,6 +1293,60 @@ func (in *RestoreSource) DeepCopyInto(out *RestoreSource) {
 			**out = **in
 		}
 	}
+	if in.ABS != nil {
+		in, out := &in.ABS, &out.ABS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(ABSRestoreSource)
+			**out = **in
+		}
+	}
+	if in.Swift != nil {
+		in, out := &in.Swift, &out.Swift
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(SwiftRestoreSource)
+			**out = **in
+		}
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(GCSRestoreSource)
+			**out = **in
+		}
+	}
+	if in.PV != nil {
+		in, out := &in.PV, &out.PV
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PVRestoreSource)
+			**out = **in
+		}
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(HTTPRestoreSource)
+			**out = **in
+		}
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(EncryptionPolicy)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -760,6 +1365,7 @@ func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 	*out = *in
 	in.ClusterSpec.DeepCopyInto(&out.ClusterSpec)
 	in.RestoreSource.DeepCopyInto(&out.RestoreSource)
+	out.RestoreTarget = in.RestoreTarget
 	return
 }
 
@@ -776,6 +1382,20 @@ func (in *RestoreSpec) DeepCopy() *RestoreSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
 	*out = *in
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(RestoredSnapshot)
+			**out = **in
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]RestoreCondition, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -789,6 +1409,54 @@ func (in *RestoreStatus) DeepCopy() *RestoreStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreTarget) DeepCopyInto(out *RestoreTarget) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreTarget.
+func (in *RestoreTarget) DeepCopy() *RestoreTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoredSnapshot) DeepCopyInto(out *RestoredSnapshot) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoredSnapshot.
+func (in *RestoredSnapshot) DeepCopy() *RestoredSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoredSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3Endpoint) DeepCopyInto(out *S3Endpoint) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Endpoint.
+func (in *S3Endpoint) DeepCopy() *S3Endpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(S3Endpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *S3RestoreSource) DeepCopyInto(out *S3RestoreSource) {
 	*out = *in
@@ -862,6 +1530,22 @@ func (in *StaticTLS) DeepCopy() *StaticTLS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageQuotaPolicy) DeepCopyInto(out *StorageQuotaPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageQuotaPolicy.
+func (in *StorageQuotaPolicy) DeepCopy() *StorageQuotaPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageQuotaPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageSource) DeepCopyInto(out *StorageSource) {
 	*out = *in
@@ -892,6 +1576,15 @@ func (in *StorageSource) DeepCopyInto(out *StorageSource) {
 			**out = **in
 		}
 	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(GCSSource)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -905,6 +1598,22 @@ func (in *StorageSource) DeepCopy() *StorageSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwiftRestoreSource) DeepCopyInto(out *SwiftRestoreSource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwiftRestoreSource.
+func (in *SwiftRestoreSource) DeepCopy() *SwiftRestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(SwiftRestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSPolicy) DeepCopyInto(out *TLSPolicy) {
 	*out = *in
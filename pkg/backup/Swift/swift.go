@@ -15,23 +15,38 @@
 package swift
 
 import (
+	"bytes"
+	"crypto/md5"
 	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd-operator/pkg/backup/multipart"
 
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/objectstorage/v1/containers"
 	"github.com/gophercloud/gophercloud/openstack/objectstorage/v1/objects"
 	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/sirupsen/logrus"
 )
 
 // Swift is a helper layer to wrap complex Swift logic.
+// Objects larger than a part are stored as static large objects, whose segments
+// are stored in the segment container "<container>_segments".
 type Swift struct {
 	container string
 	prefix    string
 	client    *gophercloud.ServiceClient
+	upload    multipart.Options
 }
 
 // New returns a Swift object for given container fetching the credentails from environment variables
@@ -47,6 +62,15 @@ func New(container, region, prefix string) (*Swift, error) {
 
 // NewFromAuthOpt returns a new Swift object for a given container using credentials passed in authOptions <ao>.
 func NewFromAuthOpt(container, region, prefix string, ao gophercloud.AuthOptions) (*Swift, error) {
+	client, err := NewClient(region, ao)
+	if err != nil {
+		return nil, err
+	}
+	return newFromClient(container, prefix, client), nil
+}
+
+// NewClient returns an object storage client for a given region using credentials passed in authOptions <ao>.
+func NewClient(region string, ao gophercloud.AuthOptions) (*gophercloud.ServiceClient, error) {
 	ao.AllowReauth = true
 	provider, err := openstack.NewClient(ao.IdentityEndpoint)
 	if err != nil {
@@ -69,7 +93,7 @@ func NewFromAuthOpt(container, region, prefix string, ao gophercloud.AuthOptions
 	if err != nil {
 		return nil, fmt.Errorf("create object storage client failed: %v", err)
 	}
-	return newFromClient(container, prefix, client), nil
+	return client, nil
 }
 
 // newFromClient returns a new Swift object for a given container using provided serviceClient <cli>.
@@ -81,44 +105,186 @@ func newFromClient(container, prefix string, cli *gophercloud.ServiceClient) *Sw
 	}
 }
 
-// Put stores the object content from <rs> at <key> in swift container.
-func (s *Swift) Put(key string, rs io.Reader) error {
+// SetUploadOptions sets the segment size, concurrency and retries of the uploads of Put.
+func (s *Swift) SetUploadOptions(o multipart.Options) {
+	s.upload = o
+}
+
+// Put stores the object content from <r> at <key> in swift container.
+// Content larger than a segment is uploaded in segments as it is read,
+// so that it is never held whole in memory.
+func (s *Swift) Put(key string, r io.Reader) error {
+	_, err := multipart.Upload(r, s.upload, &segmentUpload{s: s, name: path.Join(s.prefix, key)})
+	return err
+}
+
+// Path returns the "<container>/<object>" path of the object stored at key.
+func (s *Swift) Path(key string) string {
+	return path.Join(s.container, s.prefix, key)
+}
+
+// segmentContainer returns the container of the segments of the large objects.
+func (s *Swift) segmentContainer() string {
+	return s.container + "_segments"
+}
+
+// segmentUpload uploads an object as a static large object.
+type segmentUpload struct {
+	s    *Swift
+	name string
+	// segments is the prefix of the segments of the upload.
+	segments string
+
+	mu sync.Mutex
+	// uploaded are the segments uploaded, by part number.
+	uploaded map[int]sloSegment
+}
+
+// sloSegment is a segment in the manifest of a static large object.
+// Swift checks the ETag and size of every segment when the manifest is created.
+type sloSegment struct {
+	Path      string `json:"path"`
+	ETag      string `json:"etag"`
+	SizeBytes int    `json:"size_bytes"`
+}
+
+func (u *segmentUpload) Put(b []byte) error {
 	opts := objects.CreateOpts{
-		Content: rs,
+		Content: bytes.NewReader(b),
 	}
-	res := objects.Create(s.client, s.container, path.Join(s.prefix, key), opts)
-	return res.Err
+	if err := objects.Create(u.s.client, u.s.container, u.name, opts).Err; err != nil {
+		return err
+	}
+	// The object may have been a large object.
+	u.s.deleteOldSegments(u.name, "")
+	return nil
+}
+
+// Start creates the segment container if needed.
+// The segments of every upload have their own prefix, so that an upload does not
+// mix its segments with the ones of a previous upload of the same object.
+func (u *segmentUpload) Start() error {
+	u.segments = path.Join(u.name, strconv.FormatInt(time.Now().UnixNano(), 10))
+	u.uploaded = map[int]sloSegment{}
+	return containers.Create(u.s.client, u.s.segmentContainer(), nil).Err
+}
+
+func (u *segmentUpload) UploadPart(num int, b []byte) error {
+	sum := md5.Sum(b)
+	etag := hex.EncodeToString(sum[:])
+	name := path.Join(u.segments, fmt.Sprintf("%08d", num))
+	opts := objects.CreateOpts{
+		Content: bytes.NewReader(b),
+		ETag:    etag,
+	}
+	if err := objects.Create(u.s.client, u.s.segmentContainer(), name, opts).Err; err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.uploaded[num] = sloSegment{Path: path.Join(u.s.segmentContainer(), name), ETag: etag, SizeBytes: len(b)}
+	u.mu.Unlock()
+	return nil
+}
+
+// Complete creates the manifest of the large object, listing its segments,
+// and deletes the segments of the object it replaces.
+func (u *segmentUpload) Complete(parts int) error {
+	segs := make([]sloSegment, 0, parts)
+	for num := 1; num <= parts; num++ {
+		seg, ok := u.uploaded[num]
+		if !ok {
+			return fmt.Errorf("segment %d of %s was not uploaded", num, u.name)
+		}
+		segs = append(segs, seg)
+	}
+	url := u.s.client.ServiceURL(u.s.container, u.name) + "?multipart-manifest=put"
+	if _, err := u.s.client.Put(url, segs, nil, &gophercloud.RequestOpts{OkCodes: []int{201}}); err != nil {
+		return err
+	}
+	u.s.deleteOldSegments(u.name, u.segments)
+	return nil
+}
+
+func (u *segmentUpload) Abort() error {
+	return u.s.deleteSegments(u.segments)
+}
+
+// deleteOldSegments deletes the segments of the object <name> but the ones with the prefix <keep>, if set.
+// Failures are only logged: the object has been written, and its old segments are deleted with it eventually.
+func (s *Swift) deleteOldSegments(name, keep string) {
+	_, keys, err := s.list(s.segmentContainer(), name)
+	if _, ok := err.(gophercloud.ErrDefault404); ok {
+		return
+	}
+	if err != nil {
+		logrus.Warningf("failed to list old segments of %s: %v", name, err)
+		return
+	}
+	for _, key := range keys {
+		seg := path.Join(name, key)
+		if len(keep) != 0 && strings.HasPrefix(seg, keep+"/") {
+			continue
+		}
+		if err := objects.Delete(s.client, s.segmentContainer(), seg, nil).Err; err != nil {
+			logrus.Warningf("failed to delete old segment %s of %s: %v", seg, name, err)
+		}
+	}
+}
+
+// deleteSegments deletes the segments with the specified <prefix>.
+func (s *Swift) deleteSegments(prefix string) error {
+	_, keys, err := s.list(s.segmentContainer(), prefix)
+	if _, ok := err.(gophercloud.ErrDefault404); ok {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		result := objects.Delete(s.client, s.segmentContainer(), path.Join(prefix, key), nil)
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
 }
 
 // Get reads the content of object identified by <key> in swift container.
+// It fails with an error satisfying os.IsNotExist if there is none.
 func (s *Swift) Get(key string) (io.ReadCloser, error) {
 	resp := objects.Download(s.client, s.container, path.Join(s.prefix, key), nil)
+	if _, ok := resp.Err.(gophercloud.ErrDefault404); ok {
+		return nil, &os.PathError{Op: "get", Path: key, Err: os.ErrNotExist}
+	}
 	return resp.Body, resp.Err
 }
 
-// Delete deletes the object at <key> in swift container.
+// Delete deletes the object at <key> in swift container, and its segments if it is a large object.
 func (s *Swift) Delete(key string) error {
 	result := objects.Delete(s.client, s.container, path.Join(s.prefix, key), nil)
-	return result.Err
+	if result.Err != nil {
+		return result.Err
+	}
+	return s.deleteSegments(path.Join(s.prefix, key))
 }
 
 //List fetches the list of object keys in swift container.
 func (s *Swift) List() ([]string, error) {
-	_, l, err := s.list(s.prefix)
+	_, l, err := s.list(s.container, s.prefix)
 	return l, err
 }
 
-// list fetches the list of object keys with specified <prefix> in swift container.
+// list fetches the list of object keys with specified <prefix> in the given container.
 // It also returns the total size of listed objects.
-func (s *Swift) list(prefix string) (int64, []string, error) {
+func (s *Swift) list(container, prefix string) (int64, []string, error) {
 	keys := []string{}
 	var size int64
 	opts := &objects.ListOpts{
 		Full:   true,
-		Prefix: prefix,
+		Prefix: prefix + "/",
 	}
 	// Retrieve a pager (i.e. a paginated collection)
-	pager := objects.List(s.client, s.container, opts)
+	pager := objects.List(s.client, container, opts)
 	// Define an anonymous function to be executed on each page's iteration
 	err := pager.EachPage(func(page pagination.Page) (bool, error) {
 
@@ -140,14 +306,25 @@ func (s *Swift) list(prefix string) (int64, []string, error) {
 }
 
 // TotalSize returns the sum of size of objects in swift container.
+// The size of large objects is the size of their segments.
 func (s *Swift) TotalSize() (int64, error) {
-	size, _, err := s.list(s.prefix)
-	return size, err
+	size, _, err := s.list(s.container, s.prefix)
+	if err != nil {
+		return -1, err
+	}
+	segSize, _, err := s.list(s.segmentContainer(), s.prefix)
+	if _, ok := err.(gophercloud.ErrDefault404); ok {
+		return size, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return size + segSize, nil
 }
 
 // CopyPrefix copies the objects from specified <prefix> to prefix set in swift object.
 func (s *Swift) CopyPrefix(from string) error {
-	_, keys, err := s.list(from)
+	_, keys, err := s.list(s.container, from)
 	if err != nil {
 		return err
 	}
@@ -15,10 +15,16 @@
 package abs
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
 	"path"
 
+	"github.com/coreos/etcd-operator/pkg/backup/multipart"
+
 	"github.com/Azure/azure-sdk-for-go/storage"
 )
 
@@ -29,6 +35,7 @@ type ABS struct {
 	container *storage.Container
 	prefix    string
 	client    *storage.BlobStorageClient
+	upload    multipart.Options
 }
 
 // New returns a new ABS object for a given container using credentials set in the environment
@@ -62,13 +69,18 @@ func NewFromClient(container, prefix string, storageClient *storage.Client) (*AB
 	}, nil
 }
 
-// Put puts a chunk of data into a ABS container using the provided key for its reference
+// SetUploadOptions sets the block size, concurrency and retries of the uploads of Put.
+func (w *ABS) SetUploadOptions(o multipart.Options) {
+	w.upload = o
+}
+
+// Put puts a chunk of data into a ABS container using the provided key for its reference.
+// The data is uploaded in blocks as it is read, so that it is never held whole in memory.
 func (w *ABS) Put(key string, r io.Reader) error {
 	blobName := path.Join(v1, w.prefix, key)
 	blob := w.container.GetBlobReference(blobName)
 
-	putBlobOpts := storage.PutBlobOptions{}
-	err := blob.CreateBlockBlobFromReader(r, &putBlobOpts)
+	_, err := multipart.Upload(r, w.upload, &blockUpload{blob})
 	if err != nil {
 		return fmt.Errorf("create block blob from reader failed: %v", err)
 	}
@@ -76,13 +88,61 @@ func (w *ABS) Put(key string, r io.Reader) error {
 	return nil
 }
 
-// Get gets the blob object specified by key from a ABS container
+// blockUpload uploads a block blob block by block.
+type blockUpload struct {
+	blob *storage.Blob
+}
+
+func (u *blockUpload) Put(b []byte) error {
+	putBlobOpts := storage.PutBlobOptions{}
+	return u.blob.CreateBlockBlobFromReader(bytes.NewReader(b), &putBlobOpts)
+}
+
+func (u *blockUpload) Start() error {
+	return nil
+}
+
+func (u *blockUpload) UploadPart(num int, b []byte) error {
+	return u.blob.PutBlock(blockID(num), b, nil)
+}
+
+// Complete commits the uploaded blocks as the content of the blob.
+func (u *blockUpload) Complete(parts int) error {
+	blocks := make([]storage.Block, parts)
+	for i := range blocks {
+		blocks[i] = storage.Block{ID: blockID(i + 1), Status: storage.BlockStatusUncommitted}
+	}
+	return u.blob.PutBlockList(blocks, nil)
+}
+
+// Abort does nothing: uncommitted blocks are discarded by the storage service.
+func (u *blockUpload) Abort() error {
+	return nil
+}
+
+// blockID returns the ID of the block of the given number.
+// The IDs of the blocks of a blob must have the same length.
+func blockID(num int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", num)))
+}
+
+// Path returns the "<container>/<blob>" path of the object stored at key.
+func (w *ABS) Path(key string) string {
+	return path.Join(w.container.Name, v1, w.prefix, key)
+}
+
+// Get gets the blob object specified by key from a ABS container.
+// It fails with an error satisfying os.IsNotExist if there is none.
 func (w *ABS) Get(key string) (io.ReadCloser, error) {
 	blobName := path.Join(v1, w.prefix, key)
 	blob := w.container.GetBlobReference(blobName)
 
 	opts := &storage.GetBlobOptions{}
-	return blob.Get(opts)
+	rc, err := blob.Get(opts)
+	if serr, ok := err.(storage.AzureStorageServiceError); ok && serr.StatusCode == http.StatusNotFound {
+		return nil, &os.PathError{Op: "get", Path: key, Err: os.ErrNotExist}
+	}
+	return rc, err
 }
 
 // Delete deletes the blob object specified by key from a ABS container
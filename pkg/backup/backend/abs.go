@@ -17,9 +17,10 @@ package backend
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/abs"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
 	"github.com/sirupsen/logrus"
@@ -37,16 +38,15 @@ func NewAbsBackend(abs *abs.ABS) Backend {
 	return &absBackend{abs}
 }
 
-func (ab *absBackend) Save(version string, snapRev int64, r io.Reader) (int64, error) {
-	key := util.MakeBackupName(version, snapRev)
-
-	err := ab.ABS.Put(key, r)
+func (ab *absBackend) Save(key string, r io.Reader, m manifest.Manifest) (int64, error) {
+	h := manifest.NewHasher(r)
+	err := ab.ABS.Put(key, h)
 	if err != nil {
 		return -1, err
 	}
 
-	n, err := ab.getBlobSize(key)
-	if err != nil {
+	n := h.Size()
+	if err := saveManifest(key, h, m, ab.ABS.Put, ab.ABS.Delete); err != nil {
 		return -1, err
 	}
 
@@ -60,29 +60,47 @@ func (ab *absBackend) GetLatest() (string, error) {
 		return "", fmt.Errorf("failed to list abs container: %v", err)
 	}
 
-	return util.GetLatestBackupName(keys), nil
+	return getLatestWithManifest(keys, ab.ABS.Get)
+}
+
+func (ab *absBackend) GetChain(backup string) (string, error) {
+	keys, err := ab.ABS.List()
+	if err != nil {
+		return "", fmt.Errorf("failed to list abs container: %v", err)
+	}
+	return getChain(keys, backup), nil
 }
 
 func (ab *absBackend) Open(name string) (io.ReadCloser, error) {
-	return ab.ABS.Get(name)
+	return manifest.Open(ab.ABS.Get, name)
 }
 
-func (ab *absBackend) Purge(maxBackupFiles int) error {
-	names, err := ab.ABS.List()
+func (ab *absBackend) Manifest(name string) (*manifest.Manifest, error) {
+	return manifest.Load(ab.ABS.Get, name)
+}
+
+func (ab *absBackend) List() ([]string, error) {
+	keys, err := ab.ABS.List()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to list abs container: %v", err)
 	}
-	bnames := util.FilterAndSortBackups(names)
-	if len(bnames) < maxBackupFiles {
-		return nil
+	return util.FilterAndSortBackups(keys), nil
+}
+
+func (ab *absBackend) Delete(name string) error {
+	keys, err := ab.ABS.List()
+	if err != nil {
+		return fmt.Errorf("failed to list abs container: %v", err)
 	}
-	for i := 0; i < len(bnames)-maxBackupFiles; i++ {
-		err := ab.ABS.Delete(bnames[i])
-		if err != nil {
-			logrus.Errorf("fail to delete abs blob (%s): %v", bnames[i], err)
-		}
+	return deleteBackup(keys, name, ab.ABS.Delete)
+}
+
+func (ab *absBackend) Purge(p api.BackupRetentionPolicy) error {
+	names, err := ab.ABS.List()
+	if err != nil {
+		return err
 	}
-	return nil
+	return purge(names, p, ab.ABS.Get, ab.ABS.Delete)
 }
 
 func (ab *absBackend) Total() (int, error) {
@@ -96,16 +114,3 @@ func (ab *absBackend) Total() (int, error) {
 func (ab *absBackend) TotalSize() (int64, error) {
 	return ab.ABS.TotalSize()
 }
-
-func (ab *absBackend) getBlobSize(key string) (int64, error) {
-	rc, err := ab.Open(key)
-
-	// TODO: use less expensive method of getting byte array length
-	b, err := ioutil.ReadAll(rc)
-	if err != nil {
-		rc.Close()
-		return -1, err
-	}
-
-	return int64(len(b)), rc.Close()
-}
@@ -23,7 +23,9 @@ import (
 	"reflect"
 	"testing"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/abs"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
@@ -115,10 +117,10 @@ func TestABSBackendGetLatest(t *testing.T) {
 	}
 	ab := &absBackend{ABS: abs}
 
-	if _, err := ab.Save("3.1.0", 1, bytes.NewBuffer([]byte(blobContents))); err != nil {
+	if _, err := ab.Save(util.MakeBackupName("3.1.0", 1), bytes.NewBuffer([]byte(blobContents)), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := ab.Save("3.1.1", 2, bytes.NewBuffer([]byte(blobContents))); err != nil {
+	if _, err := ab.Save(util.MakeBackupName("3.1.1", 2), bytes.NewBuffer([]byte(blobContents)), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -198,13 +200,13 @@ func TestABSBackendPurge(t *testing.T) {
 	}
 	ab := &absBackend{ABS: abs}
 
-	if _, err := ab.Save("3.1.0", 1, bytes.NewBuffer([]byte(blobContents))); err != nil {
+	if _, err := ab.Save(util.MakeBackupName("3.1.0", 1), bytes.NewBuffer([]byte(blobContents)), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := ab.Save("3.1.0", 2, bytes.NewBuffer([]byte(blobContents))); err != nil {
+	if _, err := ab.Save(util.MakeBackupName("3.1.0", 2), bytes.NewBuffer([]byte(blobContents)), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
-	if err := ab.Purge(1); err != nil {
+	if err := ab.Purge(api.BackupRetentionPolicy{Last: 1}); err != nil {
 		t.Fatal(err)
 	}
 	names, err := abs.List()
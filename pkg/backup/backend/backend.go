@@ -14,27 +14,51 @@
 
 package backend
 
-import "io"
+import (
+	"io"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+)
 
 // Backend defines required backend operations
 type Backend interface {
-	// Save saves the backup from the given reader with given etcd version and revision.
+	// Save saves the backup from the given reader under the given name,
+	// and the manifest m completed with the SHA-256 and size of the saved backup next to it.
+	// Delta chunks and chains are saved the same way.
 	// It returns the size of the snapshot saved.
-	Save(etcdVersion string, rev int64, r io.Reader) (size int64, err error)
+	Save(name string, r io.Reader, m manifest.Manifest) (size int64, err error)
 
-	// GetLatest gets latest backup's name.
+	// GetLatest gets latest backup's name, skipping backups without a valid manifest.
 	// If no backup is available, returns empty string name.
 	GetLatest() (name string, err error)
 
-	// Open opens a backup file for reading
+	// GetChain gets the name of the delta chain of the given backup.
+	// If the backup has no delta chain, returns empty string name.
+	GetChain(backup string) (name string, err error)
+
+	// Open opens a backup file for reading.
+	// Reading fails with a *manifest.MismatchError if the backup does not match its manifest.
 	Open(name string) (rc io.ReadCloser, err error)
 
+	// Manifest returns the manifest of the given backup.
+	Manifest(name string) (*manifest.Manifest, error)
+
 	// Total returns the total number of available backups.
 	Total() (int, error)
 
 	// TotalSize returns the total size of the backups.
 	TotalSize() (int64, error)
 
-	// Purge purges backup files when backups are greater than maxBackupFiles.
-	Purge(maxBackupFiles int) error
+	// List returns the names of the backups, sorted by revision.
+	List() ([]string, error)
+
+	// Delete deletes the given backup with its manifest and delta chain.
+	// Deleting a backup that does not exist fails with an error satisfying os.IsNotExist.
+	Delete(name string) error
+
+	// Purge purges the backups the retention policy does not keep,
+	// with their manifests and delta chains.
+	// If the policy is a dry run, it only logs the backups it would purge and why.
+	Purge(p api.BackupRetentionPolicy) error
 }
@@ -0,0 +1,29 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import "github.com/coreos/etcd-operator/pkg/backup/util"
+
+// getChain returns the name of the delta chain of the given backup if it is in names.
+// Otherwise, it returns an empty string.
+func getChain(names []string, backup string) string {
+	chain := util.ChainName(backup)
+	for _, n := range names {
+		if n == chain {
+			return n
+		}
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"io"
+
+	"github.com/coreos/etcd-operator/pkg/backup/encryption"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+)
+
+// ensure encryptedBackend satisfies backend interface.
+var _ Backend = &encryptedBackend{}
+
+// encryptedBackend encrypts backups before saving them to the wrapped backend
+// and decrypts them when they are opened.
+type encryptedBackend struct {
+	Backend
+	keyring *encryption.Keyring
+}
+
+// NewEncryptedBackend wraps be so that backups are encrypted with the keyring.
+func NewEncryptedBackend(be Backend, kr *encryption.Keyring) Backend {
+	return &encryptedBackend{Backend: be, keyring: kr}
+}
+
+func (eb *encryptedBackend) Save(name string, r io.Reader, m manifest.Manifest) (int64, error) {
+	return eb.Backend.Save(name, eb.keyring.Encrypt(r), m)
+}
+
+func (eb *encryptedBackend) Open(name string) (io.ReadCloser, error) {
+	rc, err := eb.Backend.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := eb.keyring.Decrypt(rc)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return readCloser{r, rc}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/etcd-operator/pkg/backup/encryption"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+)
+
+func TestEncryptedBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-operator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, util.BackupTmpDir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	fb := NewFileBackend(dir)
+
+	// a backup taken before encryption was enabled
+	plainName := util.MakeBackupName("3.1.0", 1)
+	if _, err := fb.Save(util.MakeBackupName("3.1.0", 1), bytes.NewBufferString("plain"), manifest.Manifest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err := encryption.NewKeyring(map[string][]byte{"k1": make([]byte, encryption.KeySize)}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr.SetAllowUnencrypted(true)
+	eb := NewEncryptedBackend(fb, kr)
+	if _, err := eb.Save(util.MakeBackupName("3.1.0", 2), bytes.NewBufferString("secret"), manifest.Manifest{}); err != nil {
+		t.Fatal(err)
+	}
+	encName := util.MakeBackupName("3.1.0", 2)
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, encName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("secret")) {
+		t.Errorf("backup %s is stored in plaintext", encName)
+	}
+
+	for name, want := range map[string]string{plainName: "plain", encName: "secret"} {
+		rc, err := eb.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("content of %s = %q, want %q", name, b, want)
+		}
+	}
+}
@@ -19,9 +19,10 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
 	"github.com/sirupsen/logrus"
@@ -39,8 +40,26 @@ func NewFileBackend(dir string) Backend {
 	return &fileBackend{dir}
 }
 
-func (fb *fileBackend) Save(version string, snapRev int64, rc io.Reader) (int64, error) {
-	filename := util.MakeBackupName(version, snapRev)
+func (fb *fileBackend) Save(filename string, rc io.Reader, m manifest.Manifest) (int64, error) {
+	h := manifest.NewHasher(rc)
+	n, err := fb.save(filename, h)
+	if err != nil {
+		return -1, err
+	}
+	put := func(name string, r io.Reader) error {
+		_, err := fb.save(name, r)
+		return err
+	}
+	if err := saveManifest(filename, h, m, put, fb.remove); err != nil {
+		return -1, err
+	}
+
+	logrus.Infof("saved snapshot %s (size: %d) successfully", filepath.Join(fb.dir, filename), n)
+	return n, nil
+}
+
+// save writes the file atomically by renaming it from the tmp dir.
+func (fb *fileBackend) save(filename string, rc io.Reader) (int64, error) {
 	tmpfile, err := os.OpenFile(filepath.Join(fb.dir, util.BackupTmpDir, filename), os.O_WRONLY|os.O_TRUNC|os.O_CREATE, util.BackupFilePerm)
 	if err != nil {
 		return -1, fmt.Errorf("failed to create snapshot tempfile: %v", err)
@@ -63,8 +82,6 @@ func (fb *fileBackend) Save(version string, snapRev int64, rc io.Reader) (int64,
 		os.Remove(tmpfile.Name())
 		return -1, fmt.Errorf("rename snapshot from %s to %s failed: %v", tmpfile.Name(), nextSnapshotName, err)
 	}
-
-	logrus.Infof("saved snapshot %s (size: %d) successfully", nextSnapshotName, n)
 	return n, nil
 }
 
@@ -79,41 +96,60 @@ func (fb *fileBackend) GetLatest() (string, error) {
 		names = append(names, f.Name())
 	}
 
-	fn := util.GetLatestBackupName(names)
-	if fn == "" {
-		return "", nil
+	return getLatestWithManifest(names, fb.open)
+}
+
+func (fb *fileBackend) GetChain(backup string) (string, error) {
+	names, err := fb.list()
+	if err != nil {
+		return "", err
 	}
-	return fn, err
+	return getChain(names, backup), nil
 }
 
 func (fb *fileBackend) Open(name string) (io.ReadCloser, error) {
+	return manifest.Open(fb.open, name)
+}
+
+func (fb *fileBackend) open(name string) (io.ReadCloser, error) {
 	return os.Open(filepath.Join(fb.dir, name))
 }
 
-func (fb *fileBackend) Purge(maxBackupFiles int) error {
-	files, err := ioutil.ReadDir(fb.dir)
+func (fb *fileBackend) Manifest(name string) (*manifest.Manifest, error) {
+	return manifest.Load(fb.open, name)
+}
+
+func (fb *fileBackend) List() ([]string, error) {
+	names, err := fb.list()
+	if err != nil {
+		return nil, err
+	}
+	return util.FilterAndSortBackups(names), nil
+}
+
+func (fb *fileBackend) Delete(name string) error {
+	names, err := fb.list()
 	if err != nil {
 		return err
 	}
+	return deleteBackup(names, name, fb.remove)
+}
 
-	var names []string
-	for _, f := range files {
-		names = append(names, f.Name())
+func (fb *fileBackend) Purge(p api.BackupRetentionPolicy) error {
+	names, err := fb.list()
+	if err != nil {
+		return err
 	}
+	return purge(names, p, fb.open, fb.remove)
+}
 
-	bnames := util.FilterAndSortBackups(names)
-	if len(bnames) < maxBackupFiles {
+// remove removes the file of the given name. Removing a missing file succeeds.
+func (fb *fileBackend) remove(name string) error {
+	err := os.Remove(filepath.Join(fb.dir, name))
+	if os.IsNotExist(err) {
 		return nil
 	}
-	for i := 0; i < len(bnames)-maxBackupFiles; i++ {
-		err := os.Remove(path.Join(fb.dir, bnames[i]))
-		if err != nil {
-			logrus.Errorf("failed to remove backup file (%s): %v", bnames[i], err)
-		} else {
-			logrus.Infof("removed backup file: %s", bnames[i])
-		}
-	}
-	return nil
+	return err
 }
 
 func (fb *fileBackend) Total() (int, error) {
@@ -130,6 +166,18 @@ func (fb *fileBackend) Total() (int, error) {
 	return len(util.FilterAndSortBackups(names)), nil
 }
 
+func (fb *fileBackend) list() ([]string, error) {
+	files, err := ioutil.ReadDir(fb.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dir (%s): error (%v)", fb.dir, err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	return names, nil
+}
+
 func (fb *fileBackend) TotalSize() (int64, error) {
 	files, err := ioutil.ReadDir(fb.dir)
 	if err != nil {
@@ -15,12 +15,19 @@
 package backend
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 )
 
@@ -40,11 +47,20 @@ func TestFileBackendGetLatest(t *testing.T) {
 	}
 	fb := &fileBackend{dir}
 	for _, n := range names {
-		err := ioutil.WriteFile(filepath.Join(dir, n), []byte(n), 0600)
+		err := writeBackupFile(dir, n, []byte(n))
 		if err != nil {
 			t.Fatal(err)
 		}
 	}
+	// the latest backup has an invalid manifest and must be skipped.
+	err = writeBackupFile(dir, util.MakeBackupName("3.0.3", 20), []byte("ignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, manifest.Name(util.MakeBackupName("3.0.3", 20))), []byte("not json"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	name, err := fb.GetLatest()
 	if err != nil {
@@ -71,6 +87,104 @@ func TestFileBackendGetLatest(t *testing.T) {
 	}
 }
 
+func TestFileBackendGetLatestWithoutManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-operator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fb := &fileBackend{dir}
+	old := util.MakeBackupName("3.1.0", 1)
+	if err := writeBackupFile(dir, old, []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	// the newest backup was taken before manifests were introduced, and is not picked as the latest.
+	legacy := util.MakeBackupName("3.1.0", 2)
+	if err := ioutil.WriteFile(filepath.Join(dir, legacy), []byte("legacy"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := fb.GetLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != old {
+		t.Errorf("latest name = %s, want %s", name, old)
+	}
+	// it is still read unverified when asked for by name.
+	rc, err := fb.Open(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "legacy" {
+		t.Errorf("content = %s, want legacy", string(b))
+	}
+}
+
+func TestSaveManifestFailure(t *testing.T) {
+	objects := map[string]bool{"backup": true}
+	put := func(name string, r io.Reader) error {
+		return errors.New("transient error")
+	}
+	del := func(name string) error {
+		delete(objects, name)
+		return nil
+	}
+	h := manifest.NewHasher(strings.NewReader("backup"))
+	if _, err := ioutil.ReadAll(h); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveManifest("backup", h, manifest.Manifest{}, put, del); err == nil {
+		t.Error("expect saving the manifest to fail")
+	}
+	if objects["backup"] {
+		t.Error("backup without a manifest is left in the storage")
+	}
+}
+
+func TestGetLatestWithManifestReadError(t *testing.T) {
+	names := []string{util.MakeBackupName("3.1.0", 1), util.MakeBackupName("3.1.0", 2)}
+	open := func(name string) (io.ReadCloser, error) {
+		return nil, errors.New("transient error")
+	}
+	// a failure to read the manifest of the latest backup must not fall back to an older backup.
+	if name, err := getLatestWithManifest(names, open); err == nil {
+		t.Errorf("expect error, got latest backup %q", name)
+	}
+}
+
+func TestFileBackendOpenVerifies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-operator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fb := &fileBackend{dir}
+	name := util.MakeBackupName("3.1.0", 1)
+	if err := writeBackupFile(dir, name, []byte("snapshot")); err != nil {
+		t.Fatal(err)
+	}
+	// truncate the backup after its manifest was written.
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("snap"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := fb.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	_, err = ioutil.ReadAll(rc)
+	if _, ok := err.(*manifest.MismatchError); !ok {
+		t.Errorf("expect manifest mismatch error, got %v", err)
+	}
+}
+
 func TestFileBackendPurge(t *testing.T) {
 	tests := []struct {
 		maxFiles  int
@@ -113,6 +227,21 @@ func TestFileBackendPurge(t *testing.T) {
 			util.MakeBackupName("3.1.0", 3), // keep two of the highest revs
 		},
 		leftFiles: []string{util.MakeBackupName("3.1.0", 2), util.MakeBackupName("3.1.0", 3)},
+	}, {
+		maxFiles: 1,
+		files: []string{
+			util.MakeBackupName("3.1.0", 1),
+			util.MakeDeltaName("3.1.0", 1, 5), // deltas go with their backup
+			util.ChainName(util.MakeBackupName("3.1.0", 1)),
+			util.MakeBackupName("3.1.0", 6),
+			util.MakeDeltaName("3.1.0", 6, 8),
+			util.ChainName(util.MakeBackupName("3.1.0", 6)),
+		},
+		leftFiles: []string{
+			util.MakeDeltaName("3.1.0", 6, 8),
+			util.MakeBackupName("3.1.0", 6),
+			util.ChainName(util.MakeBackupName("3.1.0", 6)),
+		},
 	}}
 
 	for i, tt := range tests {
@@ -122,12 +251,12 @@ func TestFileBackendPurge(t *testing.T) {
 		}
 		fb := &fileBackend{dir}
 		for _, name := range tt.files {
-			err := ioutil.WriteFile(filepath.Join(dir, name), []byte("ignore"), 0600)
+			err := writeBackupFile(dir, name, []byte("ignore"))
 			if err != nil {
 				t.Fatal(err)
 			}
 		}
-		fb.Purge(tt.maxFiles)
+		fb.Purge(api.BackupRetentionPolicy{Last: tt.maxFiles})
 		infos, err := ioutil.ReadDir(dir)
 		if err != nil {
 			t.Fatal(err)
@@ -135,6 +264,12 @@ func TestFileBackendPurge(t *testing.T) {
 
 		var names []string
 		for _, f := range infos {
+			if strings.HasSuffix(f.Name(), manifest.Suffix) {
+				if _, err := os.Stat(filepath.Join(dir, strings.TrimSuffix(f.Name(), manifest.Suffix))); err != nil {
+					t.Errorf("#%d: manifest %s left without its backup", i, f.Name())
+				}
+				continue
+			}
 			names = append(names, f.Name())
 		}
 		if !reflect.DeepEqual(tt.leftFiles, names) {
@@ -146,3 +281,225 @@ func TestFileBackendPurge(t *testing.T) {
 		}
 	}
 }
+
+func TestFileBackendPurgeGenerations(t *testing.T) {
+	day := func(d, h int) string {
+		return time.Date(2017, 11, d, h, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+	backups := []struct {
+		rev          int64
+		creationTime string
+	}{
+		{1, day(1, 10)},
+		{2, day(1, 12)},
+		{3, day(2, 9)},
+		{4, day(3, 8)},
+		{5, day(3, 9)},
+	}
+	tests := []struct {
+		policy    api.BackupRetentionPolicy
+		leftFiles []string
+	}{{
+		policy: api.BackupRetentionPolicy{Daily: 2},
+		leftFiles: []string{
+			util.MakeBackupName("3.1.0", 3),
+			util.MakeBackupName("3.1.0", 5),
+		},
+	}, {
+		policy: api.BackupRetentionPolicy{Last: 2, Daily: 3},
+		leftFiles: []string{
+			util.MakeBackupName("3.1.0", 2),
+			util.MakeBackupName("3.1.0", 3),
+			util.MakeBackupName("3.1.0", 4),
+			util.MakeBackupName("3.1.0", 5),
+		},
+	}, {
+		// a dry run deletes nothing
+		policy: api.BackupRetentionPolicy{Last: 1, DryRun: true},
+		leftFiles: []string{
+			util.MakeBackupName("3.1.0", 1),
+			util.MakeBackupName("3.1.0", 2),
+			util.MakeBackupName("3.1.0", 3),
+			util.MakeBackupName("3.1.0", 4),
+			util.MakeBackupName("3.1.0", 5),
+		},
+	}}
+
+	for i, tt := range tests {
+		dir, err := ioutil.TempDir("", "etcd-operator-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		fb := &fileBackend{dir}
+		for _, b := range backups {
+			m := manifest.Manifest{Revision: b.rev, CreationTime: b.creationTime}
+			if err := writeBackupFileWithManifest(dir, util.MakeBackupName("3.1.0", b.rev), []byte("ignore"), m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := fb.Purge(tt.policy); err != nil {
+			t.Fatal(err)
+		}
+		names, err := fb.list()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if left := util.FilterAndSortBackups(names); !reflect.DeepEqual(tt.leftFiles, left) {
+			t.Errorf("#%d: left files after purge, want=%v, get=%v", i, tt.leftFiles, left)
+		}
+	}
+}
+
+func TestFileBackendPurgeUnknownCreationTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-operator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fb := &fileBackend{dir}
+	// the oldest backup was taken before manifests were introduced.
+	legacy := util.MakeBackupName("3.1.0", 1)
+	if err := ioutil.WriteFile(filepath.Join(dir, legacy), []byte("legacy"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, rev := range []int64{2, 3} {
+		m := manifest.Manifest{Revision: rev, CreationTime: time.Date(2017, 11, int(rev), 10, 0, 0, 0, time.UTC).Format(time.RFC3339)}
+		if err := writeBackupFileWithManifest(dir, util.MakeBackupName("3.1.0", rev), []byte("ignore"), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := fb.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a manifest that cannot be read fails the purge before anything is deleted.
+	failing := func(name string) (io.ReadCloser, error) {
+		if name == manifest.Name(util.MakeBackupName("3.1.0", 2)) {
+			return nil, errors.New("transient error")
+		}
+		return fb.open(name)
+	}
+	deleted := false
+	del := func(name string) error {
+		deleted = true
+		return nil
+	}
+	if err := purge(names, api.BackupRetentionPolicy{Daily: 1}, failing, del); err == nil || deleted {
+		t.Errorf("purge error = %v, deleted = %v, want error and nothing deleted", err, deleted)
+	}
+
+	if err := fb.Purge(api.BackupRetentionPolicy{Daily: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if names, err = fb.list(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{legacy, util.MakeBackupName("3.1.0", 3)}
+	if left := util.FilterAndSortBackups(names); !reflect.DeepEqual(want, left) {
+		t.Errorf("left files after purge, want=%v, get=%v", want, left)
+	}
+}
+
+func TestFileBackendGetChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-operator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fb := &fileBackend{dir}
+
+	withChain := util.MakeCompressedBackupName("3.1.0", 1, api.BackupCompressionGzip)
+	withoutChain := util.MakeBackupName("3.1.0", 2)
+	for _, name := range []string{withChain, util.ChainName(withChain), withoutChain} {
+		if err := writeBackupFile(dir, name, []byte("ignore")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		backup string
+		chain  string
+	}{
+		{withChain, util.ChainName(withChain)},
+		{withoutChain, ""},
+	}
+	for i, tt := range tests {
+		chain, err := fb.GetChain(tt.backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chain != tt.chain {
+			t.Errorf("#%d: chain = %q, want %q", i, chain, tt.chain)
+		}
+	}
+}
+
+func TestFileBackendListDelete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-operator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fb := &fileBackend{dir}
+
+	b1 := util.MakeBackupName("3.1.0", 1)
+	b2 := util.MakeBackupName("3.1.0", 2)
+	for _, name := range []string{b2, b1, util.MakeDeltaName("3.1.0", 1, 5), util.ChainName(b1)} {
+		if err := writeBackupFile(dir, name, []byte("ignore")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := fb.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{b1, b2}; !reflect.DeepEqual(names, want) {
+		t.Errorf("backups = %v, want %v", names, want)
+	}
+
+	if err := fb.Delete(b1); err != nil {
+		t.Fatal(err)
+	}
+	if err := fb.Delete(b1); !os.IsNotExist(err) {
+		t.Errorf("expect deleting a missing backup to fail with not exist, got %v", err)
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, f := range infos {
+		left = append(left, f.Name())
+	}
+	// the delta chain is deleted with its backup.
+	if want := []string{b2, manifest.Name(b2)}; !reflect.DeepEqual(left, want) {
+		t.Errorf("left files after delete = %v, want %v", left, want)
+	}
+}
+
+// writeBackupFile writes the backup and its manifest to dir.
+func writeBackupFile(dir, name string, data []byte) error {
+	return writeBackupFileWithManifest(dir, name, data, manifest.Manifest{})
+}
+
+func writeBackupFileWithManifest(dir, name string, data []byte, m manifest.Manifest) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		return err
+	}
+	h := manifest.NewHasher(bytes.NewReader(data))
+	if _, err := ioutil.ReadAll(h); err != nil {
+		return err
+	}
+	mr, err := manifestReader(h, m)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadAll(mr)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, manifest.Name(name)), b, 0600)
+}
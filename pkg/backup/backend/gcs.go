@@ -0,0 +1,119 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"io"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/gcs"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ensure gcsBackend satisfies backend interface.
+var _ Backend = &gcsBackend{}
+
+// gcsBackend is the Google Cloud Storage backend.
+type gcsBackend struct {
+	GCS *gcs.GCS
+}
+
+func NewGCSBackend(gcs *gcs.GCS) Backend {
+	return &gcsBackend{gcs}
+}
+
+func (gb *gcsBackend) Save(key string, r io.Reader, m manifest.Manifest) (int64, error) {
+	h := manifest.NewHasher(r)
+	err := gb.GCS.Put(key, h)
+	if err != nil {
+		return -1, err
+	}
+
+	if err := saveManifest(key, h, m, gb.GCS.Put, gb.GCS.Delete); err != nil {
+		return -1, err
+	}
+	n, err := gb.GCS.Size(key)
+	if err != nil {
+		return -1, err
+	}
+
+	logrus.Infof("saved backup %s (size: %d) successfully", key, n)
+	return n, nil
+}
+
+func (gb *gcsBackend) GetLatest() (string, error) {
+	keys, err := gb.GCS.List()
+	if err != nil {
+		return "", fmt.Errorf("failed to list gcs bucket: %v", err)
+	}
+
+	return getLatestWithManifest(keys, gb.GCS.Get)
+}
+
+func (gb *gcsBackend) GetChain(backup string) (string, error) {
+	keys, err := gb.GCS.List()
+	if err != nil {
+		return "", fmt.Errorf("failed to list gcs bucket: %v", err)
+	}
+	return getChain(keys, backup), nil
+}
+
+func (gb *gcsBackend) Open(name string) (io.ReadCloser, error) {
+	return manifest.Open(gb.GCS.Get, name)
+}
+
+func (gb *gcsBackend) Manifest(name string) (*manifest.Manifest, error) {
+	return manifest.Load(gb.GCS.Get, name)
+}
+
+func (gb *gcsBackend) List() ([]string, error) {
+	keys, err := gb.GCS.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gcs bucket: %v", err)
+	}
+	return util.FilterAndSortBackups(keys), nil
+}
+
+func (gb *gcsBackend) Delete(name string) error {
+	keys, err := gb.GCS.List()
+	if err != nil {
+		return fmt.Errorf("failed to list gcs bucket: %v", err)
+	}
+	return deleteBackup(keys, name, gb.GCS.Delete)
+}
+
+func (gb *gcsBackend) Purge(p api.BackupRetentionPolicy) error {
+	names, err := gb.GCS.List()
+	if err != nil {
+		return err
+	}
+	return purge(names, p, gb.GCS.Get, gb.GCS.Delete)
+}
+
+func (gb *gcsBackend) Total() (int, error) {
+	names, err := gb.GCS.List()
+	if err != nil {
+		return -1, err
+	}
+	return len(util.FilterAndSortBackups(names)), err
+}
+
+func (gb *gcsBackend) TotalSize() (int64, error) {
+	return gb.GCS.TotalSize()
+}
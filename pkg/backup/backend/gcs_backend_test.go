@@ -0,0 +1,201 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/gcs"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+)
+
+// fakeGCSEndpoint is the endpoint of a local fsouza/fake-gcs-server started with "-scheme http".
+const fakeGCSEndpoint = "http://127.0.0.1:4443"
+
+var gcsIntegrationTestNotSet = fmt.Sprintf("skipping GCS integration test due to %s not set", integrationTestEnvVar)
+
+// generateRandomBucketName returns a random name that is valid as a GCS bucket name.
+func generateRandomBucketName(t *testing.T) string {
+	name, err := generateRandomContainerName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.ToLower(name)
+}
+
+func createGCSBucket(t *testing.T) string {
+	bucket := generateRandomBucketName(t)
+	body := fmt.Sprintf(`{"name": %q}`, bucket)
+	resp, err := http.Post(fakeGCSEndpoint+"/storage/v1/b?project=test", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create bucket failed: status code = %d", resp.StatusCode)
+	}
+	return bucket
+}
+
+func TestGCSBackendBucketDoesNotExist(t *testing.T) {
+	if os.Getenv(integrationTestEnvVar) != "true" {
+		t.Skip(gcsIntegrationTestNotSet)
+	}
+
+	bucket := generateRandomBucketName(t)
+	_, err := gcs.NewFromClient(bucket, prefix, fakeGCSEndpoint, http.DefaultClient)
+	if err == nil {
+		t.Fatal("expect error for bucket that does not exist")
+	}
+	if err.Error() != fmt.Sprintf("bucket %s does not exist", bucket) {
+		t.Fatal(err)
+	}
+}
+
+func TestGCSBackendGetLatest(t *testing.T) {
+	if os.Getenv(integrationTestEnvVar) != "true" {
+		t.Skip(gcsIntegrationTestNotSet)
+	}
+
+	bucket := createGCSBucket(t)
+	g, err := gcs.NewFromClient(bucket, prefix, fakeGCSEndpoint, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb := &gcsBackend{GCS: g}
+
+	if _, err := gb.Save(util.MakeBackupName("3.1.0", 1), bytes.NewBuffer([]byte(blobContents)), manifest.Manifest{}); err != nil {
+		t.Fatal(err)
+	}
+	n, err := gb.Save(util.MakeBackupName("3.1.1", 2), bytes.NewBuffer([]byte(blobContents)), manifest.Manifest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(blobContents)) {
+		t.Errorf("saved size = %d, want %d", n, len(blobContents))
+	}
+
+	// test getLatest
+	name, err := gb.GetLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := util.MakeBackupName("3.1.1", 2)
+	if name != expected {
+		t.Errorf("lastest name = %s, want %s", name, expected)
+	}
+
+	// test total
+	totalBackups, err := gb.Total()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totalBackups != 2 {
+		t.Errorf("total backups = %v, want %v", totalBackups, 2)
+	}
+	totalSize, err := gb.TotalSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the total size includes the manifests stored next to the backups.
+	if totalSize <= int64(2*len(blobContents)) {
+		t.Errorf("total size = %v, want more than %v", totalSize, 2*len(blobContents))
+	}
+
+	// test open
+	rc, err := gb.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != blobContents {
+		t.Errorf("content = %s, want %s", string(b), blobContents)
+	}
+}
+
+func TestGCSBackendPurge(t *testing.T) {
+	if os.Getenv(integrationTestEnvVar) != "true" {
+		t.Skip(gcsIntegrationTestNotSet)
+	}
+
+	bucket := createGCSBucket(t)
+	g, err := gcs.NewFromClient(bucket, prefix, fakeGCSEndpoint, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb := &gcsBackend{GCS: g}
+
+	if _, err := gb.Save(util.MakeBackupName("3.1.0", 1), bytes.NewBuffer([]byte(blobContents)), manifest.Manifest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gb.Save(util.MakeBackupName("3.1.0", 2), bytes.NewBuffer([]byte(blobContents)), manifest.Manifest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gb.Purge(api.BackupRetentionPolicy{Last: 1}); err != nil {
+		t.Fatal(err)
+	}
+	names, err := g.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	leftFiles := []string{util.MakeBackupName("3.1.0", 2)}
+	if !reflect.DeepEqual(leftFiles, names) {
+		t.Errorf("left files after purge, want=%v, get=%v", leftFiles, names)
+	}
+}
+
+func TestGCSCopyPrefix(t *testing.T) {
+	if os.Getenv(integrationTestEnvVar) != "true" {
+		t.Skip(gcsIntegrationTestNotSet)
+	}
+
+	bucket := createGCSBucket(t)
+	from, err := gcs.NewFromClient(bucket, "ns/from", fakeGCSEndpoint, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := util.MakeBackupName("3.1.0", 1)
+	if err := from.Put(name, bytes.NewBufferString(blobContents)); err != nil {
+		t.Fatal(err)
+	}
+
+	to, err := gcs.NewFromClient(bucket, "ns/to", fakeGCSEndpoint, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := to.CopyPrefix("ns/from"); err != nil {
+		t.Fatal(err)
+	}
+	names, err := to.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]string{name}, names) {
+		t.Errorf("copied files, want=%v, get=%v", []string{name}, names)
+	}
+}
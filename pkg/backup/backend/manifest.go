@@ -0,0 +1,79 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	"github.com/sirupsen/logrus"
+)
+
+// manifestReader returns the encoded manifest of the backup read through h.
+func manifestReader(h *manifest.Hasher, m manifest.Manifest) (*bytes.Reader, error) {
+	b, err := json.Marshal(h.Complete(m))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+// saveManifest saves the manifest of the object key read through h with put.
+// If it fails, the object is deleted with del, so that it is not left without a manifest.
+func saveManifest(key string, h *manifest.Hasher, m manifest.Manifest, put func(string, io.Reader) error, del func(string) error) error {
+	mr, err := manifestReader(h, m)
+	if err == nil {
+		err = put(manifest.Name(key), mr)
+	}
+	if err == nil {
+		return nil
+	}
+	for _, name := range []string{key, manifest.Name(key)} {
+		if derr := del(name); derr != nil && !os.IsNotExist(derr) {
+			logrus.Errorf("failed to delete %s after its manifest failed to save: %v", name, derr)
+		}
+	}
+	return fmt.Errorf("failed to save manifest: %v", err)
+}
+
+// getLatestWithManifest returns the latest backup in names that has a valid manifest.
+// Backups without a manifest, such as the ones taken before manifests were introduced, and backups with an invalid manifest are skipped.
+// If there is none, it returns an empty string.
+// It fails if a manifest cannot be read, rather than falling back to an older backup.
+func getLatestWithManifest(names []string, open manifest.OpenFunc) (string, error) {
+	bnames := util.FilterAndSortBackups(names)
+	for i := len(bnames) - 1; i >= 0; i-- {
+		_, err := manifest.Load(open, bnames[i])
+		if _, ok := err.(*manifest.InvalidError); ok {
+			logrus.Warningf("skipping backup %s: %v", bnames[i], err)
+			continue
+		}
+		if os.IsNotExist(err) {
+			logrus.Warningf("skipping backup %s without a manifest", bnames[i])
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to load manifest of backup %s: %v", bnames[i], err)
+		}
+		return bnames[i], nil
+	}
+	return "", nil
+}
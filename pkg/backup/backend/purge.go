@@ -0,0 +1,120 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/retention"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	"github.com/sirupsen/logrus"
+)
+
+// purge deletes the backups among names that the retention policy p does not keep,
+// with their manifests and delta chains. open opens an object by name for reading
+// and del deletes it. If p is a dry run, it only logs the objects it would delete.
+// It deletes nothing if the creation time of a backup cannot be read.
+func purge(names []string, p api.BackupRetentionPolicy, open manifest.OpenFunc, del func(name string) error) error {
+	var backups []retention.Backup
+	for _, name := range util.FilterAndSortBackups(names) {
+		b := retention.Backup{Name: name, Revision: util.MustParseRevision(name)}
+		if p.IsGenerational() {
+			t, err := creationTime(open, name)
+			if err != nil {
+				return err
+			}
+			b.CreationTime = t
+		}
+		backups = append(backups, b)
+	}
+
+	for _, d := range retention.Evaluate(p, backups) {
+		if d.Keep {
+			continue
+		}
+		objs := append([]string{d.Name, manifest.Name(d.Name)}, util.ChainObjects(names, d.Name)...)
+		if p.DryRun {
+			logrus.Infof("dry run: would purge backup %s (%s), deleting %s", d.Name, d.Reason, strings.Join(objs, ", "))
+			continue
+		}
+		if err := deleteObjects(objs, del); err != nil {
+			logrus.Errorf("failed to delete backup (%s): %v", d.Name, err)
+			continue
+		}
+		logrus.Infof("purged backup %s (%s)", d.Name, d.Reason)
+	}
+	return nil
+}
+
+// deleteBackup deletes the backup of the given name with its manifest and delta chain.
+// names are the names of all the objects of the storage.
+func deleteBackup(names []string, name string, del func(name string) error) error {
+	found := false
+	for _, n := range util.FilterAndSortBackups(names) {
+		if n == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return &os.PathError{Op: "delete", Path: name, Err: os.ErrNotExist}
+	}
+	objs := append([]string{name, manifest.Name(name)}, util.ChainObjects(names, name)...)
+	if err := deleteObjects(objs, del); err != nil {
+		return err
+	}
+	logrus.Infof("deleted backup %s", name)
+	return nil
+}
+
+// deleteObjects deletes the backup objs[0], then the other objects of the backup.
+// Once the backup is deleted, failing to delete the other objects is only logged.
+func deleteObjects(objs []string, del func(name string) error) error {
+	if err := del(objs[0]); err != nil {
+		return err
+	}
+	for _, name := range objs[1:] {
+		if err := del(name); err != nil {
+			logrus.Errorf("failed to delete backup object (%s): %v", name, err)
+		}
+	}
+	return nil
+}
+
+// creationTime returns the creation time recorded in the manifest of the backup,
+// or the zero time if it is unknown: the backup has no manifest, or an invalid one.
+// It fails if the manifest cannot be read.
+func creationTime(open manifest.OpenFunc, name string) (time.Time, error) {
+	m, err := manifest.Load(open, name)
+	if _, ok := err.(*manifest.InvalidError); ok || os.IsNotExist(err) {
+		logrus.Warningf("unknown creation time of backup (%s): %v", name, err)
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load manifest of backup (%s): %v", name, err)
+	}
+	t, err := time.Parse(time.RFC3339, m.CreationTime)
+	if err != nil {
+		logrus.Warningf("bad creation time of backup (%s): %v", name, err)
+		return time.Time{}, nil
+	}
+	return t, nil
+}
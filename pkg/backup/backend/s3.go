@@ -17,20 +17,15 @@ package backend
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/s3"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	tmpDir              = "/tmp"
-	tmpBackupFilePrefix = "etcd-backup-"
-)
-
 // ensure s3Backend satisfies backend interface.
 var _ Backend = &s3Backend{}
 
@@ -43,29 +38,16 @@ func NewS3Backend(s3 *s3.S3) Backend {
 	return &s3Backend{s3}
 }
 
-func (sb *s3Backend) Save(version string, snapRev int64, rc io.Reader) (int64, error) {
-	// make a local file copy of the backup first, since s3 requires io.ReadSeeker.
-	tmpfile, err := ioutil.TempFile(tmpDir, tmpBackupFilePrefix)
-	if err != nil {
-		return -1, fmt.Errorf("failed to create snapshot tempfile: %v", err)
-	}
-	defer func() {
-		tmpfile.Close()
-		os.Remove(tmpfile.Name())
-	}()
-
-	n, err := io.Copy(tmpfile, rc)
-	if err != nil {
-		return -1, fmt.Errorf("failed to save snapshot to tmpfile: %v", err)
-	}
-	_, err = tmpfile.Seek(0, os.SEEK_SET)
+func (sb *s3Backend) Save(key string, rc io.Reader, m manifest.Manifest) (int64, error) {
+	h := manifest.NewHasher(rc)
+	// S3 put is atomic, so let's go ahead and put the key directly.
+	// The backup is uploaded in parts as it is read, without a local copy.
+	err := sb.s3.Put(key, h)
 	if err != nil {
 		return -1, err
 	}
-	// S3 put is atomic, so let's go ahead and put the key directly.
-	key := util.MakeBackupName(version, snapRev)
-	err = sb.s3.Put(key, tmpfile)
-	if err != nil {
+	n := h.Size()
+	if err := saveManifest(key, h, m, sb.s3.Put, sb.s3.Delete); err != nil {
 		return -1, err
 	}
 	logrus.Infof("saved backup %s (size: %d) successfully", key, n)
@@ -78,29 +60,47 @@ func (sb *s3Backend) GetLatest() (string, error) {
 		return "", fmt.Errorf("failed to list s3 bucket: %v", err)
 	}
 
-	return util.GetLatestBackupName(keys), nil
+	return getLatestWithManifest(keys, sb.s3.Get)
+}
+
+func (sb *s3Backend) GetChain(backup string) (string, error) {
+	keys, err := sb.s3.List()
+	if err != nil {
+		return "", fmt.Errorf("failed to list s3 bucket: %v", err)
+	}
+	return getChain(keys, backup), nil
 }
 
 func (sb *s3Backend) Open(name string) (io.ReadCloser, error) {
-	return sb.s3.Get(name)
+	return manifest.Open(sb.s3.Get, name)
 }
 
-func (sb *s3Backend) Purge(maxBackupFiles int) error {
-	names, err := sb.s3.List()
+func (sb *s3Backend) Manifest(name string) (*manifest.Manifest, error) {
+	return manifest.Load(sb.s3.Get, name)
+}
+
+func (sb *s3Backend) List() ([]string, error) {
+	keys, err := sb.s3.List()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to list s3 bucket: %v", err)
 	}
-	bnames := util.FilterAndSortBackups(names)
-	if len(bnames) < maxBackupFiles {
-		return nil
+	return util.FilterAndSortBackups(keys), nil
+}
+
+func (sb *s3Backend) Delete(name string) error {
+	keys, err := sb.s3.List()
+	if err != nil {
+		return fmt.Errorf("failed to list s3 bucket: %v", err)
 	}
-	for i := 0; i < len(bnames)-maxBackupFiles; i++ {
-		err := sb.s3.Delete(bnames[i])
-		if err != nil {
-			logrus.Errorf("fail to delete s3 file (%s): %v", bnames[i], err)
-		}
+	return deleteBackup(keys, name, sb.s3.Delete)
+}
+
+func (sb *s3Backend) Purge(p api.BackupRetentionPolicy) error {
+	names, err := sb.s3.List()
+	if err != nil {
+		return err
 	}
-	return nil
+	return purge(names, p, sb.s3.Get, sb.s3.Delete)
 }
 
 func (sb *s3Backend) Total() (int, error) {
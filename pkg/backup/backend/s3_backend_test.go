@@ -22,8 +22,11 @@ import (
 	"testing"
 	"time"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/s3"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -45,20 +48,28 @@ func randString(strlen int) string {
 	return string(result)
 }
 
+// minioSessionOpt returns the session options to talk to the local MinIO
+// the same way the operator talks to an S3-compatible endpoint.
+func minioSessionOpt() session.Options {
+	so := session.Options{
+		Config: aws.Config{
+			Credentials: credentials.NewStaticCredentials(os.Getenv("MINIO_ACCESS_KEY"), os.Getenv("MINIO_SECRET_KEY"), ""),
+		},
+	}
+	s3factory.ApplyS3Endpoint(&so, api.S3Endpoint{
+		Endpoint:       "http://localhost:9000",
+		Region:         "us-east-1",
+		ForcePathStyle: true,
+	}, nil)
+	return so
+}
+
 func TestS3Backend(t *testing.T) {
 	if os.Getenv("RUN_INTEGRATION_TEST") != "true" {
 		t.Skip("skipping integration test due to RUN_INTEGRATION_TEST not set")
 	}
 	rs := randString(10)
-	sessOpt := session.Options{
-		Config: aws.Config{
-			Credentials:      credentials.NewStaticCredentials(os.Getenv("MINIO_ACCESS_KEY"), os.Getenv("MINIO_SECRET_KEY"), ""),
-			Endpoint:         aws.String("http://localhost:9000"),
-			Region:           aws.String("us-east-1"),
-			DisableSSL:       aws.Bool(true),
-			S3ForcePathStyle: aws.Bool(true),
-		},
-	}
+	sessOpt := minioSessionOpt()
 	buc := "test-bucket" // This is pre-set bucket
 	s3cli, err := s3.NewFromSessionOpt(buc, rs, sessOpt)
 	if err != nil {
@@ -77,13 +88,13 @@ func testS3BackendPurge(t *testing.T, s3cli *s3.S3) {
 	s := &s3Backend{
 		s3: s3cli,
 	}
-	if _, err := s.Save("3.1.0", 1, bytes.NewBuffer([]byte("ignore"))); err != nil {
+	if _, err := s.Save(util.MakeBackupName("3.1.0", 1), bytes.NewBuffer([]byte("ignore")), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := s.Save("3.1.0", 2, bytes.NewBuffer([]byte("ignore"))); err != nil {
+	if _, err := s.Save(util.MakeBackupName("3.1.0", 2), bytes.NewBuffer([]byte("ignore")), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
-	if err := s.Purge(1); err != nil {
+	if err := s.Purge(api.BackupRetentionPolicy{Last: 1}); err != nil {
 		t.Fatal(err)
 	}
 	names, err := s3cli.List()
@@ -103,7 +114,7 @@ func testS3BackendDelete(t *testing.T, s3cli *s3.S3) {
 	s := &s3Backend{
 		s3: s3cli,
 	}
-	if _, err := s.Save("3.1.0", 1, bytes.NewBuffer([]byte("ignore"))); err != nil {
+	if _, err := s.Save(util.MakeBackupName("3.1.0", 1), bytes.NewBuffer([]byte("ignore")), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
 	names, err := s3cli.List()
@@ -131,15 +142,7 @@ func TestS3BackendWithPrefix(t *testing.T) {
 	if os.Getenv("RUN_INTEGRATION_TEST") != "true" {
 		t.Skip("skipping integration test due to RUN_INTEGRATION_TEST not set")
 	}
-	sessOpt := session.Options{
-		Config: aws.Config{
-			Credentials:      credentials.NewStaticCredentials(os.Getenv("MINIO_ACCESS_KEY"), os.Getenv("MINIO_SECRET_KEY"), ""),
-			Endpoint:         aws.String("http://localhost:9000"),
-			Region:           aws.String("us-east-1"),
-			DisableSSL:       aws.Bool(true),
-			S3ForcePathStyle: aws.Bool(true),
-		},
-	}
+	sessOpt := minioSessionOpt()
 	buc := "test-bucket" // This is pre-set bucket
 	prefix := randString(10)
 	s3Cli, err := s3.NewFromSessionOpt(buc, prefix, sessOpt)
@@ -158,11 +161,11 @@ func TestS3BackendWithPrefix(t *testing.T) {
 		s3: s3Cli2,
 	}
 
-	if _, err := s.Save("file1", 1, bytes.NewBuffer([]byte("ignore"))); err != nil {
+	if _, err := s.Save(util.MakeBackupName("file1", 1), bytes.NewBuffer([]byte("ignore")), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
 
-	if _, err := s2.Save("file2", 1, bytes.NewBuffer([]byte("ignore"))); err != nil {
+	if _, err := s2.Save(util.MakeBackupName("file2", 1), bytes.NewBuffer([]byte("ignore")), manifest.Manifest{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -185,11 +188,11 @@ func TestS3BackendWithPrefix(t *testing.T) {
 	}
 
 	// clean up
-	if err = s.Purge(1); err != nil {
+	if err = s.Purge(api.BackupRetentionPolicy{Last: 1}); err != nil {
 		t.Fatal(err)
 	}
 
-	if err = s2.Purge(1); err != nil {
+	if err = s2.Purge(api.BackupRetentionPolicy{Last: 1}); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -17,8 +17,9 @@ package backend
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/swift"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
@@ -38,72 +39,79 @@ func NewSwiftBackend(swift *swift.Swift) Backend {
 	return &swiftBackend{swift}
 }
 
-// Save saves the backup on swift container from the given reader with given etcd version and revision.
+// Save saves the backup on swift container from the given reader under the given key,
+// and its manifest next to it.
 // It returns the size of the snapshot saved.
-func (sb *swiftBackend) Save(version string, snapRev int64, rc io.Reader) (int64, error) {
+func (sb *swiftBackend) Save(key string, rc io.Reader, m manifest.Manifest) (int64, error) {
+	h := manifest.NewHasher(rc)
 	// swift put is atomic, so let's go ahead and put the key directly.
-	key := util.MakeBackupName(version, snapRev)
-	err := sb.swift.Put(key, rc)
+	err := sb.swift.Put(key, h)
 	if err != nil {
 		return -1, err
 	}
-	n, err := sb.getObjectSize(key)
-	if err != nil {
+	n := h.Size()
+	if err := saveManifest(key, h, m, sb.swift.Put, sb.swift.Delete); err != nil {
 		return -1, err
 	}
 	logrus.Infof("saved backup %s (size: %d) successfully", key, n)
 	return n, nil
 }
 
-// getObjectSize returns the size of the backup with specfied <key>.
-func (sb *swiftBackend) getObjectSize(key string) (int64, error) {
-	rc, err := sb.Open(key)
+// GetLatest gets latest backup's name.
+// If no backup is available, returns empty string name.
+func (sb *swiftBackend) GetLatest() (string, error) {
+	keys, err := sb.swift.List()
 	if err != nil {
-		return -1, err
+		return "", fmt.Errorf("failed to list swift container: %v", err)
 	}
 
-	b, err := ioutil.ReadAll(rc)
+	return getLatestWithManifest(keys, sb.swift.Get)
+}
+
+// GetChain gets the name of the delta chain of the given backup.
+func (sb *swiftBackend) GetChain(backup string) (string, error) {
+	keys, err := sb.swift.List()
 	if err != nil {
-		rc.Close()
-		return -1, err
+		return "", fmt.Errorf("failed to list swift container: %v", err)
 	}
+	return getChain(keys, backup), nil
+}
 
-	return int64(len(b)), rc.Close()
+// Open opens a backup file for reading and verifies it against its manifest.
+func (sb *swiftBackend) Open(name string) (io.ReadCloser, error) {
+	return manifest.Open(sb.swift.Get, name)
 }
 
-// GetLatest gets latest backup's name.
-// If no backup is available, returns empty string name.
-func (sb *swiftBackend) GetLatest() (string, error) {
+// Manifest returns the manifest of the given backup.
+func (sb *swiftBackend) Manifest(name string) (*manifest.Manifest, error) {
+	return manifest.Load(sb.swift.Get, name)
+}
+
+// List returns the names of the backups, sorted by revision.
+func (sb *swiftBackend) List() ([]string, error) {
 	keys, err := sb.swift.List()
 	if err != nil {
-		return "", fmt.Errorf("failed to list swift container: %v", err)
+		return nil, fmt.Errorf("failed to list swift container: %v", err)
 	}
-
-	return util.GetLatestBackupName(keys), nil
+	return util.FilterAndSortBackups(keys), nil
 }
 
-// Open opens a backup file for reading
-func (sb *swiftBackend) Open(name string) (io.ReadCloser, error) {
-	return sb.swift.Get(name)
+// Delete deletes the given backup with its manifest and delta chain.
+func (sb *swiftBackend) Delete(name string) error {
+	keys, err := sb.swift.List()
+	if err != nil {
+		return fmt.Errorf("failed to list swift container: %v", err)
+	}
+	return deleteBackup(keys, name, sb.swift.Delete)
 }
 
-// Purge purges backup files when backups are greater than maxBackupFiles.
-func (sb *swiftBackend) Purge(maxBackupFiles int) error {
+// Purge purges the backups the retention policy does not keep.
+func (sb *swiftBackend) Purge(p api.BackupRetentionPolicy) error {
 	names, err := sb.swift.List()
 	if err != nil {
 		return err
 	}
-	bnames := util.FilterAndSortBackups(names)
-	if len(bnames) < maxBackupFiles {
-		return nil
-	}
-	for i := 0; i < len(bnames)-maxBackupFiles; i++ {
-		err := sb.swift.Delete(bnames[i])
-		if err != nil {
-			logrus.Errorf("fail to delete swift file (%s): %v", bnames[i], err)
-		}
-	}
-	return nil
+	return purge(names, p, sb.swift.Get, sb.swift.Delete)
 }
 
 // Total returns the total number of available backups.
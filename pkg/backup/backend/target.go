@@ -0,0 +1,104 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FindBackupAt returns the newest backup of be at or before the target t that has a valid manifest.
+// A backup is at or before a target revision if its revision is not greater,
+// and at or before a target time if it was taken no later.
+// If there is none, it returns an empty string.
+func FindBackupAt(be Backend, t api.RestoreTarget) (string, error) {
+	tt, err := t.Time()
+	if err != nil {
+		return "", err
+	}
+	names, err := be.List()
+	if err != nil {
+		return "", err
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if t.TargetRevision > 0 && util.MustParseRevision(name) > t.TargetRevision {
+			continue
+		}
+		m, err := be.Manifest(name)
+		if os.IsNotExist(err) {
+			logrus.Warningf("skipping backup %s without a manifest", name)
+			continue
+		}
+		if _, ok := err.(*manifest.InvalidError); ok {
+			logrus.Warningf("skipping backup %s: %v", name, err)
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to load manifest of backup %s: %v", name, err)
+		}
+		if !tt.IsZero() {
+			ct, err := time.Parse(time.RFC3339, m.CreationTime)
+			if err != nil {
+				logrus.Warningf("skipping backup %s with a bad creation time: %v", name, err)
+				continue
+			}
+			if ct.After(tt) {
+				continue
+			}
+		}
+		return name, nil
+	}
+	return "", nil
+}
+
+// RestoreRevision returns the revision the given backup is restored to at the target t:
+// the end revision of the last delta of its chain c recorded at or before the target,
+// or the revision of the backup if there is none. c is nil if the backup has no chain.
+// If t is not set, all the deltas of the chain are replayed.
+func RestoreRevision(backup string, c *delta.Chain, t api.RestoreTarget) (int64, error) {
+	if c == nil {
+		return util.MustParseRevision(backup), nil
+	}
+	if !t.IsSet() {
+		return c.EndRevision(), nil
+	}
+	tt, err := t.Time()
+	if err != nil {
+		return 0, err
+	}
+	rev := c.Revision
+	for _, l := range c.Deltas {
+		if t.TargetRevision > 0 && l.EndRevision > t.TargetRevision {
+			break
+		}
+		if !tt.IsZero() {
+			ct, err := time.Parse(time.RFC3339, l.CreationTime)
+			if err != nil || ct.After(tt) {
+				break
+			}
+		}
+		rev = l.EndRevision
+	}
+	return rev, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+)
+
+func TestFindBackupAt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-operator-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	backups := []struct {
+		rev          int64
+		creationTime string
+	}{
+		{10, "2017-11-21T10:00:00Z"},
+		{20, "2017-11-21T11:00:00Z"},
+		{30, "2017-11-21T12:00:00Z"},
+	}
+	for _, b := range backups {
+		name := util.MakeBackupName("3.1.8", b.rev)
+		err := writeBackupFileWithManifest(dir, name, []byte(name), manifest.Manifest{Revision: b.rev, CreationTime: b.creationTime})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the newest backup has an invalid manifest and must be skipped.
+	err = writeBackupFile(dir, util.MakeBackupName("3.1.8", 40), []byte("ignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, manifest.Name(util.MakeBackupName("3.1.8", 40))), []byte("not json"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the oldest backup was taken before manifests were introduced and must be skipped.
+	err = ioutil.WriteFile(filepath.Join(dir, util.MakeBackupName("3.1.8", 5)), []byte("legacy"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb := NewFileBackend(dir)
+
+	tests := []struct {
+		target api.RestoreTarget
+		want   string
+	}{
+		{api.RestoreTarget{}, util.MakeBackupName("3.1.8", 30)},
+		{api.RestoreTarget{TargetRevision: 50}, util.MakeBackupName("3.1.8", 30)},
+		{api.RestoreTarget{TargetRevision: 20}, util.MakeBackupName("3.1.8", 20)},
+		{api.RestoreTarget{TargetRevision: 29}, util.MakeBackupName("3.1.8", 20)},
+		{api.RestoreTarget{TargetRevision: 9}, ""},
+		{api.RestoreTarget{TargetTime: "2017-11-21T11:00:00Z"}, util.MakeBackupName("3.1.8", 20)},
+		{api.RestoreTarget{TargetTime: "2017-11-21T11:59:59Z"}, util.MakeBackupName("3.1.8", 20)},
+		{api.RestoreTarget{TargetTime: "2017-11-21T13:00:00+01:00"}, util.MakeBackupName("3.1.8", 30)},
+		{api.RestoreTarget{TargetTime: "2017-11-21T09:00:00Z"}, ""},
+	}
+	for i, tt := range tests {
+		name, err := FindBackupAt(fb, tt.target)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if name != tt.want {
+			t.Errorf("#%d: backup = %q, want %q", i, name, tt.want)
+		}
+	}
+
+	if _, err := FindBackupAt(fb, api.RestoreTarget{TargetTime: "yesterday"}); err == nil {
+		t.Error("expected an error for a bad target time")
+	}
+}
+
+func TestRestoreRevision(t *testing.T) {
+	backup := util.MakeBackupName("3.1.8", 10)
+	c := &delta.Chain{
+		Backup:   backup,
+		Revision: 10,
+		Deltas: []delta.Link{
+			{Name: "a", StartRevision: 11, EndRevision: 15, CreationTime: "2017-11-21T10:10:00Z"},
+			{Name: "b", StartRevision: 16, EndRevision: 20, CreationTime: "2017-11-21T10:20:00Z"},
+		},
+	}
+
+	tests := []struct {
+		c      *delta.Chain
+		target api.RestoreTarget
+		want   int64
+	}{
+		{nil, api.RestoreTarget{}, 10},
+		{nil, api.RestoreTarget{TargetRevision: 12}, 10},
+		{c, api.RestoreTarget{}, 20},
+		{c, api.RestoreTarget{TargetRevision: 100}, 20},
+		{c, api.RestoreTarget{TargetRevision: 17}, 15},
+		{c, api.RestoreTarget{TargetRevision: 12}, 10},
+		{c, api.RestoreTarget{TargetTime: "2017-11-21T10:15:00Z"}, 15},
+		{c, api.RestoreTarget{TargetTime: "2017-11-21T10:20:00Z"}, 20},
+		{c, api.RestoreTarget{TargetTime: "2017-11-21T10:05:00Z"}, 10},
+	}
+	for i, tt := range tests {
+		rev, err := RestoreRevision(backup, tt.c, tt.target)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if rev != tt.want {
+			t.Errorf("#%d: revision = %d, want %d", i, rev, tt.want)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package backup
 import (
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"time"
@@ -25,14 +26,20 @@ import (
 	"github.com/coreos/etcd-operator/pkg/backup/abs"
 	"github.com/coreos/etcd-operator/pkg/backup/backend"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
+	"github.com/coreos/etcd-operator/pkg/backup/encryption"
 	"github.com/coreos/etcd-operator/pkg/backup/env"
+	"github.com/coreos/etcd-operator/pkg/backup/gcs"
+	"github.com/coreos/etcd-operator/pkg/backup/multipart"
 	"github.com/coreos/etcd-operator/pkg/backup/s3"
 	"github.com/coreos/etcd-operator/pkg/backup/swift"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
 	"github.com/coreos/etcd-operator/pkg/util/constants"
 	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 )
@@ -45,11 +52,26 @@ const (
 
 // BackupController controls when to do backup based on backup policy and incoming HTTP backup requests.
 type BackupController struct {
-	listenAddr    string
-	backupNow     chan chan backupNowAck
+	listenAddr string
+	// apiTLSConfig is the TLS config the HTTP API is served with.
+	// It is nil if the backup policy does not secure the API.
+	apiTLSConfig *tls.Config
+	// apiToken is the bearer token that authenticates the requests to the secured HTTP API.
+	apiToken string
+
+	backupNow chan chan backupNowAck
+	// rollbackNow receives the requests to take a backup of a cluster that was rolled back.
+	rollbackNow   chan chan backupNowAck
 	policy        api.BackupPolicy
 	backupManager *BackupManager
 	backupServer  *BackupServer
+	// verifier is nil if the backup policy has no verification.
+	verifier *backupVerifier
+	// deltas is nil if the backup policy records no deltas.
+	deltas    *deltaRecorder
+	scheduler *backupScheduler
+	// replicator is nil if the backup policy has no destinations.
+	replicator *replicator
 	// recentBackupStatus keeps the statuses of 'maxRecentBackupStatusCount' recent backups.
 	recentBackupsStatus []backupapi.BackupStatus
 }
@@ -60,7 +82,11 @@ type BackupControllerConfig struct {
 
 	ListenAddr  string
 	ClusterName string
-	Namespace   string
+	// ClusterUID is the UID of the EtcdCluster, recorded in the manifests of the backups.
+	ClusterUID string
+	Namespace  string
+	// BaseImage is the etcd image used to verify backups.
+	BaseImage string
 
 	TLS          *api.TLSPolicy
 	BackupPolicy *api.BackupPolicy
@@ -80,14 +106,27 @@ func NewBackupController(config *BackupControllerConfig) (*BackupController, err
 		}
 		be = backend.NewFileBackend(bdir)
 	case api.BackupStorageTypeS3:
+		so := session.Options{
+			SharedConfigState: session.SharedConfigEnable,
+		}
 		s3Prefix := ""
 		if bp.S3 != nil {
 			s3Prefix = bp.S3.Prefix
+			var caBundle []byte
+			if caFile := os.Getenv(env.AWSCABundle); len(caFile) != 0 {
+				var err error
+				caBundle, err = ioutil.ReadFile(caFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read S3 CA bundle: %v", err)
+				}
+			}
+			s3factory.ApplyS3Endpoint(&so, bp.S3.S3Endpoint, caBundle)
 		}
-		s3cli, err := s3.New(os.Getenv(env.AWSS3Bucket), backupapi.ToS3Prefix(s3Prefix, config.Namespace, config.ClusterName))
+		s3cli, err := s3.NewFromSessionOpt(os.Getenv(env.AWSS3Bucket), backupapi.ToS3Prefix(s3Prefix, config.Namespace, config.ClusterName), so)
 		if err != nil {
 			return nil, err
 		}
+		s3cli.SetUploadOptions(uploadOptions(bp.Upload))
 		be = backend.NewS3Backend(s3cli)
 	case api.BackupStorageTypeABS:
 		absCli, err := abs.New(os.Getenv(env.ABSContainer),
@@ -97,17 +136,59 @@ func NewBackupController(config *BackupControllerConfig) (*BackupController, err
 		if err != nil {
 			return nil, err
 		}
+		absCli.SetUploadOptions(uploadOptions(bp.Upload))
 		be = backend.NewAbsBackend(absCli)
 	case api.BackupStorageTypeSwift:
 		swiftCli, err := swift.New(bp.Swift.SwiftContainer, bp.Swift.SwiftRegion, path.Join(config.Namespace, config.ClusterName))
 		if err != nil {
 			return nil, err
 		}
+		swiftCli.SetUploadOptions(uploadOptions(bp.Upload))
 		be = backend.NewSwiftBackend(swiftCli)
+	case api.BackupStorageTypeGCS:
+		saJSON, err := ioutil.ReadFile(os.Getenv(env.GCSCredentials))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GCS credentials: %v", err)
+		}
+		gcsCli, err := gcs.New(os.Getenv(env.GCSBucket), path.Join(bp.GCS.Prefix, config.Namespace, config.ClusterName), saJSON)
+		if err != nil {
+			return nil, err
+		}
+		be = backend.NewGCSBackend(gcsCli)
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %v", bp.StorageType)
 	}
 
+	var kr *encryption.Keyring
+	if bp.Encryption != nil {
+		var err error
+		kr, err = k8sutil.GetEncryptionKeyring(config.Kubecli, config.Namespace, bp.Encryption)
+		if err != nil {
+			return nil, err
+		}
+		be = backend.NewEncryptedBackend(be, kr)
+	}
+
+	var rp *replicator
+	if len(bp.Destinations) != 0 {
+		rp = newReplicator(be)
+		retention := bp.RetentionPolicy()
+		for _, d := range bp.Destinations {
+			dbe, err := newDestinationBackend(config.Kubecli, config.Namespace, config.ClusterName, d, uploadOptions(bp.Upload))
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up backup destination (%s): %v", d.Name, err)
+			}
+			if kr != nil {
+				dbe = backend.NewEncryptedBackend(dbe, kr)
+			}
+			r := retention
+			if d.Retention != nil {
+				r = d.Retention
+			}
+			rp.addDestination(d.Name, dbe, r)
+		}
+	}
+
 	var tc *tls.Config
 	if config.TLS.IsSecureClient() {
 		d, err := k8sutil.GetTLSDataFromSecret(config.Kubecli, config.Namespace, config.TLS.Static.OperatorSecret)
@@ -123,20 +204,85 @@ func NewBackupController(config *BackupControllerConfig) (*BackupController, err
 	bm := &BackupManager{
 		kubecli:       config.Kubecli,
 		clusterName:   config.ClusterName,
+		clusterUID:    config.ClusterUID,
 		namespace:     config.Namespace,
 		be:            be,
 		etcdTLSConfig: tc,
+		compression:   string(bp.Compression),
+		replicator:    rp,
 	}
 	bs := &BackupServer{
 		backend: be,
 	}
+	if rp != nil {
+		bs.fallbacks = rp.backends()
+	}
+
+	var (
+		apiTC      *tls.Config
+		apiToken   string
+		authSecret string
+		err        error
+	)
+	if bp.API != nil {
+		apiTC, err = k8sutil.NewBackupAPIServerTLSConfig(config.Kubecli, config.Namespace, bp.API.ServerSecret, bp.API.ClientCASecret)
+		if err != nil {
+			return nil, err
+		}
+		// The operator provisions the auth secret before it creates the sidecar.
+		bs.secured = true
+		authSecret = k8sutil.BackupAPIAuthSecretName(config.ClusterName)
+		apiToken, err = k8sutil.GetBackupAPIAuthToken(config.Kubecli, config.Namespace, authSecret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var bv *backupVerifier
+	if bp.Verification != nil {
+		bv = &backupVerifier{
+			kubecli:     config.Kubecli,
+			clusterName: config.ClusterName,
+			clusterUID:  config.ClusterUID,
+			namespace:   config.Namespace,
+			baseImage:   config.BaseImage,
+			authSecret:  authSecret,
+			be:          be,
+			policy:      *bp.Verification,
+		}
+	}
+
+	// The cluster UID is unknown to sidecars created by older operators.
+	key := config.ClusterUID
+	if len(key) == 0 {
+		key = path.Join(config.Namespace, config.ClusterName)
+	}
+	sched, err := newBackupScheduler(bp, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var dr *deltaRecorder
+	if bp.DeltaIntervalInSecond > 0 {
+		dr = &deltaRecorder{
+			bm:       bm,
+			interval: time.Duration(bp.DeltaIntervalInSecond) * time.Second,
+		}
+	}
 
 	return &BackupController{
 		listenAddr:    config.ListenAddr,
+		apiTLSConfig:  apiTC,
+		apiToken:      apiToken,
 		backupNow:     make(chan chan backupNowAck),
+		rollbackNow:   make(chan chan backupNowAck),
 		policy:        *bp,
 		backupManager: bm,
 		backupServer:  bs,
+		verifier:      bv,
+		deltas:        dr,
+		scheduler:     sched,
+		replicator:    rp,
 	}, nil
 }
 
@@ -144,18 +290,30 @@ func NewBackupController(config *BackupControllerConfig) (*BackupController, err
 // controlls backups based on backup policy and HTTP backup requests.
 func (bc *BackupController) Run() {
 	lastSnapRev := bc.backupManager.getLatestBackupRev()
-	interval := constants.DefaultSnapshotInterval
-	if bc.policy.BackupIntervalInSecond != 0 {
-		interval = time.Duration(bc.policy.BackupIntervalInSecond) * time.Second
+	if bc.scheduler.start(bc.backupManager.getLatestBackupTime(), time.Now()) {
+		logrus.Info("a scheduled backup was missed, backing up now")
+	}
+
+	if bc.verifier != nil {
+		go bc.verifier.run()
+	}
+	if bc.replicator != nil {
+		go bc.replicator.run()
+	}
+	if bc.deltas != nil {
+		if err := bc.deltas.resume(); err != nil {
+			logrus.Errorf("failed to resume recording deltas: %v", err)
+		}
 	}
 
 	go func() {
-		if bc.policy.MaxBackups == 0 {
+		rp := bc.policy.RetentionPolicy()
+		if rp == nil {
 			return
 		}
 		for {
-			<-time.After(10 * time.Second)
-			err := bc.backupManager.be.Purge(bc.policy.MaxBackups)
+			<-time.After(purgeInterval(rp))
+			err := bc.backupManager.be.Purge(*rp)
 			if err != nil {
 				logrus.Errorf("fail to purge backups: %v", err)
 			}
@@ -164,25 +322,55 @@ func (bc *BackupController) Run() {
 
 	for {
 		var ackchan chan backupNowAck
+		scheduled, rollback := false, false
 		select {
-		case <-time.After(interval):
+		case <-time.After(time.Until(bc.scheduler.nextTime())):
+			scheduled = true
 		case ackchan = <-bc.backupNow:
 			logrus.Info("received a backup request")
+		case ackchan = <-bc.rollbackNow:
+			logrus.Info("received a rollback request")
+			rollback = true
 		}
 
-		bs, err := bc.backupManager.SaveSnap(lastSnapRev)
+		var (
+			bs  *backupapi.BackupStatus
+			err error
+		)
+		if rollback {
+			err = bc.rollback()
+			if err == nil {
+				// The revision of the cluster went back, so the next backup is taken whatever its revision.
+				lastSnapRev = 0
+			}
+		}
+		if err == nil {
+			bs, err = bc.backupManager.SaveSnap(lastSnapRev)
+		}
 		if err != nil {
 			logrus.Errorf("failed to save snapshot: %v", err)
 		}
 
 		if bs != nil {
 			lastSnapRev = bs.Revision
+			if bc.deltas != nil {
+				name := util.MakeCompressedBackupName(bs.Version, bs.Revision, bc.backupManager.compression)
+				bc.deltas.start(&delta.Chain{Backup: name, Revision: bs.Revision}, bs.Version)
+			}
+			if bc.replicator != nil {
+				bc.replicator.notify()
+			}
 			bc.recentBackupsStatus = append(bc.recentBackupsStatus, *bs)
 			if len(bc.recentBackupsStatus) > maxRecentBackupStatusCount {
 				bc.recentBackupsStatus = bc.recentBackupsStatus[1:]
 			}
 		}
 
+		if scheduled {
+			bc.scheduler.advance(time.Now())
+			logrus.Infof("next backup is scheduled at %v", bc.scheduler.nextTime().Format(time.RFC3339))
+		}
+
 		if ackchan != nil {
 			ack := backupNowAck{err: err}
 			if err == nil && len(bc.recentBackupsStatus) > 0 {
@@ -192,3 +380,49 @@ func (bc *BackupController) Run() {
 		}
 	}
 }
+
+// purgeInterval returns the interval between two purges of the backups.
+// A generational retention reads the manifest of every backup, so it runs less often.
+func purgeInterval(rp *api.BackupRetentionPolicy) time.Duration {
+	if rp.IsGenerational() {
+		return 5 * time.Minute
+	}
+	return 10 * time.Second
+}
+
+// uploadOptions returns the options of the multipart uploads of the upload policy.
+func uploadOptions(up *api.BackupUploadPolicy) multipart.Options {
+	if up == nil {
+		return multipart.Options{}
+	}
+	return multipart.Options{
+		PartSize:    up.PartSizeInMB * 1024 * 1024,
+		Concurrency: up.Concurrency,
+		MaxRetries:  up.MaxRetries,
+	}
+}
+
+// rollback prepares the backup storage and its destinations for a cluster that was rolled back
+// to an older revision, e.g. by an in-place restore: it stops recording the deltas of the current chain
+// and retires the backups taken after the current revision of the cluster.
+func (bc *BackupController) rollback() error {
+	if bc.deltas != nil {
+		bc.deltas.stop()
+	}
+	rev, err := bc.backupManager.getClusterRevision()
+	if err != nil {
+		return err
+	}
+	logrus.Infof("cluster was rolled back to revision %d", rev)
+	if err := retireAfter(bc.backupManager.be, rev); err != nil {
+		return err
+	}
+	if bc.replicator != nil {
+		for _, d := range bc.replicator.destinations {
+			if err := retireAfter(d.be, rev); err != nil {
+				logrus.Errorf("failed to retire backups of destination (%s): %v", d.name, err)
+			}
+		}
+	}
+	return nil
+}
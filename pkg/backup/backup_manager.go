@@ -19,11 +19,15 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"path"
 	"time"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/backend"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/compression"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 	"github.com/coreos/etcd-operator/pkg/backup/writer"
 	"github.com/coreos/etcd-operator/pkg/util/constants"
@@ -40,12 +44,18 @@ import (
 type BackupManager struct {
 	kubecli kubernetes.Interface
 
-	clusterName   string
+	clusterName string
+	// clusterUID is recorded in the manifests of the backups, if known.
+	clusterUID    string
 	namespace     string
 	etcdTLSConfig *tls.Config
 
 	be backend.Backend
-	bw writer.Writer
+	// replicator saves the backups to the destinations along with be. It is nil if there are none.
+	replicator *replicator
+	bw         writer.Writer
+	// compression is the codec new backups are compressed with.
+	compression string
 }
 
 // NewBackupManager creates a BackupManager.
@@ -60,12 +70,14 @@ func NewBackupManager(kubecli kubernetes.Interface, clusterName string, namespac
 }
 
 // NewBackupManagerFromWriter creates a BackupManager with backup writer.
-func NewBackupManagerFromWriter(kubecli kubernetes.Interface, bw writer.Writer, clusterName, namespace string) *BackupManager {
+// Backups are compressed with the given codec, if any.
+func NewBackupManagerFromWriter(kubecli kubernetes.Interface, bw writer.Writer, clusterName, namespace string, codec api.BackupCompression) *BackupManager {
 	return &BackupManager{
 		kubecli:     kubecli,
 		clusterName: clusterName,
 		namespace:   namespace,
 		bw:          bw,
+		compression: string(codec),
 	}
 }
 
@@ -83,7 +95,11 @@ func (bm *BackupManager) SaveSnap(lastSnapRev int64) (*backupapi.BackupStatus, e
 		return nil, nil
 	}
 
-	bs, err := bm.writeSnap(etcdcli.Maintenance, etcdcli.Endpoints()[0], rev)
+	keyCount, err := getKeyCount(etcdcli.KV, rev)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := bm.writeSnap(etcdcli.Maintenance, etcdcli.Endpoints()[0], rev, keyCount)
 	if err != nil {
 		return nil, fmt.Errorf("write snapshot failed: %v", err)
 	}
@@ -92,7 +108,7 @@ func (bm *BackupManager) SaveSnap(lastSnapRev int64) (*backupapi.BackupStatus, e
 	return bs, nil
 }
 
-func (bm *BackupManager) writeSnap(mcli clientv3.Maintenance, endpoint string, rev int64) (*backupapi.BackupStatus, error) {
+func (bm *BackupManager) writeSnap(mcli clientv3.Maintenance, endpoint string, rev, keyCount int64) (*backupapi.BackupStatus, error) {
 	start := time.Now()
 
 	version, err := getEtcdVersion(mcli, endpoint)
@@ -108,13 +124,19 @@ func (bm *BackupManager) writeSnap(mcli clientv3.Maintenance, endpoint string, r
 	}
 	defer rc.Close()
 
-	n, err := bm.be.Save(version, rev, rc)
+	r, err := compression.Compress(rc, bm.compression)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	m := bm.newManifest(version, rev, keyCount)
+	n, err := bm.save(util.MakeCompressedBackupName(version, rev, bm.compression), r, m)
 	if err != nil {
 		return nil, err
 	}
 
 	bs := &backupapi.BackupStatus{
-		CreationTime:     time.Now().Format(time.RFC3339),
+		CreationTime:     m.CreationTime,
 		Size:             util.ToMB(n),
 		Version:          version,
 		Revision:         rev,
@@ -124,12 +146,21 @@ func (bm *BackupManager) writeSnap(mcli clientv3.Maintenance, endpoint string, r
 	return bs, nil
 }
 
+// save saves the backup, delta chunk or chain read from r to the backup storage and its destinations.
+func (bm *BackupManager) save(name string, r io.Reader, m manifest.Manifest) (int64, error) {
+	if bm.replicator == nil {
+		return bm.be.Save(name, r, m)
+	}
+	return bm.replicator.save(name, r, m)
+}
+
 // SaveSnapWithPrefix uses backup writer to save latest snapshot to a path prepended with the given prefix
 // and returns file size and full path.
 // the full path has the format of prefix/<etcd_version>_<snapshot_reversion>_etcd.backup
 // e.g prefix = etcd-backups/v1/default/example-etcd-cluster and
 // backup object name = 3.1.8_0000000000000001_etcd.backup
 // full path is "etcd-backups/v1/default/example-etcd-cluster/3.1.8_0000000000000001_etcd.backup".
+// A compressed backup has the suffix of its codec appended, e.g. ".gz".
 func (bm *BackupManager) SaveSnapWithPrefix(prefix string) (string, error) {
 	etcdcli, rev, err := bm.etcdClientWithMaxRevision()
 	if err != nil {
@@ -149,14 +180,46 @@ func (bm *BackupManager) SaveSnapWithPrefix(prefix string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	fullPath := path.Join(prefix, util.MakeBackupName(version, rev))
-	_, err = bm.bw.Write(fullPath, rc)
+	keyCount, err := getKeyCount(etcdcli.KV, rev)
+	if err != nil {
+		return "", err
+	}
+	r, err := compression.Compress(rc, bm.compression)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	fullPath := path.Join(prefix, util.MakeCompressedBackupName(version, rev, bm.compression))
+	_, err = bm.bw.Write(fullPath, r, bm.newManifest(version, rev, keyCount))
 	if err != nil {
 		return "", fmt.Errorf("failed to write snapshot (%v)", err)
 	}
 	return fullPath, nil
 }
 
+// newManifest returns the manifest of a backup taken now, to be completed by the backend.
+func (bm *BackupManager) newManifest(version string, rev, keyCount int64) manifest.Manifest {
+	return manifest.Manifest{
+		EtcdVersion:  version,
+		Revision:     rev,
+		KeyCount:     keyCount,
+		ClusterUID:   bm.clusterUID,
+		CreationTime: time.Now().Format(time.RFC3339),
+	}
+}
+
+// getKeyCount returns the number of keys at the given revision.
+func getKeyCount(kv clientv3.KV, rev int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	// Ranges must not start at the empty key; "\x00" is the smallest key.
+	resp, err := kv.Get(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithCountOnly(), clientv3.WithRev(rev))
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count keys at revision %d (%v)", rev, err)
+	}
+	return resp.Count, nil
+}
+
 func getEtcdVersion(mcli clientv3.Maintenance, endpoint string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultSnapshotTimeout)
 	resp, err := mcli.Status(ctx, endpoint)
@@ -167,6 +230,16 @@ func getEtcdVersion(mcli clientv3.Maintenance, endpoint string) (string, error)
 	return resp.Version, nil
 }
 
+// getClusterRevision returns the revision of the member of the cluster with the maximum revision.
+func (bm *BackupManager) getClusterRevision() (int64, error) {
+	etcdcli, rev, err := bm.etcdClientWithMaxRevision()
+	if err != nil {
+		return 0, fmt.Errorf("create etcd client with max revision failed: %v", err)
+	}
+	etcdcli.Close()
+	return rev, nil
+}
+
 // etcdClientWithMaxRevision gets the etcd member with the maximum kv store revision
 // and returns the etcd client and the rev of that member.
 func (bm *BackupManager) etcdClientWithMaxRevision() (*clientv3.Client, int64, error) {
@@ -198,6 +271,37 @@ func (bm *BackupManager) etcdClientWithMaxRevision() (*clientv3.Client, int64, e
 	return etcdcli, rev, nil
 }
 
+// etcdClient returns an etcd client to all running members.
+func (bm *BackupManager) etcdClient() (*clientv3.Client, error) {
+	podList, err := bm.kubecli.Core().Pods(bm.namespace).List(k8sutil.ClusterListOpt(bm.clusterName))
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []string
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		m := &etcdutil.Member{
+			Name:         pod.Name,
+			Namespace:    pod.Namespace,
+			SecureClient: bm.etcdTLSConfig != nil,
+		}
+		endpoints = append(endpoints, m.ClientURL())
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("no running etcd pods found")
+	}
+
+	return clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: constants.DefaultDialTimeout,
+		TLS:         bm.etcdTLSConfig,
+	})
+}
+
 func getMemberWithMaxRev(pods []*v1.Pod, tc *tls.Config) (*etcdutil.Member, int64) {
 	var member *etcdutil.Member
 	maxRev := int64(0)
@@ -248,6 +352,26 @@ func (b *BackupManager) getLatestBackupRev() int64 {
 	return util.MustParseRevision(name)
 }
 
+// getLatestBackupTime returns the creation time of the latest backup,
+// or the zero time if there is none or it is unknown.
+func (b *BackupManager) getLatestBackupTime() time.Time {
+	name, err := b.be.GetLatest()
+	if err != nil || len(name) == 0 {
+		return time.Time{}
+	}
+	m, err := b.be.Manifest(name)
+	if err != nil {
+		logrus.Warningf("failed to get manifest of backup %s: %v", name, err)
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, m.CreationTime)
+	if err != nil {
+		logrus.Warningf("bad creation time of backup %s: %v", name, err)
+		return time.Time{}
+	}
+	return t
+}
+
 func createEtcdClient(url string, tlsConfig *tls.Config) (*clientv3.Client, error) {
 	cfg := clientv3.Config{
 		Endpoints:   []string{url},
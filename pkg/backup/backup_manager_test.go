@@ -23,7 +23,10 @@ import (
 	"path/filepath"
 	"testing"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/compression"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 	"github.com/coreos/etcd/clientv3"
 )
@@ -58,7 +61,7 @@ func TestWriteSnap(t *testing.T) {
 		be: backend.NewFileBackend(d),
 	}
 
-	bs, err := bm.writeSnap(&fakeMaintenanceClient{}, "", rev)
+	bs, err := bm.writeSnap(&fakeMaintenanceClient{}, "", rev, 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -88,6 +91,59 @@ func TestWriteSnap(t *testing.T) {
 	if sds != testData {
 		t.Fatalf("expect saved data %v, got (%v) ", testData, sds)
 	}
+
+	m, err := manifest.Load(func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(d, name))
+	}, lbn)
+	if err != nil {
+		t.Fatalf("failed to load manifest of %v: (%v) ", lbn, err)
+	}
+	if m.EtcdVersion != testEtcdVersion || m.Revision != rev || m.KeyCount != 1 || m.Size != int64(len(testData)) {
+		t.Fatalf("expect manifest of version %v, revision %v, 1 key and size %d, got %+v", testEtcdVersion, rev, len(testData), m)
+	}
+}
+
+// TestWriteSnapCompressed ensures BackupManager.WriteSnap names a compressed
+// backup after its codec and that it decompresses to the snapshot.
+func TestWriteSnapCompressed(t *testing.T) {
+	var rev int64 = 1
+	bn := util.MakeCompressedBackupName(testEtcdVersion, rev, api.BackupCompressionGzip)
+	d, err := makeFileBackendDir(bn)
+	if err != nil {
+		t.Fatalf("failed to make file backend dir: (%v)", err)
+	}
+	bm := &BackupManager{
+		be:          backend.NewFileBackend(d),
+		compression: api.BackupCompressionGzip,
+	}
+
+	if _, err = bm.writeSnap(&fakeMaintenanceClient{}, "", rev, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	lbn, err := bm.be.GetLatest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bn != lbn {
+		t.Fatalf("expect backup name %v, got %v", bn, lbn)
+	}
+	rc, err := bm.be.Open(lbn)
+	if err != nil {
+		t.Fatalf("failed to open %v: (%v) ", lbn, err)
+	}
+	defer rc.Close()
+	dr, err := compression.Decompress(rc, util.CompressionFromBackupName(lbn))
+	if err != nil {
+		t.Fatalf("failed to decompress %v: (%v) ", lbn, err)
+	}
+	sd, err := ioutil.ReadAll(dr)
+	if err != nil {
+		t.Fatalf("failed to read %v: (%v) ", lbn, err)
+	}
+	if sds := string(sd); sds != testData {
+		t.Fatalf("expect saved data %v, got (%v) ", testData, sds)
+	}
 }
 
 func makeFileBackendDir(snap string) (string, error) {
@@ -15,14 +15,18 @@
 package backup
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strconv"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/backend"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/compression"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
 	"github.com/sirupsen/logrus"
@@ -31,13 +35,21 @@ import (
 // BackupServer provides the http handlers to handle backup related requests.
 type BackupServer struct {
 	backend backend.Backend
+	// fallbacks are the backends backups are served from, in order, when backend is unreachable.
+	fallbacks []backend.Backend
+	// secured is set when the requests are authenticated.
+	// Backups are only deleted through the catalog then.
+	secured bool
 }
 
 // NewBackupServer creates a BackupServer.
 func NewBackupServer(backend backend.Backend) *BackupServer {
-	return &BackupServer{backend}
+	return &BackupServer{backend: backend}
 }
 
+// errNoBackup is returned when the latest backup is requested and there is none.
+var errNoBackup = errors.New("no backup")
+
 // ServeBackup serves the backup request:
 // - For GET, it returns the headers of etcd version and revision, with backup data.
 //   For HEAD, it only returns the headers.
@@ -45,16 +57,43 @@ func NewBackupServer(backend backend.Backend) *BackupServer {
 // - If both etcd version and revision, it returns the specified backup.
 //   If etcd version and revision is not given, it returns the latest compatible backup.
 //   If etcd version is not given, it returns the latest backup.
+// - The latest backup is returned with the deltas recorded after it replayed, if any.
+//   If they cannot be replayed, the backup is returned without them.
+// - If a target revision or time is given, the newest backup at or before it is returned
+//   with the deltas recorded after it up to the target replayed.
+// - If the backup storage is unreachable, the backup is served from the secondary destinations.
 func (bs *BackupServer) ServeBackup(w http.ResponseWriter, r *http.Request) {
 	var (
 		fname string
-		err   error
+		// fnames are the names the requested backup may have.
+		fnames []string
+		err    error
+		// latest is true if the latest backup is requested.
+		latest bool
 	)
 
 	revision := r.FormValue(backupapi.HTTPQueryRevisionKey)
 	version := r.FormValue(backupapi.HTTPQueryVersionKey)
 
+	target := api.RestoreTarget{TargetTime: r.FormValue(backupapi.HTTPQueryTargetTimeKey)}
+	if tr := r.FormValue(backupapi.HTTPQueryTargetRevisionKey); len(tr) != 0 {
+		target.TargetRevision, err = strconv.ParseInt(tr, 10, 64)
+		if err != nil {
+			http.Error(w, "target revision is not a vaild integer", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := target.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	switch {
+	case target.IsSet():
+		if len(revision) != 0 {
+			http.Error(w, "revision cannot be provided with a target.", http.StatusBadRequest)
+			return
+		}
 	case len(revision) != 0 && len(version) != 0:
 		revisioni, err := strconv.ParseInt(revision, 10, 64)
 		if err != nil {
@@ -62,25 +101,20 @@ func (bs *BackupServer) ServeBackup(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		fname = util.MakeBackupName(version, revisioni)
+		fnames = util.BackupNames(version, revisioni)
 	case len(revision) == 0:
-		fname, err = bs.backend.GetLatest()
-		if err != nil {
-			logrus.Errorf("fail to serve backup: %v", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if len(fname) == 0 {
-			http.NotFound(w, r)
-			return
-		}
+		latest = true
 	default:
 		http.Error(w, "version must be provided when revision is provided.", http.StatusBadRequest)
 		return
 	}
 
-	rc, err := bs.backend.Open(fname)
+	be, fname, rc, err := bs.findBackup(fnames, latest, target)
 	if err != nil {
+		if err == errNoBackup {
+			http.NotFound(w, r)
+			return
+		}
 		// TODO: define backend layer not found error
 		if os.IsNotExist(err) {
 			http.Error(w, "backup not found", http.StatusNotFound)
@@ -114,16 +148,142 @@ func (bs *BackupServer) ServeBackup(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// A delta chain that cannot be read or replayed does not make the backup unusable:
+	// the backup is served at its own revision instead, and the revision header says so.
+	backupRev := util.MustParseRevision(fname)
+	rev := backupRev
+	var chain *delta.Chain
+	if latest || target.IsSet() {
+		chain, err = getChain(be, fname)
+		if err == nil {
+			rev, err = backend.RestoreRevision(fname, chain, target)
+		}
+		if err != nil {
+			logrus.Errorf("failed to get delta chain of backup (%s), serving it at its revision %d without deltas: %v", fname, backupRev, err)
+			chain, rev = nil, backupRev
+		}
+	}
+
+	var br io.Reader = rc
+	codec := util.CompressionFromBackupName(fname)
+	if r.Method != http.MethodHead && chain != nil && len(chain.Upto(rev)) != 0 {
+		f, err := replayChain(be, rc, codec, chain, rev)
+		if err != nil {
+			logrus.Errorf("failed to replay deltas of backup (%s), serving it at its revision %d without deltas: %v", fname, backupRev, err)
+			// The backup has been read by the replay already.
+			brc, err := be.Open(fname)
+			if err != nil {
+				logrus.Errorf("fail to open backup (%s): %v", fname, err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer brc.Close()
+			br, rev = brc, backupRev
+		} else {
+			defer os.Remove(f.Name())
+			defer f.Close()
+			br, codec = f, ""
+		}
+	}
+
 	w.Header().Set(HTTPHeaderEtcdVersion, getVersionFromBackup(fname))
-	rev := util.MustParseRevision(fname)
 	w.Header().Set(HTTPHeaderRevision, strconv.FormatInt(rev, 10))
+	w.Header().Set(backupapi.HTTPHeaderBackup, fname)
 
 	if r.Method == http.MethodHead {
 		return
 	}
 
-	_, err = io.Copy(w, rc)
+	err = compression.WriteResponse(w, r, br, codec)
 	if err != nil {
 		logrus.Errorf("failed to write backup to %s: %v", r.RemoteAddr, err)
+		// The status has been sent already. Abort the response so that the client
+		// does not take a truncated or corrupted backup for a complete one.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// findBackup opens the first of the given backup names that can be opened, the newest backup at or before
+// the target if it is set, or the latest backup if latest is true.
+// If the backup storage fails, it falls back to the next backend, and returns the backend it opened the backup from.
+func (bs *BackupServer) findBackup(names []string, latest bool, target api.RestoreTarget) (backend.Backend, string, io.ReadCloser, error) {
+	bes := append([]backend.Backend{bs.backend}, bs.fallbacks...)
+	var (
+		name string
+		rc   io.ReadCloser
+		err  error
+	)
+	for i, be := range bes {
+		name, rc, err = openLatestOrBackup(be, names, latest, target)
+		if err == nil || err == errNoBackup || os.IsNotExist(err) {
+			return be, name, rc, err
+		}
+		if i < len(bes)-1 {
+			logrus.Warningf("failed to open backup from backend %d, falling back to the next one: %v", i, err)
+		}
+	}
+	return nil, name, nil, err
+}
+
+func openLatestOrBackup(be backend.Backend, names []string, latest bool, target api.RestoreTarget) (string, io.ReadCloser, error) {
+	if latest || target.IsSet() {
+		var (
+			name string
+			err  error
+		)
+		if target.IsSet() {
+			name, err = backend.FindBackupAt(be, target)
+		} else {
+			name, err = be.GetLatest()
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if len(name) == 0 {
+			return "", nil, errNoBackup
+		}
+		names = []string{name}
+	}
+	return openBackup(be, names)
+}
+
+// getChain returns the delta chain of the given backup, or nil if it has none.
+func getChain(be backend.Backend, backup string) (*delta.Chain, error) {
+	name, err := be.GetChain(backup)
+	if err != nil || len(name) == 0 {
+		return nil, err
+	}
+	return loadChain(be, name)
+}
+
+// replayChain returns a temporary file with the backup read from rc and the deltas of the chain
+// ending at or before rev replayed. If rev is negative, all deltas are replayed.
+func replayChain(be backend.Backend, rc io.Reader, codec string, chain *delta.Chain, rev int64) (*os.File, error) {
+	dr, err := compression.Decompress(rc, codec)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	f, rev, err := delta.Restore(dr, chain, be.Open, rev)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("replayed deltas of backup %s up to revision %d", chain.Backup, rev)
+	return f, nil
+}
+
+// openBackup opens the first of the given backup names that can be opened.
+// If none can, it returns the first name and the error of opening it.
+func openBackup(be backend.Backend, names []string) (string, io.ReadCloser, error) {
+	var firstErr error
+	for _, name := range names {
+		rc, err := be.Open(name)
+		if err == nil {
+			return name, rc, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
 	}
+	return names[0], nil, firstErr
 }
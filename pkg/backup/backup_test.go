@@ -15,6 +15,9 @@
 package backup
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -22,8 +25,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/backend"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
 )
 
 func TestRespHeaderHasVersionRevision(t *testing.T) {
@@ -86,6 +93,52 @@ func TestServeBackup(t *testing.T) {
 	}
 }
 
+func TestServeBackupAtTarget(t *testing.T) {
+	d, err := setupBackupDir("3.1.0_0000000000000002_etcd.backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	if err := writeBackup(d, "3.1.0_000000000000000a_etcd.backup", []byte("ignored")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target api.RestoreTarget
+		httpC  int
+		backup string
+		rev    string
+	}{
+		{api.RestoreTarget{TargetRevision: 20}, http.StatusOK, "3.1.0_000000000000000a_etcd.backup", "10"},
+		{api.RestoreTarget{TargetRevision: 10}, http.StatusOK, "3.1.0_000000000000000a_etcd.backup", "10"},
+		{api.RestoreTarget{TargetRevision: 9}, http.StatusOK, "3.1.0_0000000000000002_etcd.backup", "2"},
+		{api.RestoreTarget{TargetRevision: 1}, http.StatusNotFound, "", ""},
+		{api.RestoreTarget{TargetTime: "yesterday"}, http.StatusBadRequest, "", ""},
+	}
+
+	for i, tt := range tests {
+		bs := &BackupServer{
+			backend: backend.NewFileBackend(d),
+		}
+		req := &http.Request{
+			URL: backupapi.NewBackupURLAt("http", "ignore", "3.1.0", tt.target),
+		}
+		rr := httptest.NewRecorder()
+		bs.ServeBackup(rr, req)
+
+		if rr.Code != tt.httpC {
+			t.Errorf("#%d: http code want = %d, get = %d", i, tt.httpC, rr.Code)
+			continue
+		}
+		if get := rr.Header().Get(backupapi.HTTPHeaderBackup); get != tt.backup {
+			t.Errorf("#%d: backup want=%s, get=%s", i, tt.backup, get)
+		}
+		if get := rr.Header().Get(HTTPHeaderRevision); get != tt.rev {
+			t.Errorf("#%d: revision want=%s, get=%s", i, tt.rev, get)
+		}
+	}
+}
+
 func TestBackupVersionCompatiblity(t *testing.T) {
 	d, err := setupBackupDir("3.0.15_0000000000000002_etcd.backup")
 	if err != nil {
@@ -126,14 +179,195 @@ func TestBackupVersionCompatiblity(t *testing.T) {
 	}
 }
 
+func TestServeCompressedBackup(t *testing.T) {
+	d, err := ioutil.TempDir("", "backupdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("snapshot"))
+	zw.Close()
+	if err := writeBackup(d, "3.1.0_0000000000000002_etcd.backup.gz", gz.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		acceptEncoding  string
+		contentEncoding string
+		body            []byte
+	}{
+		{"", "", []byte("snapshot")},
+		{"deflate", "", []byte("snapshot")},
+		{"gzip, deflate", "gzip", gz.Bytes()},
+	}
+
+	for i, tt := range tests {
+		bs := &BackupServer{
+			backend: backend.NewFileBackend(d),
+		}
+		req := &http.Request{
+			URL:    backupapi.NewBackupURL("http", "ignore", "3.1.0", 2),
+			Header: http.Header{},
+		}
+		if len(tt.acceptEncoding) != 0 {
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		rr := httptest.NewRecorder()
+		bs.ServeBackup(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("#%d: http code want = %d, get = %d", i, http.StatusOK, rr.Code)
+		}
+		if get := rr.Header().Get("Content-Encoding"); get != tt.contentEncoding {
+			t.Errorf("#%d: content encoding want = %q, get = %q", i, tt.contentEncoding, get)
+		}
+		if !bytes.Equal(rr.Body.Bytes(), tt.body) {
+			t.Errorf("#%d: body want = %q, get = %q", i, tt.body, rr.Body.Bytes())
+		}
+	}
+}
+
+func TestServeBackupWithBrokenChain(t *testing.T) {
+	backup := "3.1.0_0000000000000002_etcd.backup"
+	link := delta.Link{Name: util.MakeDeltaName("3.1.0", 2, 10), StartRevision: 3, EndRevision: 10}
+	chain, err := json.Marshal(&delta.Chain{Backup: backup, Revision: 2, Deltas: []delta.Link{link}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		chain []byte
+	}{
+		// the chain is corrupt.
+		{[]byte("{")},
+		// the delta of the chain is missing.
+		{chain},
+	}
+
+	for i, tt := range tests {
+		d, err := setupBackupDir(backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(d)
+		if err := writeBackup(d, util.ChainName(backup), tt.chain); err != nil {
+			t.Fatal(err)
+		}
+		bs := &BackupServer{
+			backend: backend.NewFileBackend(d),
+		}
+		req := &http.Request{
+			URL: backupapi.NewBackupURL("http", "ignore", "", -1),
+		}
+		rr := httptest.NewRecorder()
+		bs.ServeBackup(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("#%d: http code want = %d, get = %d", i, http.StatusOK, rr.Code)
+			continue
+		}
+		if get := rr.Header().Get(HTTPHeaderRevision); get != "2" {
+			t.Errorf("#%d: revision want=%s, get=%s", i, "2", get)
+		}
+		if get := rr.Body.String(); get != "ignored" {
+			t.Errorf("#%d: body want = %q, get = %q", i, "ignored", get)
+		}
+	}
+}
+
+func TestServeCorruptedBackup(t *testing.T) {
+	d, err := setupBackupDir("3.1.0_0000000000000002_etcd.backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	if err := ioutil.WriteFile(filepath.Join(d, "3.1.0_0000000000000002_etcd.backup"), []byte("ignore"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bs := &BackupServer{
+		backend: backend.NewFileBackend(d),
+	}
+	req := &http.Request{
+		URL: backupapi.NewBackupURL("http", "ignore", "3.1.0", 2),
+	}
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("expect response to be aborted, got %v", r)
+		}
+	}()
+	bs.ServeBackup(httptest.NewRecorder(), req)
+}
+
 func setupBackupDir(snap string) (string, error) {
 	d, err := ioutil.TempDir("", "backupdir")
 	if err != nil {
 		return "", err
 	}
-	f := filepath.Join(d, snap)
-	if err := ioutil.WriteFile(f, []byte("ignored"), 0644); err != nil {
+	if err := writeBackup(d, snap, []byte("ignored")); err != nil {
 		return "", err
 	}
 	return d, nil
 }
+
+// writeBackup writes the backup and its manifest to dir.
+func writeBackup(dir, name string, data []byte) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return err
+	}
+	h := manifest.NewHasher(bytes.NewReader(data))
+	if _, err := ioutil.ReadAll(h); err != nil {
+		return err
+	}
+	b, err := json.Marshal(h.Complete(manifest.Manifest{}))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, manifest.Name(name)), b, 0644)
+}
+
+func TestServeBackupFallback(t *testing.T) {
+	d, err := setupBackupDir("3.1.0_0000000000000002_etcd.backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	// A file where the backup directory is expected makes the primary storage fail.
+	f, err := ioutil.TempFile("", "backupdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	tests := []struct {
+		primary  string
+		fallback string
+		httpC    int
+	}{
+		{f.Name(), d, http.StatusOK},
+		{f.Name(), f.Name(), http.StatusInternalServerError},
+		// A backup missing from the primary storage is not served from the fallbacks.
+		{filepath.Join(d, "empty"), d, http.StatusNotFound},
+	}
+	if err := os.Mkdir(filepath.Join(d, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range tests {
+		bs := &BackupServer{
+			backend:   backend.NewFileBackend(tt.primary),
+			fallbacks: []backend.Backend{backend.NewFileBackend(tt.fallback)},
+		}
+		req := &http.Request{
+			URL: backupapi.NewBackupURL("http", "ignore", "", -1),
+		}
+		rr := httptest.NewRecorder()
+		bs.ServeBackup(rr, req)
+
+		if rr.Code != tt.httpC {
+			t.Errorf("#%d: http code want = %d, get = %d", i, tt.httpC, rr.Code)
+		}
+	}
+}
@@ -33,6 +33,39 @@ type ServiceStatus struct {
 
 	// BackupSize is the total size of existing backups in MB.
 	BackupSize float64 `json:"backupSize"`
+
+	// LastVerifiedBackup is status of the most recent backup that passed verification.
+	LastVerifiedBackup *BackupStatus `json:"lastVerifiedBackup,omitempty"`
+
+	// LastVerificationError is the error of the last verification, if it failed.
+	LastVerificationError string `json:"lastVerificationError,omitempty"`
+
+	// NextScheduledBackup is the time of the next scheduled backup in RFC3339 format.
+	NextScheduledBackup string `json:"nextScheduledBackup,omitempty"`
+
+	// Destinations are the statuses of the secondary storages backups are replicated to.
+	Destinations []DestinationStatus `json:"destinations,omitempty"`
+}
+
+type DestinationStatus struct {
+	// Name is the name of the destination.
+	Name string `json:"name"`
+
+	// RecentBackup is status of the most recent backup in the destination.
+	RecentBackup *BackupStatus `json:"recentBackup,omitempty"`
+
+	// Backups is the total number of backups in the destination.
+	Backups int `json:"backups"`
+
+	// LagInRevisions is the number of revisions the most recent backup in the destination
+	// is behind the most recent backup in the primary storage.
+	LagInRevisions int64 `json:"lagInRevisions"`
+
+	// LastReplicationTime is the time of the last successful replication in RFC3339 format.
+	LastReplicationTime string `json:"lastReplicationTime,omitempty"`
+
+	// LastReplicationError is the error of the last replication, if it failed.
+	LastReplicationError string `json:"lastReplicationError,omitempty"`
 }
 
 type BackupStatus struct {
@@ -52,6 +85,42 @@ type BackupStatus struct {
 	TimeTookInSecond int `json:"timeTookInSecond"`
 }
 
+// BackupInfo describes a backup of the backup catalog.
+type BackupInfo struct {
+	// Name is the name of the backup in the backup storage.
+	Name string `json:"name"`
+
+	// Version is the etcd version of the backup.
+	Version string `json:"version"`
+
+	// Revision is the revision of the backup.
+	Revision int64 `json:"revision"`
+
+	// CreationTime is the creation time of the backup in RFC3339 format.
+	CreationTime string `json:"creationTime,omitempty"`
+
+	// SizeInBytes is the size of the backup as stored, compressed and encrypted.
+	SizeInBytes int64 `json:"sizeInBytes,omitempty"`
+
+	// SHA256 is the SHA-256 of the backup as stored.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// ClusterUID is the UID of the backed up cluster.
+	ClusterUID string `json:"clusterUID,omitempty"`
+
+	// ManifestError is the error of reading the manifest of the backup, if it failed.
+	// The fields read from the manifest are empty then.
+	ManifestError string `json:"manifestError,omitempty"`
+
+	// Deltas is the number of delta chunks recorded after the backup.
+	// It is only set when a single backup is inspected.
+	Deltas int `json:"deltas,omitempty"`
+
+	// EndRevision is the revision the backup restores to with its delta chunks replayed.
+	// It is only set when a single backup is inspected.
+	EndRevision int64 `json:"endRevision,omitempty"`
+}
+
 // ToS3Prefix concatenates s3Prefix, S3V1, namespace, clusterName to a single s3 prefix.
 // the concatenated prefix determines the location of S3 backup files.
 func ToS3Prefix(s3Prefix, namespace, clusterName string) string {
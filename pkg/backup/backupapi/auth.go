@@ -0,0 +1,131 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupapi
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	bearerPrefix = "Bearer "
+	// tokenSize is the number of random bytes of a token.
+	tokenSize = 32
+)
+
+// TokenFunc returns the bearer token that authenticates the request,
+// or an empty string if no token does.
+type TokenFunc func(r *http.Request) string
+
+// NewServerTLSConfig returns the TLS config of a backup API server presenting the certificate certPEM with its key keyPEM.
+// If clientCAPEM is not empty, the client certificates signed by its CAs are verified and authenticate the requests.
+func NewServerTLSConfig(certPEM, keyPEM, clientCAPEM []byte) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid serving certificate: %v", err)
+	}
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if len(clientCAPEM) != 0 {
+		tc.ClientCAs = x509.NewCertPool()
+		if !tc.ClientCAs.AppendCertsFromPEM(clientCAPEM) {
+			return nil, errors.New("invalid client CA bundle")
+		}
+		tc.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tc, nil
+}
+
+// Authenticate returns a handler that passes the requests authenticated by a verified client certificate
+// or by the bearer token tf returns to h, and rejects the others.
+func Authenticate(h http.Handler, tf TokenFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) != 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if t := bearerToken(r); len(t) != 0 {
+			if want := tf(r); len(want) != 0 && subtle.ConstantTimeCompare([]byte(t), []byte(want)) == 1 {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="etcd-backup"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	a := r.Header.Get("Authorization")
+	if !strings.HasPrefix(a, bearerPrefix) {
+		return ""
+	}
+	return a[len(bearerPrefix):]
+}
+
+// NewClient returns an HTTP client that verifies the backup API server with the CAs of caPEM
+// and authenticates with the bearer token.
+func NewClient(caPEM []byte, token string) (*http.Client, error) {
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("invalid CA bundle")
+	}
+	if len(token) == 0 {
+		return nil, errors.New("empty bearer token")
+	}
+	return &http.Client{Transport: &bearerTransport{
+		token: token,
+		rt: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: rootCAs},
+		},
+	}}, nil
+}
+
+// bearerTransport sets the bearer token on the requests it sends.
+type bearerTransport struct {
+	token string
+	rt    http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request.
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = make(http.Header, len(r.Header)+1)
+	for k, v := range r.Header {
+		r2.Header[k] = v
+	}
+	r2.Header.Set("Authorization", bearerPrefix+t.token)
+	return t.rt.RoundTrip(r2)
+}
+
+// NewToken returns a random bearer token.
+func NewToken() (string, error) {
+	b := make([]byte, tokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
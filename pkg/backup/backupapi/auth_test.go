@@ -0,0 +1,104 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := Authenticate(ok, func(r *http.Request) string {
+		if r.URL.Path == "/none" {
+			return ""
+		}
+		return "secret"
+	})
+
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{}}}
+	tests := []struct {
+		path  string
+		auth  string
+		tls   *tls.ConnectionState
+		httpC int
+	}{
+		{"/", "Bearer secret", nil, http.StatusOK},
+		{"/", "", nil, http.StatusUnauthorized},
+		{"/", "Bearer wrong", nil, http.StatusUnauthorized},
+		{"/", "Basic secret", nil, http.StatusUnauthorized},
+		{"/", "Bearer ", nil, http.StatusUnauthorized},
+		{"/none", "Bearer ", nil, http.StatusUnauthorized},
+		{"/", "", &tls.ConnectionState{}, http.StatusUnauthorized},
+		{"/", "", verified, http.StatusOK},
+		{"/none", "Bearer wrong", verified, http.StatusOK},
+	}
+	for i, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if len(tt.auth) != 0 {
+			r.Header.Set("Authorization", tt.auth)
+		}
+		r.TLS = tt.tls
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		if rr.Code != tt.httpC {
+			t.Errorf("#%d: status code = %d, want %d", i, rr.Code, tt.httpC)
+		}
+		if rr.Code == http.StatusUnauthorized && len(rr.Header().Get("WWW-Authenticate")) == 0 {
+			t.Errorf("#%d: no WWW-Authenticate header in unauthorized response", i)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	token, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewTLSServer(Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		func(r *http.Request) string { return token }))
+	defer ts.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+
+	tests := []struct {
+		token string
+		httpC int
+	}{
+		{token, http.StatusOK},
+		{token + "0", http.StatusUnauthorized},
+	}
+	for i, tt := range tests {
+		c, err := NewClient(caPEM, tt.token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("#%d: request failed: %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.httpC {
+			t.Errorf("#%d: status code = %d, want %d", i, resp.StatusCode, tt.httpC)
+		}
+	}
+
+	if _, err := NewClient(nil, token); err == nil {
+		t.Error("expected error for empty CA bundle")
+	}
+}
@@ -18,11 +18,21 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"strconv"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 )
 
 const (
-	HTTPQueryVersionKey  = "etcdVersion"
-	HTTPQueryRevisionKey = "etcdRevision"
+	HTTPQueryVersionKey        = "etcdVersion"
+	HTTPQueryRevisionKey       = "etcdRevision"
+	HTTPQueryTargetRevisionKey = "targetRevision"
+	HTTPQueryTargetTimeKey     = "targetTime"
+
+	HTTPHeaderEtcdVersion = "X-etcd-Version"
+	HTTPHeaderRevision    = "X-Revision"
+	// HTTPHeaderBackup holds the name of the backup served.
+	HTTPHeaderBackup = "X-Backup"
 )
 
 // NewBackupURL creates a URL struct for retrieving an existing backup.
@@ -30,6 +40,21 @@ func NewBackupURL(scheme, host, version string, revision int64) *url.URL {
 	return backupURLForCluster(scheme, host, "", version, revision)
 }
 
+// NewBackupURLAt creates a URL struct for retrieving the backup to restore
+// a cluster of the given version to the target t.
+func NewBackupURLAt(scheme, host, version string, t api.RestoreTarget) *url.URL {
+	u := NewBackupURL(scheme, host, version, -1)
+	uv := u.Query()
+	if t.TargetRevision > 0 {
+		uv.Set(HTTPQueryTargetRevisionKey, strconv.FormatInt(t.TargetRevision, 10))
+	}
+	if len(t.TargetTime) != 0 {
+		uv.Set(HTTPQueryTargetTimeKey, t.TargetTime)
+	}
+	u.RawQuery = uv.Encode()
+	return u
+}
+
 // backupURLForCluster creates a URL struct for retrieving an existing backup of given cluster.
 func backupURLForCluster(scheme, host, clusterName, version string, revision int64) *url.URL {
 	u := &url.URL{
@@ -47,6 +72,23 @@ func backupURLForCluster(scheme, host, clusterName, version string, revision int
 	return u
 }
 
+// NewCatalogURL creates a URL struct for the backup catalog, or for the backup
+// ref refers to if ref is not empty. A backup is referred to by name or revision.
+func NewCatalogURL(scheme, host, ref string) *url.URL {
+	return &url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   path.Join(APIV1, "backups", ref),
+	}
+}
+
+// NewBackupDataURL creates a URL struct for downloading the backup ref refers to, by name or revision.
+func NewBackupDataURL(scheme, host, ref string) *url.URL {
+	u := NewCatalogURL(scheme, host, ref)
+	u.Path = path.Join(u.Path, "data")
+	return u
+}
+
 // BackupURLForRestore creates a URL struct for retrieving an existing backup specified by a restore CR
 func BackupURLForRestore(scheme, host, restoreName string) *url.URL {
 	return &url.URL{
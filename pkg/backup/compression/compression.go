@@ -0,0 +1,132 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compression compresses and decompresses backups while they are streamed.
+package compression
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compress returns a reader that compresses the data read from r with the given codec.
+// An empty codec returns r as is.
+// The reader must be closed, which stops the compression if it has not been read to the end.
+func Compress(r io.Reader, codec string) (io.ReadCloser, error) {
+	var newWriter func(io.Writer) (io.WriteCloser, error)
+	switch codec {
+	case api.BackupCompressionNone:
+		return ioutil.NopCloser(r), nil
+	case api.BackupCompressionGzip:
+		newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		}
+	case api.BackupCompressionZstd:
+		newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w)
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression codec: %s", codec)
+	}
+
+	pr, pw := io.Pipe()
+	cw, err := newWriter(pw)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		_, err := io.Copy(cw, r)
+		if cerr := cw.Close(); err == nil {
+			err = cerr
+		}
+		pw.CloseWithError(err)
+	}()
+	return compressReader{pr}, nil
+}
+
+// compressReader stops the compression when it is closed.
+type compressReader struct {
+	*io.PipeReader
+}
+
+func (c compressReader) Close() error {
+	// Fails the pending and next writes of the compression, which then returns.
+	return c.PipeReader.CloseWithError(errCompressReaderClosed)
+}
+
+var errCompressReaderClosed = errors.New("compression reader closed")
+
+// Decompress returns a reader that decompresses the data read from r with the given codec.
+// An empty codec returns r as is.
+func Decompress(r io.Reader, codec string) (io.ReadCloser, error) {
+	switch codec {
+	case api.BackupCompressionNone:
+		return ioutil.NopCloser(r), nil
+	case api.BackupCompressionGzip:
+		return gzip.NewReader(r)
+	case api.BackupCompressionZstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zstdReadCloser{d}, nil
+	default:
+		return nil, fmt.Errorf("unknown compression codec: %s", codec)
+	}
+}
+
+// WriteResponse writes the backup read from r, compressed with codec, to w.
+// A gzip backup is sent as is with "Content-Encoding: gzip" if the client accepts it;
+// otherwise the backup is decompressed before it is sent.
+func WriteResponse(w http.ResponseWriter, req *http.Request, r io.Reader, codec string) error {
+	if codec == api.BackupCompressionGzip && acceptsGzip(req) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, err := io.Copy(w, r)
+		return err
+	}
+	rc, err := Decompress(r, codec)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+func acceptsGzip(req *http.Request) bool {
+	for _, enc := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(enc, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+type zstdReadCloser struct {
+	*zstd.Decoder
+}
+
+func (z zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compression
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+)
+
+func TestCompressDecompress(t *testing.T) {
+	plain := bytes.Repeat([]byte("etcd snapshot "), 10000)
+	codecs := []string{api.BackupCompressionNone, api.BackupCompressionGzip, api.BackupCompressionZstd}
+	for i, codec := range codecs {
+		r, err := Compress(bytes.NewReader(plain), codec)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		compressed, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if codec != api.BackupCompressionNone && len(compressed) >= len(plain) {
+			t.Errorf("#%d: %s compressed size = %d, want less than %d", i, codec, len(compressed), len(plain))
+		}
+
+		rc, err := Decompress(bytes.NewReader(compressed), codec)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		got, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("#%d: %s round trip changed the data", i, codec)
+		}
+	}
+}
+
+func TestCompressClose(t *testing.T) {
+	for i, codec := range []string{api.BackupCompressionGzip, api.BackupCompressionZstd} {
+		plain := bytes.Repeat([]byte("etcd snapshot "), 1<<20)
+		src := &countingReader{r: bytes.NewReader(plain)}
+		r, err := Compress(src, codec)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if _, err := r.Read(make([]byte, 1)); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if _, err := r.Read(make([]byte, 1)); err == nil {
+			t.Errorf("#%d: expect read after close to fail", i)
+		}
+		// The compression stops reading the source once the reader is closed.
+		n := int64(-1)
+		for j := 0; j < 100 && n != atomic.LoadInt64(&src.n); j++ {
+			n = atomic.LoadInt64(&src.n)
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n == int64(len(plain)) {
+			t.Errorf("#%d: source read to the end after close", i)
+		}
+	}
+}
+
+type countingReader struct {
+	r *bytes.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+func TestUnknownCodec(t *testing.T) {
+	if _, err := Compress(bytes.NewReader(nil), "lz4"); err == nil {
+		t.Error("expect compress with unknown codec to fail")
+	}
+	if _, err := Decompress(bytes.NewReader(nil), "lz4"); err == nil {
+		t.Error("expect decompress with unknown codec to fail")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package delta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Chain ties the delta chunks recorded after a full backup to it.
+type Chain struct {
+	// Backup is the name of the full backup the deltas apply to.
+	Backup string `json:"backup"`
+	// Revision is the revision of the backup.
+	Revision int64 `json:"revision"`
+	// Deltas are the chunks recorded after the backup, in revision order.
+	Deltas []Link `json:"deltas"`
+}
+
+// Link describes a delta chunk of a chain.
+type Link struct {
+	// Name is the name of the chunk.
+	Name          string `json:"name"`
+	StartRevision int64  `json:"startRevision"`
+	EndRevision   int64  `json:"endRevision"`
+	// CreationTime is the time the chunk was written in RFC3339 format.
+	CreationTime string `json:"creationTime"`
+}
+
+// EndRevision returns the newest revision the chain can restore to.
+func (c *Chain) EndRevision() int64 {
+	if len(c.Deltas) == 0 {
+		return c.Revision
+	}
+	return c.Deltas[len(c.Deltas)-1].EndRevision
+}
+
+// Append links the chunk l to the end of the chain.
+// The chunk must start right after the end of the chain.
+func (c *Chain) Append(l Link) error {
+	if end := c.EndRevision(); l.StartRevision != end+1 {
+		return fmt.Errorf("delta %s starts at revision %d, want %d", l.Name, l.StartRevision, end+1)
+	}
+	c.Deltas = append(c.Deltas, l)
+	return nil
+}
+
+// Upto returns the deltas to replay to restore up to the given revision:
+// the deltas ending at or before rev. If rev is negative, it returns all deltas.
+func (c *Chain) Upto(rev int64) []Link {
+	if rev < 0 {
+		return c.Deltas
+	}
+	var ls []Link
+	for _, l := range c.Deltas {
+		if l.EndRevision > rev {
+			break
+		}
+		ls = append(ls, l)
+	}
+	return ls
+}
+
+// Validate checks that the deltas of the chain are contiguous.
+func (c *Chain) Validate() error {
+	end := c.Revision
+	for _, l := range c.Deltas {
+		if l.StartRevision != end+1 || l.EndRevision < l.StartRevision {
+			return fmt.Errorf("delta %s covers revisions %d to %d, want a start at %d", l.Name, l.StartRevision, l.EndRevision, end+1)
+		}
+		end = l.EndRevision
+	}
+	return nil
+}
+
+// ReadChain decodes and validates a chain from r.
+func ReadChain(r io.Reader) (*Chain, error) {
+	c := &Chain{}
+	// Read to the end, so that a verifying reader checks the manifest.
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("failed to decode delta chain: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid delta chain of backup %s: %v", c.Backup, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package delta records the changes made to an etcd cluster after a full backup
+// and replays them on top of it.
+//
+// The changes are watched from the revision of the backup and written as chunks.
+// A chunk holds the last event of every key changed within its revisions.
+// The chunks recorded after a backup are tied to it by a Chain.
+package delta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// Chunk holds the changes made to an etcd cluster between two revisions.
+type Chunk struct {
+	// StartRevision is the first revision covered by the chunk.
+	StartRevision int64 `json:"startRevision"`
+	// EndRevision is the last revision covered by the chunk.
+	EndRevision int64 `json:"endRevision"`
+	// Events are the last event of every key changed between
+	// StartRevision and EndRevision, in revision order.
+	Events []*mvccpb.Event `json:"events"`
+}
+
+// NewChunk returns the chunk of the given events, which are compacted.
+// The chunk starts at startRev and ends at the revision of the last event.
+func NewChunk(startRev int64, evs []*mvccpb.Event) *Chunk {
+	c := &Chunk{
+		StartRevision: startRev,
+		EndRevision:   startRev - 1,
+		Events:        Compact(evs),
+	}
+	if len(evs) != 0 {
+		c.EndRevision = evs[len(evs)-1].Kv.ModRevision
+	}
+	return c
+}
+
+// Compact returns the last event of every key in evs, keeping their order.
+func Compact(evs []*mvccpb.Event) []*mvccpb.Event {
+	last := make(map[string]int, len(evs))
+	for i, ev := range evs {
+		last[string(ev.Kv.Key)] = i
+	}
+	compacted := make([]*mvccpb.Event, 0, len(last))
+	for i, ev := range evs {
+		if last[string(ev.Kv.Key)] == i {
+			compacted = append(compacted, ev)
+		}
+	}
+	return compacted
+}
+
+// ReadChunk decodes a chunk from r.
+func ReadChunk(r io.Reader) (*Chunk, error) {
+	c := &Chunk{}
+	// Read to the end, so that a verifying reader checks the manifest.
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("failed to decode delta chunk: %v", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package delta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+func put(key, val string, rev int64) *mvccpb.Event {
+	return &mvccpb.Event{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte(key), Value: []byte(val), ModRevision: rev}}
+}
+
+func del(key string, rev int64) *mvccpb.Event {
+	return &mvccpb.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: rev}}
+}
+
+func TestNewChunk(t *testing.T) {
+	evs := []*mvccpb.Event{put("a", "1", 3), put("b", "1", 4), put("a", "2", 5), del("b", 6), put("c", "1", 6)}
+	c := NewChunk(2, evs)
+	w := []*mvccpb.Event{put("a", "2", 5), del("b", 6), put("c", "1", 6)}
+	if c.StartRevision != 2 || c.EndRevision != 6 {
+		t.Errorf("revisions = %d to %d, want 2 to 6", c.StartRevision, c.EndRevision)
+	}
+	if !reflect.DeepEqual(c.Events, w) {
+		t.Errorf("events = %v, want %v", c.Events, w)
+	}
+}
+
+func TestChain(t *testing.T) {
+	c := &Chain{Backup: "b", Revision: 10}
+	if err := c.Append(Link{Name: "d1", StartRevision: 11, EndRevision: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Append(Link{Name: "d2", StartRevision: 25, EndRevision: 30}); err == nil {
+		t.Error("expect a gap in the chain to be rejected")
+	}
+	if err := c.Append(Link{Name: "d2", StartRevision: 21, EndRevision: 30}); err != nil {
+		t.Fatal(err)
+	}
+	if rev := c.EndRevision(); rev != 30 {
+		t.Errorf("end revision = %d, want 30", rev)
+	}
+
+	tests := []struct {
+		rev   int64
+		names []string
+	}{
+		{-1, []string{"d1", "d2"}},
+		{10, nil},
+		{25, []string{"d1"}},
+		{30, []string{"d1", "d2"}},
+	}
+	for i, tt := range tests {
+		var names []string
+		for _, l := range c.Upto(tt.rev) {
+			names = append(names, l.Name)
+		}
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("#%d: deltas = %v, want %v", i, names, tt.names)
+		}
+	}
+
+	c.Deltas[1].StartRevision = 22
+	if err := c.Validate(); err == nil {
+		t.Error("expect a gap in the chain to be invalid")
+	}
+}
+
+func revKey(main, sub int64, tombstone bool) []byte {
+	k := make([]byte, revBytesLen)
+	binary.BigEndian.PutUint64(k, uint64(main))
+	k[8] = '_'
+	binary.BigEndian.PutUint64(k[9:], uint64(sub))
+	if tombstone {
+		k = append(k, markTombstone)
+	}
+	return k
+}
+
+// newSnapshotFile writes a store with the key "foo" put at revision 2 and a lease of ID 7.
+func newSnapshotFile(t *testing.T) string {
+	f, err := ioutil.TempFile("", "delta-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		kb, err := tx.CreateBucket(keyBucketName)
+		if err != nil {
+			return err
+		}
+		v, _ := (&mvccpb.KeyValue{Key: []byte("foo"), Value: []byte("bar"), CreateRevision: 2, ModRevision: 2, Version: 1}).Marshal()
+		if err := kb.Put(revKey(2, 0, false), v); err != nil {
+			return err
+		}
+		lb, err := tx.CreateBucket(leaseBucketName)
+		if err != nil {
+			return err
+		}
+		k := make([]byte, 8)
+		binary.BigEndian.PutUint64(k, 7)
+		return lb.Put(k, []byte{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestSnapshotApply(t *testing.T) {
+	path := newSnapshotFile(t)
+	defer os.Remove(path)
+
+	s, err := OpenSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Revision() != 2 {
+		t.Errorf("revision = %d, want 2", s.Revision())
+	}
+	leased := put("a", "1", 3)
+	leased.Kv.Lease = 7
+	lost := put("b", "1", 3)
+	lost.Kv.Lease = 8
+	c := &Chunk{StartRevision: 1, EndRevision: 4, Events: []*mvccpb.Event{put("foo", "old", 2), leased, lost, del("foo", 4)}}
+	if err := s.Apply(c); err != nil {
+		t.Fatal(err)
+	}
+	if s.Revision() != 4 {
+		t.Errorf("revision = %d, want 4", s.Revision())
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size()%hashAlign != 32 {
+		t.Errorf("expect the SHA-256 to be appended, got size %d", fi.Size())
+	}
+	// Reopening checks the appended SHA-256.
+	s, err = OpenSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	want := map[string]*mvccpb.KeyValue{
+		string(revKey(2, 0, false)): {Key: []byte("foo"), Value: []byte("bar"), CreateRevision: 2, ModRevision: 2, Version: 1},
+		string(revKey(3, 0, false)): {Key: []byte("a"), Value: []byte("1"), ModRevision: 3, Lease: 7},
+		string(revKey(3, 1, false)): {Key: []byte("b"), Value: []byte("1"), ModRevision: 3},
+		string(revKey(4, 0, true)):  {Key: []byte("foo")},
+	}
+	got := make(map[string]*mvccpb.KeyValue)
+	err = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(keyBucketName).ForEach(func(k, v []byte) error {
+			kv := &mvccpb.KeyValue{}
+			if err := kv.Unmarshal(v); err != nil {
+				return err
+			}
+			got[string(k)] = kv
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	path := newSnapshotFile(t)
+	defer os.Remove(path)
+	snap, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := map[string]*Chunk{
+		"d1": NewChunk(3, []*mvccpb.Event{put("a", "1", 3), put("a", "2", 4)}),
+		"d2": NewChunk(5, []*mvccpb.Event{del("a", 5)}),
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		b, err := json.Marshal(chunks[name])
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	c := &Chain{Backup: "b", Revision: 2, Deltas: []Link{
+		{Name: "d1", StartRevision: 3, EndRevision: 4},
+		{Name: "d2", StartRevision: 5, EndRevision: 5},
+	}}
+
+	tests := []struct {
+		rev  int64
+		wrev int64
+	}{
+		{-1, 5},
+		{4, 4},
+		{2, 2},
+	}
+	for i, tt := range tests {
+		f, rev, err := Restore(bytes.NewReader(snap), c, open, tt.rev)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		f.Close()
+		os.Remove(f.Name())
+		if rev != tt.wrev {
+			t.Errorf("#%d: revision = %d, want %d", i, rev, tt.wrev)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package delta
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+)
+
+// Restore writes the snapshot read from r to a temporary file and replays the deltas
+// of the chain ending at or before rev on top of it. If rev is negative, all deltas are replayed.
+// open opens a delta chunk of the chain by name.
+//
+// It returns the file rewound to its start and the revision it restores to.
+// The caller is responsible for closing and removing the file.
+func Restore(r io.Reader, c *Chain, open manifest.OpenFunc, rev int64) (*os.File, int64, error) {
+	f, err := ioutil.TempFile("", "etcd-restore-")
+	if err != nil {
+		return nil, 0, err
+	}
+	restoredRev, err := restore(f, r, c, open, rev)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, 0, err
+	}
+	return f, restoredRev, nil
+}
+
+func restore(f *os.File, r io.Reader, c *Chain, open manifest.OpenFunc, rev int64) (int64, error) {
+	if _, err := io.Copy(f, r); err != nil {
+		return 0, fmt.Errorf("failed to copy backup %s: %v", c.Backup, err)
+	}
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+	s, err := OpenSnapshot(f.Name())
+	if err != nil {
+		return 0, fmt.Errorf("failed to open backup %s: %v", c.Backup, err)
+	}
+	restoredRev := c.Revision
+	for _, l := range c.Upto(rev) {
+		if err := applyLink(s, l, open); err != nil {
+			s.Close()
+			return 0, err
+		}
+		restoredRev = l.EndRevision
+	}
+	if err := s.Close(); err != nil {
+		return 0, err
+	}
+	return restoredRev, nil
+}
+
+func applyLink(s *Snapshot, l Link, open manifest.OpenFunc) error {
+	rc, err := open(l.Name)
+	if err != nil {
+		return fmt.Errorf("failed to open delta %s: %v", l.Name, err)
+	}
+	defer rc.Close()
+	c, err := ReadChunk(rc)
+	if err != nil {
+		return fmt.Errorf("failed to read delta %s: %v", l.Name, err)
+	}
+	if c.StartRevision != l.StartRevision || c.EndRevision != l.EndRevision {
+		return fmt.Errorf("delta %s covers revisions %d to %d, the chain has %d to %d",
+			l.Name, c.StartRevision, c.EndRevision, l.StartRevision, l.EndRevision)
+	}
+	return s.Apply(c)
+}
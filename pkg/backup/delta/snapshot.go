@@ -0,0 +1,198 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package delta
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+)
+
+// The layout of the etcd v3 store, see github.com/coreos/etcd/mvcc.
+var (
+	keyBucketName   = []byte("key")
+	leaseBucketName = []byte("lease")
+)
+
+const (
+	// revBytesLen is the length of a revision key: 8 bytes of main revision,
+	// a '_' and 8 bytes of sub revision.
+	revBytesLen = 8 + 1 + 8
+	// markTombstone is appended to the revision key of a deletion.
+	markTombstone byte = 't'
+
+	// hashAlign is the alignment of a snapshot database. etcdctl takes a snapshot whose
+	// size is sha256.Size past the alignment as ending with the SHA-256 of the database.
+	hashAlign = 512
+)
+
+// Snapshot is an etcd snapshot file that delta chunks are applied to.
+// Applied events are written to the store with their original revisions,
+// so that restoring the snapshot brings up the revision of the last applied event.
+type Snapshot struct {
+	path string
+	db   *bolt.DB
+	rev  int64
+}
+
+// OpenSnapshot opens the snapshot file at path for applying chunks.
+// The SHA-256 etcd appends to snapshots is checked and removed until the snapshot is closed.
+func OpenSnapshot(path string) (*Snapshot, error) {
+	if err := trimHash(path); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot database: %v", err)
+	}
+	s := &Snapshot{path: path, db: db}
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keyBucketName)
+		if b == nil {
+			return fmt.Errorf("snapshot has no %q bucket", keyBucketName)
+		}
+		if k, _ := b.Cursor().Last(); k != nil {
+			s.rev = int64(binary.BigEndian.Uint64(k[:8]))
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Revision returns the revision of the snapshot with the chunks applied so far.
+func (s *Snapshot) Revision() int64 {
+	return s.rev
+}
+
+// Apply writes the events of the chunk to the snapshot.
+// Events at or before the revision of the snapshot are skipped.
+func (s *Snapshot) Apply(c *Chunk) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		kb := tx.Bucket(keyBucketName)
+		lb := tx.Bucket(leaseBucketName)
+		var main, sub int64
+		for _, ev := range c.Events {
+			kv := *ev.Kv
+			if kv.ModRevision <= s.rev {
+				continue
+			}
+			if kv.ModRevision == main {
+				sub++
+			} else {
+				main, sub = kv.ModRevision, 0
+			}
+
+			key := make([]byte, revBytesLen, revBytesLen+1)
+			binary.BigEndian.PutUint64(key, uint64(main))
+			key[8] = '_'
+			binary.BigEndian.PutUint64(key[9:], uint64(sub))
+			if ev.Type == mvccpb.DELETE {
+				key = append(key, markTombstone)
+				kv = mvccpb.KeyValue{Key: kv.Key}
+			} else if kv.Lease != 0 && !hasLease(lb, kv.Lease) {
+				// The lease was granted after the backup and is lost with it.
+				kv.Lease = 0
+			}
+			v, err := kv.Marshal()
+			if err != nil {
+				return err
+			}
+			if err := kb.Put(key, v); err != nil {
+				return err
+			}
+		}
+		if main > s.rev {
+			s.rev = main
+		}
+		return nil
+	})
+}
+
+func hasLease(lb *bolt.Bucket, id int64) bool {
+	if lb == nil {
+		return false
+	}
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(id))
+	return lb.Get(k) != nil
+}
+
+// Close closes the snapshot database and appends its SHA-256 to it.
+func (s *Snapshot) Close() error {
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	return appendHash(s.path)
+}
+
+// trimHash checks and removes the SHA-256 appended to the snapshot, if any.
+func trimHash(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := fi.Size()
+	if size%hashAlign != sha256.Size {
+		return nil
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, size-sha256.Size); err != nil {
+		return err
+	}
+	want := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(f, want); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), want) {
+		return fmt.Errorf("snapshot does not match its SHA-256")
+	}
+	return f.Truncate(size - sha256.Size)
+}
+
+func appendHash(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if n%hashAlign != 0 {
+		return fmt.Errorf("snapshot database size %d is not aligned to %d bytes", n, hashAlign)
+	}
+	if _, err := f.Write(h.Sum(nil)); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,210 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"github.com/sirupsen/logrus"
+)
+
+// deltaRecorder watches the changes made after the latest backup
+// and saves them as delta chunks chained to the backup.
+type deltaRecorder struct {
+	bm       *BackupManager
+	interval time.Duration
+
+	// cancel stops recording the current chain.
+	cancel context.CancelFunc
+	// done is closed once recording the current chain has stopped.
+	done chan struct{}
+}
+
+// start stops recording the current chain and starts recording the chain c.
+// version is the etcd version of the backup of c.
+func (dr *deltaRecorder) start(c *delta.Chain, version string) {
+	dr.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	dr.cancel = cancel
+	dr.done = make(chan struct{})
+	go func() {
+		defer close(dr.done)
+		dr.record(ctx, c, version)
+	}()
+}
+
+func (dr *deltaRecorder) stop() {
+	if dr.cancel == nil {
+		return
+	}
+	dr.cancel()
+	<-dr.done
+	dr.cancel = nil
+}
+
+// resume starts recording the chain of the latest backup, continuing from its saved deltas.
+func (dr *deltaRecorder) resume() error {
+	be := dr.bm.be
+	name, err := be.GetLatest()
+	if err != nil || len(name) == 0 {
+		return err
+	}
+	m, err := be.Manifest(name)
+	if os.IsNotExist(err) {
+		// The etcd version of the backup is unknown. The deltas are recorded from the next backup.
+		logrus.Infof("not resuming deltas of backup %s without a manifest", name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c := &delta.Chain{Backup: name, Revision: m.Revision}
+	if cname, err := be.GetChain(name); err != nil {
+		return err
+	} else if len(cname) != 0 {
+		if c, err = loadChain(be, cname); err != nil {
+			return err
+		}
+	}
+	dr.start(c, m.EtcdVersion)
+	return nil
+}
+
+// record watches the changes made after the end of the chain and saves them
+// every interval until ctx is canceled or the watch fails.
+func (dr *deltaRecorder) record(ctx context.Context, c *delta.Chain, version string) {
+	etcdcli, err := dr.bm.etcdClient()
+	if err != nil {
+		logrus.Errorf("failed to record deltas of backup %s: %v", c.Backup, err)
+		return
+	}
+	defer etcdcli.Close()
+
+	logrus.Infof("recording deltas of backup %s from revision %d", c.Backup, c.EndRevision()+1)
+	wch := etcdcli.Watch(ctx, "", clientv3.WithFromKey(), clientv3.WithRev(c.EndRevision()+1))
+	tick := time.NewTicker(dr.interval)
+	defer tick.Stop()
+
+	var evs []*mvccpb.Event
+	for {
+		select {
+		case wr, ok := <-wch:
+			if !ok {
+				return
+			}
+			if err := wr.Err(); err != nil {
+				// The revisions following the chain may have been compacted.
+				// Recording resumes with the chain of the next backup.
+				logrus.Errorf("stopped recording deltas of backup %s: %v", c.Backup, err)
+				return
+			}
+			for _, ev := range wr.Events {
+				evs = append(evs, (*mvccpb.Event)(ev))
+			}
+		case <-tick.C:
+			if len(evs) == 0 {
+				continue
+			}
+			if err := dr.save(c, version, evs); err != nil {
+				// Keep the events to save them with the next chunk.
+				logrus.Errorf("failed to save deltas of backup %s: %v", c.Backup, err)
+				continue
+			}
+			evs = nil
+		}
+	}
+}
+
+// save saves the chunk of evs and links it to the chain c.
+func (dr *deltaRecorder) save(c *delta.Chain, version string, evs []*mvccpb.Event) error {
+	ch := delta.NewChunk(c.EndRevision()+1, evs)
+	l := delta.Link{
+		Name:          util.MakeDeltaName(version, c.Revision, ch.EndRevision),
+		StartRevision: ch.StartRevision,
+		EndRevision:   ch.EndRevision,
+		CreationTime:  time.Now().Format(time.RFC3339),
+	}
+	if err := dr.saveJSON(l.Name, ch, version, ch.EndRevision, l.CreationTime); err != nil {
+		return err
+	}
+
+	next := *c
+	next.Deltas = append([]delta.Link(nil), c.Deltas...)
+	if err := next.Append(l); err != nil {
+		return err
+	}
+	if err := dr.saveJSON(util.ChainName(c.Backup), &next, version, ch.EndRevision, l.CreationTime); err != nil {
+		return err
+	}
+	*c = next
+	logrus.Infof("saved delta %s (%d changes)", l.Name, len(ch.Events))
+	return nil
+}
+
+func (dr *deltaRecorder) saveJSON(name string, v interface{}, version string, rev int64, creationTime string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	m := manifest.Manifest{
+		EtcdVersion:  version,
+		Revision:     rev,
+		ClusterUID:   dr.bm.clusterUID,
+		CreationTime: creationTime,
+	}
+	_, err = dr.bm.save(name, bytes.NewReader(b), m)
+	return err
+}
+
+// chainLoadRetries is the number of times a chain failing its manifest is read again.
+// The chain is overwritten with every delta, so it may be read between the writes
+// of the chain and of its manifest.
+const chainLoadRetries = 3
+
+// loadChain reads the delta chain of the given name.
+func loadChain(be backend.Backend, name string) (*delta.Chain, error) {
+	for i := 0; ; i++ {
+		c, err := readChain(be, name)
+		if _, ok := err.(*manifest.MismatchError); ok && i < chainLoadRetries {
+			time.Sleep(time.Second)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read delta chain %s: %v", name, err)
+		}
+		return c, nil
+	}
+}
+
+func readChain(be backend.Backend, name string) (*delta.Chain, error) {
+	rc, err := be.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return delta.ReadChain(rc)
+}
@@ -0,0 +1,329 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encryption encrypts backups with AES-256-GCM while they are streamed
+// to a backend.
+//
+// An encrypted backup starts with a header:
+//
+//	magic (8 bytes) | key ID length (1 byte) | key ID | nonce prefix (7 bytes)
+//
+// followed by chunks of at most chunkSize bytes of plaintext, each sealed as:
+//
+//	ciphertext length (4 bytes, big endian) | ciphertext
+//
+// The nonce of a chunk is the nonce prefix, the chunk counter (4 bytes, big endian)
+// and a byte that is 1 for the last chunk and 0 otherwise, so that reordered or
+// truncated backups fail to decrypt. The header is the additional data of every chunk.
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// KeySize is the size of an AES-256 key in bytes.
+	KeySize = 32
+
+	chunkSize       = 64 * 1024
+	noncePrefixSize = 7
+	maxKeyIDLen     = 255
+)
+
+var magic = []byte("ETCDENC\x01")
+
+var errTruncated = errors.New("encrypted backup is truncated")
+
+// ErrNotEncrypted is returned when decrypting data without an encryption header,
+// unless the keyring allows unencrypted data.
+var ErrNotEncrypted = errors.New("backup is not encrypted")
+
+// Keyring holds the keys to encrypt and decrypt backups with.
+type Keyring struct {
+	// keyID is the ID of the key new backups are encrypted with.
+	keyID string
+	keys  map[string][]byte
+	// allowUnencrypted is true if data without an encryption header is decrypted as is.
+	allowUnencrypted bool
+}
+
+// NewKeyring returns a Keyring that encrypts with the key of activeKeyID and
+// decrypts with any of the given keys. A key is either 32 raw bytes or
+// the base64 encoding of them.
+func NewKeyring(keys map[string][]byte, activeKeyID string) (*Keyring, error) {
+	kr := &Keyring{
+		keyID: activeKeyID,
+		keys:  make(map[string][]byte, len(keys)),
+	}
+	for id, k := range keys {
+		if len(id) > maxKeyIDLen {
+			return nil, fmt.Errorf("key ID %q is longer than %d bytes", id, maxKeyIDLen)
+		}
+		key, err := parseKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %v", id, err)
+		}
+		kr.keys[id] = key
+	}
+	if _, ok := kr.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active key %q not found in keys %v", activeKeyID, kr.keyIDs())
+	}
+	return kr, nil
+}
+
+// SetAllowUnencrypted sets whether data without an encryption header is returned as is by Decrypt,
+// so that backups taken before encryption was enabled can still be read.
+func (kr *Keyring) SetAllowUnencrypted(allow bool) {
+	kr.allowUnencrypted = allow
+}
+
+func parseKey(k []byte) ([]byte, error) {
+	if len(k) == KeySize {
+		return k, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(k)))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, raw or base64 encoded", KeySize)
+	}
+	return key, nil
+}
+
+func (kr *Keyring) keyIDs() []string {
+	ids := make([]string, 0, len(kr.keys))
+	for id := range kr.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt returns a reader that encrypts the data read from r with the active key.
+func (kr *Keyring) Encrypt(r io.Reader) io.Reader {
+	return &encryptReader{kr: kr, src: bufio.NewReaderSize(r, chunkSize)}
+}
+
+// Decrypt returns a reader that decrypts the data read from r with the key
+// recorded in its header. Data without an encryption header fails with ErrNotEncrypted,
+// unless the keyring allows unencrypted data, in which case it is returned as is.
+func (kr *Keyring) Decrypt(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, chunkSize)
+	b, err := br.Peek(len(magic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(b, magic) {
+		if !kr.allowUnencrypted {
+			return nil, ErrNotEncrypted
+		}
+		return br, nil
+	}
+
+	header, keyID, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := kr.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("backup is encrypted with unknown key %q", keyID)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		aead:        aead,
+		src:         br,
+		header:      header,
+		noncePrefix: header[len(header)-noncePrefixSize:],
+	}, nil
+}
+
+func readHeader(br *bufio.Reader) (header []byte, keyID string, err error) {
+	fixed := make([]byte, len(magic)+1)
+	if _, err = io.ReadFull(br, fixed); err != nil {
+		return nil, "", errTruncated
+	}
+	rest := make([]byte, int(fixed[len(magic)])+noncePrefixSize)
+	if _, err = io.ReadFull(br, rest); err != nil {
+		return nil, "", errTruncated
+	}
+	header = append(fixed, rest...)
+	keyID = string(rest[:len(rest)-noncePrefixSize])
+	return header, keyID, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+type encryptReader struct {
+	kr  *Keyring
+	src *bufio.Reader
+
+	aead        cipher.AEAD
+	header      []byte
+	noncePrefix []byte
+	counter     uint32
+	done        bool
+	err         error
+
+	plain []byte
+	out   bytes.Buffer
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for e.out.Len() == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+		if e.done {
+			return 0, io.EOF
+		}
+		e.err = e.fill()
+	}
+	return e.out.Read(p)
+}
+
+// fill writes the header on the first call and the next sealed chunk into out.
+func (e *encryptReader) fill() error {
+	if e.aead == nil {
+		if err := e.init(); err != nil {
+			return err
+		}
+		e.out.Write(e.header)
+	}
+
+	n, err := io.ReadFull(e.src, e.plain)
+	last := false
+	switch err {
+	case nil:
+		if _, err := e.src.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+
+	sealed := e.aead.Seal(nil, chunkNonce(e.noncePrefix, e.counter, last), e.plain[:n], e.header)
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(sealed)))
+	e.out.Write(l[:])
+	e.out.Write(sealed)
+
+	e.counter++
+	e.done = last
+	return nil
+}
+
+func (e *encryptReader) init() error {
+	var err error
+	e.aead, err = newAEAD(e.kr.keys[e.kr.keyID])
+	if err != nil {
+		return err
+	}
+	e.noncePrefix = make([]byte, noncePrefixSize)
+	if _, err := rand.Read(e.noncePrefix); err != nil {
+		return err
+	}
+	e.header = append(e.header, magic...)
+	e.header = append(e.header, byte(len(e.kr.keyID)))
+	e.header = append(e.header, e.kr.keyID...)
+	e.header = append(e.header, e.noncePrefix...)
+	e.plain = make([]byte, chunkSize)
+	return nil
+}
+
+type decryptReader struct {
+	aead        cipher.AEAD
+	src         *bufio.Reader
+	header      []byte
+	noncePrefix []byte
+	counter     uint32
+	done        bool
+	err         error
+
+	out bytes.Buffer
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for d.out.Len() == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.fill()
+	}
+	return d.out.Read(p)
+}
+
+func (d *decryptReader) fill() error {
+	var l [4]byte
+	if _, err := io.ReadFull(d.src, l[:]); err != nil {
+		return errTruncated
+	}
+	n := binary.BigEndian.Uint32(l[:])
+	if n > chunkSize+uint32(d.aead.Overhead()) {
+		return fmt.Errorf("encrypted chunk %d is too large (%d bytes)", d.counter, n)
+	}
+	sealed := make([]byte, n)
+	if _, err := io.ReadFull(d.src, sealed); err != nil {
+		return errTruncated
+	}
+	last := false
+	if _, err := d.src.Peek(1); err == io.EOF {
+		last = true
+	} else if err != nil {
+		return err
+	}
+
+	plain, err := d.aead.Open(nil, chunkNonce(d.noncePrefix, d.counter, last), sealed, d.header)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk %d: %v", d.counter, err)
+	}
+	d.out.Write(plain)
+
+	d.counter++
+	d.done = last
+	return nil
+}
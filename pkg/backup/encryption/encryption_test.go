@@ -0,0 +1,180 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"testing"
+)
+
+func newTestKey(t *testing.T) []byte {
+	k := make([]byte, KeySize)
+	if _, err := rand.Read(k); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func encrypt(t *testing.T, kr *Keyring, plain []byte) []byte {
+	b, err := ioutil.ReadAll(kr.Encrypt(bytes.NewReader(plain)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func decrypt(kr *Keyring, sealed []byte) ([]byte, error) {
+	r, err := kr.Decrypt(bytes.NewReader(sealed))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	kr, err := NewKeyring(map[string][]byte{"k1": newTestKey(t)}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 17}
+	for i, size := range tests {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		sealed := encrypt(t, kr, plain)
+		if size > 0 && bytes.Contains(sealed, plain) {
+			t.Errorf("#%d: encrypted backup contains the plaintext", i)
+		}
+		got, err := decrypt(kr, sealed)
+		if err != nil {
+			t.Errorf("#%d: decrypt failed: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("#%d: decrypted data differs from plaintext of size %d", i, size)
+		}
+	}
+}
+
+func TestDecryptWithRetiredKey(t *testing.T) {
+	k1, k2 := newTestKey(t), newTestKey(t)
+	old, err := NewKeyring(map[string][]byte{"k1": k1}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := []byte("snapshot")
+	sealed := encrypt(t, old, plain)
+
+	// The key is rotated: k2 is active and k1 is kept, base64 encoded, to read old backups.
+	rotated, err := NewKeyring(map[string][]byte{
+		"k1": []byte(base64.StdEncoding.EncodeToString(k1)),
+		"k2": k2,
+	}, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decrypt(rotated, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("decrypted = %q, want %q", got, plain)
+	}
+
+	// Without k1 the backup can't be read.
+	k2only, err := NewKeyring(map[string][]byte{"k2": k2}, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decrypt(k2only, sealed); err == nil {
+		t.Error("expect decrypting with a missing key to fail")
+	}
+}
+
+func TestDecryptPlaintext(t *testing.T) {
+	kr, err := NewKeyring(map[string][]byte{"k1": newTestKey(t)}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, plain := range [][]byte{{}, []byte("abc"), []byte("an unencrypted snapshot")} {
+		if _, err := decrypt(kr, plain); err != ErrNotEncrypted {
+			t.Errorf("#%d: error = %v, want %v", i, err, ErrNotEncrypted)
+		}
+	}
+
+	kr.SetAllowUnencrypted(true)
+	for i, plain := range [][]byte{{}, []byte("abc"), []byte("an unencrypted snapshot")} {
+		got, err := decrypt(kr, plain)
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("#%d: got %q, want %q", i, got, plain)
+		}
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	kr, err := NewKeyring(map[string][]byte{"k1": newTestKey(t)}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := make([]byte, 2*chunkSize+5)
+	sealed := encrypt(t, kr, plain)
+	chunk := 4 + chunkSize + 16
+
+	tests := []func([]byte) []byte{
+		// flip a bit of the ciphertext
+		func(b []byte) []byte { b[len(b)-1] ^= 1; return b },
+		// truncate at a chunk boundary
+		func(b []byte) []byte { return b[:len(b)-(4+5+16)] },
+		// truncate in the middle of a chunk
+		func(b []byte) []byte { return b[:len(b)-3] },
+		// drop the first chunk
+		func(b []byte) []byte {
+			h := len(magic) + 1 + 2 + noncePrefixSize
+			return append(b[:h:h], b[h+chunk:]...)
+		},
+	}
+	for i, tamper := range tests {
+		b := tamper(append([]byte(nil), sealed...))
+		if _, err := decrypt(kr, b); err == nil {
+			t.Errorf("#%d: expect tampered backup to fail to decrypt", i)
+		}
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		keys   map[string][]byte
+		active string
+		ok     bool
+	}{
+		{map[string][]byte{"k1": make([]byte, KeySize)}, "k1", true},
+		{map[string][]byte{"k1": []byte(base64.StdEncoding.EncodeToString(make([]byte, KeySize)) + "\n")}, "k1", true},
+		{map[string][]byte{"k1": make([]byte, KeySize)}, "k2", false},
+		{map[string][]byte{"k1": make([]byte, 16)}, "k1", false},
+	}
+	for i, tt := range tests {
+		_, err := NewKeyring(tt.keys, tt.active)
+		if (err == nil) != tt.ok {
+			t.Errorf("#%d: ok = %v, want %v (err: %v)", i, err == nil, tt.ok, err)
+		}
+	}
+}
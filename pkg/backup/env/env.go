@@ -16,9 +16,11 @@ package env
 
 const (
 	ClusterSpec           = "CLUSTER_SPEC"
+	ClusterUID            = "CLUSTER_UID"
 	BackupSpec            = "BACKUP_SPEC"
 	AWSS3Bucket           = "AWS_S3_BUCKET"
 	AWSConfig             = "AWS_CONFIG_FILE"
+	AWSCABundle           = "AWS_CA_BUNDLE"
 	ABSContainer          = "AZURE_STORAGE_CONTAINER"
 	ABSStorageAccount     = "AZURE_STORAGE_ACCOUNT"
 	ABSStorageKey         = "AZURE_STORAGE_KEY"
@@ -29,4 +31,6 @@ const (
 	SwiftUsername         = "OS_USERNAME"
 	SwiftPassword         = "OS_PASSWORD"
 	SwiftDomainName       = "OS_DOMAIN_NAME"
+	GCSBucket             = "GCS_BUCKET"
+	GCSCredentials        = "GOOGLE_APPLICATION_CREDENTIALS"
 )
@@ -0,0 +1,272 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	v1 = "v1/"
+
+	// DefaultEndpoint is the endpoint of the Google Cloud Storage JSON API.
+	DefaultEndpoint = "https://www.googleapis.com"
+
+	readWriteScope = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+// GCS is a helper to wrap complex GCS logic
+type GCS struct {
+	bucket   string
+	prefix   string
+	endpoint string
+	client   *http.Client
+}
+
+type object struct {
+	Name string `json:"name"`
+	Size string `json:"size"`
+}
+
+type objectList struct {
+	Items         []object `json:"items"`
+	NextPageToken string   `json:"nextPageToken"`
+}
+
+// New returns a new GCS object for a given bucket using the given service account JSON key
+func New(bucket, prefix string, serviceAccountJSON []byte) (*GCS, error) {
+	client, err := NewClient(serviceAccountJSON)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromClient(bucket, prefix, DefaultEndpoint, client)
+}
+
+// NewClient returns an HTTP client authorizing its requests with the given service account JSON key
+func NewClient(serviceAccountJSON []byte) (*http.Client, error) {
+	conf, err := google.JWTConfigFromJSON(serviceAccountJSON, readWriteScope)
+	if err != nil {
+		return nil, fmt.Errorf("create GCS client failed: %v", err)
+	}
+	return conf.Client(context.Background()), nil
+}
+
+// NewFromClient returns a new GCS object for a given bucket using the supplied
+// endpoint and HTTP client. The client is expected to authorize its requests.
+func NewFromClient(bucket, prefix, endpoint string, client *http.Client) (*GCS, error) {
+	g := &GCS{
+		bucket:   bucket,
+		prefix:   prefix,
+		endpoint: endpoint,
+		client:   client,
+	}
+
+	// Check if supplied bucket exists
+	resp, err := g.do("GET", g.bucketURL(), nil, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("bucket %v does not exist", bucket)
+		}
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return g, nil
+}
+
+// Put puts a chunk of data into a GCS bucket using the provided key for its reference
+func (g *GCS) Put(key string, r io.Reader) error {
+	q := url.Values{}
+	q.Set("uploadType", "media")
+	q.Set("name", path.Join(v1, g.prefix, key))
+	u := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", g.endpoint, url.PathEscape(g.bucket), q.Encode())
+
+	resp, err := g.do("POST", u, r, map[string]string{"Content-Type": "application/octet-stream"})
+	if err != nil {
+		return fmt.Errorf("upload object failed: %v", err)
+	}
+	return resp.Body.Close()
+}
+
+// Path returns the "<bucket>/<object>" path of the object stored at key.
+func (g *GCS) Path(key string) string {
+	return path.Join(g.bucket, v1, g.prefix, key)
+}
+
+// Get gets the object specified by key from a GCS bucket.
+// It fails with an error satisfying os.IsNotExist if there is none.
+func (g *GCS) Get(key string) (io.ReadCloser, error) {
+	resp, err := g.do("GET", g.objectURL(path.Join(v1, g.prefix, key))+"?alt=media", nil, nil)
+	if isNotFound(err) {
+		return nil, &os.PathError{Op: "get", Path: key, Err: os.ErrNotExist}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete deletes the object specified by key from a GCS bucket
+func (g *GCS) Delete(key string) error {
+	resp, err := g.do("DELETE", g.objectURL(path.Join(v1, g.prefix, key)), nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// List lists all objects in a given GCS bucket
+func (g *GCS) List() ([]string, error) {
+	_, l, err := g.list(g.prefix)
+	return l, err
+}
+
+func (g *GCS) list(prefix string) (int64, []string, error) {
+	p := path.Join(v1, prefix) + "/"
+
+	keys := []string{}
+	var size int64
+	pageToken := ""
+	for {
+		q := url.Values{}
+		q.Set("prefix", p)
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		resp, err := g.do("GET", g.bucketURL()+"/o?"+q.Encode(), nil, nil)
+		if err != nil {
+			return -1, nil, err
+		}
+		var ol objectList
+		err = json.NewDecoder(resp.Body).Decode(&ol)
+		resp.Body.Close()
+		if err != nil {
+			return -1, nil, fmt.Errorf("decode object list failed: %v", err)
+		}
+
+		for _, o := range ol.Items {
+			keys = append(keys, o.Name[len(p):])
+			// The JSON API encodes the uint64 size as a string.
+			n, err := strconv.ParseInt(o.Size, 10, 64)
+			if err != nil {
+				return -1, nil, fmt.Errorf("invalid size (%s) of object %s: %v", o.Size, o.Name, err)
+			}
+			size += n
+		}
+
+		if ol.NextPageToken == "" {
+			break
+		}
+		pageToken = ol.NextPageToken
+	}
+
+	return size, keys, nil
+}
+
+// TotalSize returns the total size of all objects in a GCS bucket
+func (g *GCS) TotalSize() (int64, error) {
+	size, _, err := g.list(g.prefix)
+	return size, err
+}
+
+// Size returns the size of the object specified by key
+func (g *GCS) Size(key string) (int64, error) {
+	resp, err := g.do("GET", g.objectURL(path.Join(v1, g.prefix, key)), nil, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+
+	var o object
+	if err := json.NewDecoder(resp.Body).Decode(&o); err != nil {
+		return -1, fmt.Errorf("decode object metadata failed: %v", err)
+	}
+	return strconv.ParseInt(o.Size, 10, 64)
+}
+
+// CopyPrefix copies all objects with given prefix
+func (g *GCS) CopyPrefix(from string) error {
+	_, objects, err := g.list(from)
+	if err != nil {
+		return err
+	}
+	for _, basename := range objects {
+		src := path.Join(v1, from, basename)
+		dst := path.Join(v1, g.prefix, basename)
+		u := fmt.Sprintf("%s/copyTo/b/%s/o/%s", g.objectURL(src), url.PathEscape(g.bucket), url.PathEscape(dst))
+		resp, err := g.do("POST", u, nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (g *GCS) bucketURL() string {
+	return fmt.Sprintf("%s/storage/v1/b/%s", g.endpoint, url.PathEscape(g.bucket))
+}
+
+func (g *GCS) objectURL(name string) string {
+	return fmt.Sprintf("%s/o/%s", g.bucketURL(), url.PathEscape(name))
+}
+
+// statusError is returned when GCS responds with a non 2xx status code.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code (%d): %s", e.code, e.msg)
+}
+
+func isNotFound(err error) bool {
+	se, ok := err.(*statusError)
+	return ok && se.code == http.StatusNotFound
+}
+
+// do sends the request and returns the response if its status code is 2xx.
+// The caller is responsible for closing the response body.
+func (g *GCS) do(method, u string, body io.Reader, header map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &statusError{code: resp.StatusCode, msg: string(b)}
+	}
+	return resp, nil
+}
@@ -16,10 +16,16 @@ package backup
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/compression"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -27,18 +33,32 @@ import (
 )
 
 const (
-	HTTPHeaderEtcdVersion = "X-etcd-Version"
-	HTTPHeaderRevision    = "X-Revision"
+	HTTPHeaderEtcdVersion = backupapi.HTTPHeaderEtcdVersion
+	HTTPHeaderRevision    = backupapi.HTTPHeaderRevision
 )
 
 func (bc *BackupController) StartHTTP() {
 	http.HandleFunc(backupapi.APIV1+"/backup", bc.backupServer.ServeBackup)
 	http.HandleFunc(backupapi.APIV1+"/backupnow", bc.serveBackupNow)
+	http.HandleFunc(backupapi.APIV1+"/rollback", bc.serveRollback)
 	http.HandleFunc(backupapi.APIV1+"/status", bc.serveStatus)
+	http.HandleFunc(backupapi.APIV1+"/backups", bc.backupServer.ServeCatalog)
+	http.HandleFunc(backupapi.APIV1+"/backups/", bc.backupServer.ServeCatalog)
 	http.Handle("/metrics", prometheus.Handler())
 
-	logrus.Infof("listening on %v", bc.listenAddr)
-	panic(http.ListenAndServe(bc.listenAddr, nil))
+	if bc.apiTLSConfig == nil {
+		logrus.Infof("listening on %v", bc.listenAddr)
+		panic(http.ListenAndServe(bc.listenAddr, nil))
+	}
+	srv := &http.Server{
+		Addr: bc.listenAddr,
+		Handler: backupapi.Authenticate(http.DefaultServeMux, func(r *http.Request) string {
+			return bc.apiToken
+		}),
+		TLSConfig: bc.apiTLSConfig,
+	}
+	logrus.Infof("listening on %v with TLS", bc.listenAddr)
+	panic(srv.ListenAndServeTLS("", ""))
 }
 
 type backupNowAck struct {
@@ -47,9 +67,25 @@ type backupNowAck struct {
 }
 
 func (bc *BackupController) serveBackupNow(w http.ResponseWriter, r *http.Request) {
+	bc.serveRequest(w, bc.backupNow)
+}
+
+// serveRollback serves POST /rollback: the cluster was rolled back to an older revision.
+// The backups taken after its revision are retired, and a backup of the cluster is taken.
+func (bc *BackupController) serveRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	bc.serveRequest(w, bc.rollbackNow)
+}
+
+// serveRequest sends a request to the backup loop on reqchan and writes the status of the backup it took.
+func (bc *BackupController) serveRequest(w http.ResponseWriter, reqchan chan chan backupNowAck) {
 	ackchan := make(chan backupNowAck, 1)
 	select {
-	case bc.backupNow <- ackchan:
+	case reqchan <- ackchan:
 	case <-time.After(time.Minute):
 		http.Error(w, "timeout", http.StatusRequestTimeout)
 		return
@@ -91,9 +127,181 @@ func (bc *BackupController) serveStatus(w http.ResponseWriter, r *http.Request)
 	if len(rbs) != 0 {
 		s.RecentBackup = &rbs[len(rbs)-1]
 	}
+	if next := bc.scheduler.nextTime(); !next.IsZero() {
+		s.NextScheduledBackup = next.Format(time.RFC3339)
+	}
+	if bc.replicator != nil {
+		s.Destinations = bc.replicator.status()
+	}
+	if bc.verifier != nil {
+		s.LastVerifiedBackup, s.LastVerificationError = bc.verifier.status()
+	}
 
 	je := json.NewEncoder(w)
 	if err := je.Encode(&s); err != nil {
 		logrus.Errorf("failed to write service status to %s: %v", r.RemoteAddr, err)
 	}
 }
+
+// ServeCatalog serves the catalog of the backups in the backup storage:
+// - GET /backups lists the backups, sorted by revision.
+// - GET /backups/<ref> returns the information of the backup ref refers to, by name or revision.
+//   If several backups have the revision, it refers to the latest listed.
+// - GET /backups/<ref>/data downloads the backup, decrypted and without its deltas replayed.
+//   It is decompressed unless the client accepts its encoding.
+// - DELETE /backups/<ref> deletes the backup with its manifest and delta chain.
+// Deleting backups is forbidden unless the API is secured.
+func (bs *BackupServer) ServeCatalog(w http.ResponseWriter, r *http.Request) {
+	p := strings.Trim(strings.TrimPrefix(r.URL.Path, backupapi.APIV1+"/backups"), "/")
+	if len(p) == 0 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		bs.serveList(w, r)
+		return
+	}
+
+	ref, data := p, false
+	if strings.HasSuffix(p, "/data") {
+		ref, data = strings.TrimSuffix(p, "/data"), true
+	}
+	if strings.Contains(ref, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodDelete && !bs.secured {
+		http.Error(w, "deleting backups requires a secured backup API", http.StatusForbidden)
+		return
+	}
+	name, err := findBackupByRef(bs.backend, ref)
+	if err != nil {
+		logrus.Errorf("failed to find backup (%s): %v", ref, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(name) == 0 {
+		http.Error(w, fmt.Sprintf("backup (%s) not found", ref), http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case data && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		bs.serveData(w, r, name)
+	case !data && r.Method == http.MethodGet:
+		bs.serveInfo(w, r, name)
+	case !data && r.Method == http.MethodDelete:
+		err := bs.backend.Delete(name)
+		if os.IsNotExist(err) {
+			http.Error(w, fmt.Sprintf("backup (%s) not found", ref), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("failed to delete backup (%s): %v", name, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logrus.Infof("deleted backup %s on request from %s", name, r.RemoteAddr)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (bs *BackupServer) serveList(w http.ResponseWriter, r *http.Request) {
+	names, err := bs.backend.List()
+	if err != nil {
+		logrus.Errorf("failed to list backups: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	bis := []backupapi.BackupInfo{}
+	for _, name := range names {
+		bis = append(bis, backupInfo(bs.backend, name))
+	}
+	if err := json.NewEncoder(w).Encode(bis); err != nil {
+		logrus.Errorf("failed to write backup list to %s: %v", r.RemoteAddr, err)
+	}
+}
+
+func (bs *BackupServer) serveInfo(w http.ResponseWriter, r *http.Request, name string) {
+	bi := backupInfo(bs.backend, name)
+	chain, err := getChain(bs.backend, name)
+	if err != nil {
+		logrus.Errorf("failed to get delta chain of backup (%s): %v", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	bi.EndRevision = bi.Revision
+	if chain != nil {
+		bi.Deltas = len(chain.Deltas)
+		bi.EndRevision = chain.EndRevision()
+	}
+	if err := json.NewEncoder(w).Encode(&bi); err != nil {
+		logrus.Errorf("failed to write backup info to %s: %v", r.RemoteAddr, err)
+	}
+}
+
+func (bs *BackupServer) serveData(w http.ResponseWriter, r *http.Request, name string) {
+	rc, err := bs.backend.Open(name)
+	if os.IsNotExist(err) {
+		http.Error(w, fmt.Sprintf("backup (%s) not found", name), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("fail to open backup (%s): %v", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set(HTTPHeaderEtcdVersion, getVersionFromBackup(name))
+	w.Header().Set(HTTPHeaderRevision, strconv.FormatInt(util.MustParseRevision(name), 10))
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := compression.WriteResponse(w, r, rc, util.CompressionFromBackupName(name)); err != nil {
+		logrus.Errorf("failed to write backup to %s: %v", r.RemoteAddr, err)
+		// The status has been sent already. Abort the response so that the client
+		// does not take a truncated or corrupted backup for a complete one.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// findBackupByRef returns the name of the backup ref refers to: the backup of that name,
+// or else the latest backup of that revision. It returns an empty string if there is none.
+func findBackupByRef(be backend.Backend, ref string) (string, error) {
+	names, err := be.List()
+	if err != nil {
+		return "", err
+	}
+	rev, rerr := strconv.ParseInt(ref, 10, 64)
+	found := ""
+	for _, name := range names {
+		if name == ref {
+			return name, nil
+		}
+		if rerr == nil && util.MustParseRevision(name) == rev {
+			found = name
+		}
+	}
+	return found, nil
+}
+
+// backupInfo returns the information of the backup of the given name, read from its manifest.
+func backupInfo(be backend.Backend, name string) backupapi.BackupInfo {
+	bi := backupapi.BackupInfo{
+		Name:     name,
+		Version:  getVersionFromBackup(name),
+		Revision: util.MustParseRevision(name),
+	}
+	m, err := be.Manifest(name)
+	if err != nil {
+		bi.ManifestError = err.Error()
+		return bi
+	}
+	bi.CreationTime = m.CreationTime
+	bi.SizeInBytes = m.Size
+	bi.SHA256 = m.SHA256
+	bi.ClusterUID = m.ClusterUID
+	return bi
+}
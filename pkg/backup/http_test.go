@@ -0,0 +1,135 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+)
+
+func TestServeCatalog(t *testing.T) {
+	b1 := "3.1.0_0000000000000001_etcd.backup"
+	b2 := "3.1.0_0000000000000002_etcd.backup"
+	d, err := setupBackupDir(b1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	if err := writeBackup(d, b2, []byte("snapshot")); err != nil {
+		t.Fatal(err)
+	}
+	bs := &BackupServer{backend: backend.NewFileBackend(d), secured: true}
+
+	serve := func(method string, ref string, data bool) *httptest.ResponseRecorder {
+		u := backupapi.NewCatalogURL("http", "ignore", ref)
+		if data {
+			u = backupapi.NewBackupDataURL("http", "ignore", ref)
+		}
+		rr := httptest.NewRecorder()
+		bs.ServeCatalog(rr, &http.Request{Method: method, URL: u})
+		return rr
+	}
+
+	rr := serve(http.MethodGet, "", false)
+	var bis []backupapi.BackupInfo
+	if err := json.NewDecoder(rr.Body).Decode(&bis); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, bi := range bis {
+		names = append(names, bi.Name)
+	}
+	if want := []string{b1, b2}; !reflect.DeepEqual(names, want) {
+		t.Errorf("listed backups = %v, want %v", names, want)
+	}
+
+	tests := []struct {
+		method string
+		ref    string
+		data   bool
+		httpC  int
+		body   string
+	}{
+		{http.MethodGet, b2, false, http.StatusOK, ""},
+		{http.MethodGet, "2", false, http.StatusOK, ""},
+		{http.MethodGet, "3", false, http.StatusNotFound, ""},
+		{http.MethodGet, "2", true, http.StatusOK, "snapshot"},
+		{http.MethodPost, "2", false, http.StatusMethodNotAllowed, ""},
+		{http.MethodDelete, b1, false, http.StatusOK, ""},
+		{http.MethodGet, b1, true, http.StatusNotFound, ""},
+	}
+	for i, tt := range tests {
+		rr := serve(tt.method, tt.ref, tt.data)
+		if rr.Code != tt.httpC {
+			t.Errorf("#%d: http code want = %d, get = %d", i, tt.httpC, rr.Code)
+			continue
+		}
+		if rr.Code != http.StatusOK || tt.method != http.MethodGet {
+			continue
+		}
+		if tt.data {
+			if rr.Body.String() != tt.body {
+				t.Errorf("#%d: body want = %q, get = %q", i, tt.body, rr.Body.String())
+			}
+			continue
+		}
+		var bi backupapi.BackupInfo
+		if err := json.NewDecoder(rr.Body).Decode(&bi); err != nil {
+			t.Fatal(err)
+		}
+		if bi.Name != b2 || bi.Revision != 2 || bi.EndRevision != 2 || len(bi.ManifestError) != 0 {
+			t.Errorf("#%d: unexpected backup info %+v", i, bi)
+		}
+	}
+}
+
+func TestServeCatalogUnsecured(t *testing.T) {
+	b1 := "3.1.0_0000000000000001_etcd.backup"
+	d, err := setupBackupDir(b1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	bs := &BackupServer{backend: backend.NewFileBackend(d)}
+
+	tests := []struct {
+		method string
+		url    *url.URL
+		httpC  int
+	}{
+		{http.MethodGet, backupapi.NewCatalogURL("http", "ignore", "1"), http.StatusOK},
+		{http.MethodGet, backupapi.NewBackupDataURL("http", "ignore", "1"), http.StatusOK},
+		{http.MethodDelete, backupapi.NewCatalogURL("http", "ignore", "1"), http.StatusForbidden},
+	}
+	for i, tt := range tests {
+		rr := httptest.NewRecorder()
+		bs.ServeCatalog(rr, &http.Request{Method: tt.method, URL: tt.url})
+		if rr.Code != tt.httpC {
+			t.Errorf("#%d: http code want = %d, get = %d", i, tt.httpC, rr.Code)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(d, b1)); err != nil {
+		t.Errorf("backup deleted through an unsecured API: %v", err)
+	}
+}
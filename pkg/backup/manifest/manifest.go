@@ -0,0 +1,207 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package manifest records the integrity of backups.
+//
+// Every backup is stored with a manifest next to it, named after the backup with
+// the Suffix appended. The manifest holds the SHA-256 and size of the stored bytes,
+// so that a truncated or corrupted backup is detected when it is read.
+// Backups taken before manifests were introduced have none, and are read unverified.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Suffix is appended to the name of a backup to get the name of its manifest.
+const Suffix = ".manifest.json"
+
+// Manifest describes a stored backup.
+type Manifest struct {
+	// SHA256 is the hex encoded SHA-256 of the stored backup.
+	SHA256 string `json:"sha256"`
+	// Size is the size of the stored backup in bytes.
+	Size int64 `json:"size"`
+
+	EtcdVersion string `json:"etcdVersion"`
+	Revision    int64  `json:"revision"`
+	// KeyCount is the number of keys of the cluster at Revision.
+	KeyCount int64 `json:"keyCount"`
+	// ClusterUID is the UID of the EtcdCluster the backup was taken from, if known.
+	ClusterUID string `json:"clusterUID,omitempty"`
+	// CreationTime is the time the backup was taken in RFC3339 format.
+	CreationTime string `json:"creationTime"`
+}
+
+// Name returns the name of the manifest of the given backup.
+func Name(backup string) string {
+	return backup + Suffix
+}
+
+// Validate checks that the manifest can be used to verify a backup.
+func (m *Manifest) Validate() error {
+	if b, err := hex.DecodeString(m.SHA256); err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("invalid sha256 %q", m.SHA256)
+	}
+	if m.Size < 0 {
+		return fmt.Errorf("invalid size %d", m.Size)
+	}
+	return nil
+}
+
+// Parse parses and validates a manifest.
+func Parse(b []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %v", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// OpenFunc opens the object of the given name for reading.
+type OpenFunc func(name string) (io.ReadCloser, error)
+
+// InvalidError is returned when the manifest of a backup exists but cannot be parsed or is invalid.
+type InvalidError struct {
+	Backup string
+	Err    error
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("invalid manifest of backup %s: %v", e.Backup, e.Err)
+}
+
+// Load reads and parses the manifest of the given backup with open.
+// It fails with an error satisfying os.IsNotExist if the backup has no manifest,
+// and with an *InvalidError if the manifest is invalid.
+func Load(open OpenFunc, backup string) (*Manifest, error) {
+	rc, err := open(Name(backup))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(b)
+	if err != nil {
+		return nil, &InvalidError{Backup: backup, Err: err}
+	}
+	return m, nil
+}
+
+// Open opens the given backup with open and returns a reader that verifies it
+// against its manifest. The reader fails with a *MismatchError instead of
+// returning io.EOF if the backup does not match.
+// A backup without a manifest is read unverified.
+func Open(open OpenFunc, backup string) (io.ReadCloser, error) {
+	rc, err := open(backup)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Load(open, backup)
+	if os.IsNotExist(err) {
+		logrus.Warningf("backup %s has no manifest, reading it unverified", backup)
+		return rc, nil
+	}
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("failed to load manifest of backup %s: %v", backup, err)
+	}
+	return NewVerifier(rc, backup, m), nil
+}
+
+// Hasher computes the SHA-256 and size of the data read through it.
+type Hasher struct {
+	r    io.Reader
+	h    hash.Hash
+	size int64
+}
+
+// NewHasher returns a Hasher reading from r.
+func NewHasher(r io.Reader) *Hasher {
+	return &Hasher{r: r, h: sha256.New()}
+}
+
+func (h *Hasher) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.h.Write(p[:n])
+	h.size += int64(n)
+	return n, err
+}
+
+// Size returns the number of bytes read so far.
+func (h *Hasher) Size() int64 {
+	return h.size
+}
+
+// Complete returns m with the SHA-256 and size of the data read so far.
+func (h *Hasher) Complete(m Manifest) Manifest {
+	m.SHA256 = hex.EncodeToString(h.h.Sum(nil))
+	m.Size = h.size
+	return m
+}
+
+// MismatchError is returned when a backup does not match its manifest.
+type MismatchError struct {
+	Backup string
+	Want   *Manifest
+	SHA256 string
+	Size   int64
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("backup %s does not match its manifest: got sha256 %s and size %d, want sha256 %s and size %d",
+		e.Backup, e.SHA256, e.Size, e.Want.SHA256, e.Want.Size)
+}
+
+type verifier struct {
+	rc     io.ReadCloser
+	backup string
+	m      *Manifest
+	h      *Hasher
+}
+
+// NewVerifier returns a reader that verifies the backup read from rc against m.
+func NewVerifier(rc io.ReadCloser, backup string, m *Manifest) io.ReadCloser {
+	return &verifier{rc: rc, backup: backup, m: m, h: NewHasher(rc)}
+}
+
+func (v *verifier) Read(p []byte) (int, error) {
+	n, err := v.h.Read(p)
+	if err == io.EOF {
+		got := v.h.Complete(Manifest{})
+		if got.SHA256 != v.m.SHA256 || got.Size != v.m.Size {
+			return n, &MismatchError{Backup: v.backup, Want: v.m, SHA256: got.SHA256, Size: got.Size}
+		}
+	}
+	return n, err
+}
+
+func (v *verifier) Close() error {
+	return v.rc.Close()
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type memStore map[string][]byte
+
+func (s memStore) open(name string) (io.ReadCloser, error) {
+	b, ok := s[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s memStore) put(name string, data []byte) {
+	h := NewHasher(bytes.NewReader(data))
+	b, _ := ioutil.ReadAll(h)
+	s[name] = b
+	mb, _ := json.Marshal(h.Complete(Manifest{EtcdVersion: "3.1.0", Revision: 1}))
+	s[Name(name)] = mb
+}
+
+func TestOpenVerifies(t *testing.T) {
+	s := memStore{}
+	s.put("good", []byte("snapshot"))
+	s.put("truncated", []byte("snapshot"))
+	s["truncated"] = []byte("snap")
+	s.put("corrupted", []byte("snapshot"))
+	s["corrupted"] = []byte("snapsh0t")
+	s["nomanifest"] = []byte("snapshot")
+	s.put("invalidmanifest", []byte("snapshot"))
+	s[Name("invalidmanifest")] = []byte("not json")
+
+	tests := []struct {
+		name     string
+		openErr  bool
+		mismatch bool
+	}{
+		{"good", false, false},
+		{"truncated", false, true},
+		{"corrupted", false, true},
+		{"nomanifest", false, false}, // backups taken before manifests are read unverified
+		{"invalidmanifest", true, false},
+	}
+	for i, tt := range tests {
+		rc, err := Open(s.open, tt.name)
+		if (err != nil) != tt.openErr {
+			t.Errorf("#%d: open error = %v, want error %v", i, err, tt.openErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		_, isMismatch := err.(*MismatchError)
+		if isMismatch != tt.mismatch {
+			t.Errorf("#%d: read error = %v, want mismatch %v", i, err, tt.mismatch)
+		}
+		if !tt.mismatch && (err != nil || string(b) != "snapshot") {
+			t.Errorf("#%d: read %q (%v), want %q", i, b, err, "snapshot")
+		}
+	}
+}
+
+func TestOpenMissingBackup(t *testing.T) {
+	_, err := Open(memStore{}.open, "missing")
+	if !os.IsNotExist(err) {
+		t.Errorf("expect not exist error, got %v", err)
+	}
+}
+
+func TestOpenManifestReadError(t *testing.T) {
+	s := memStore{}
+	s.put("backup", []byte("snapshot"))
+	open := func(name string) (io.ReadCloser, error) {
+		if name == Name("backup") {
+			return nil, errors.New("transient error")
+		}
+		return s.open(name)
+	}
+	// a failure to read the manifest must not be mistaken for a backup without one.
+	if _, err := Open(open, "backup"); err == nil {
+		t.Error("expect error when the manifest cannot be read")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		data  string
+		valid bool
+	}{
+		{`{"sha256":"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae","size":3}`, true},
+		{`{"sha256":"2c26b46b","size":3}`, false},
+		{`{"sha256":"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae","size":-1}`, false},
+		{`not json`, false},
+	}
+	for i, tt := range tests {
+		_, err := Parse([]byte(tt.data))
+		if (err == nil) != tt.valid {
+			t.Errorf("#%d: parse error = %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package multipart uploads objects of unknown size to object stores in parts,
+// as they are read, so that they are never held whole in memory or on disk.
+package multipart
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPartSize is the default size of the parts in bytes.
+	DefaultPartSize = 16 * 1024 * 1024
+	// DefaultConcurrency is the default number of parts uploaded at once.
+	DefaultConcurrency = 4
+	// DefaultMaxRetries is the default number of times the upload of a part is retried.
+	DefaultMaxRetries = 3
+)
+
+// retryInterval is the delay before the first retry. It doubles with every retry.
+var retryInterval = time.Second
+
+// Options configures multipart uploads. Zero values are replaced with the defaults.
+type Options struct {
+	// PartSize is the size of the parts in bytes.
+	PartSize int
+	// Concurrency is the number of parts uploaded at once.
+	// An upload holds at most PartSize * Concurrency bytes in memory.
+	Concurrency int
+	// MaxRetries is the number of times the upload of a part is retried before the upload fails.
+	MaxRetries int
+}
+
+// WithDefaults returns o with its zero values replaced with the defaults.
+func (o Options) WithDefaults() Options {
+	if o.PartSize <= 0 {
+		o.PartSize = DefaultPartSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultConcurrency
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = DefaultMaxRetries
+	}
+	return o
+}
+
+// Uploader uploads a single object to an object store.
+type Uploader interface {
+	// Put uploads b as the whole object. It is used instead of a multipart upload
+	// when the object fits in a single part.
+	Put(b []byte) error
+	// Start starts a multipart upload.
+	Start() error
+	// UploadPart uploads the part of the given number. Parts are numbered from 1,
+	// and are uploaded concurrently and in any order.
+	UploadPart(num int, b []byte) error
+	// Complete completes the multipart upload of parts 1 to parts.
+	Complete(parts int) error
+	// Abort discards the parts of a multipart upload that failed.
+	Abort() error
+}
+
+// Upload reads r to its end and uploads it with u in parts of o.PartSize bytes.
+// Every request is retried up to o.MaxRetries times. It returns the number of bytes uploaded.
+func Upload(r io.Reader, o Options, u Uploader) (int64, error) {
+	o = o.WithDefaults()
+	first := make([]byte, o.PartSize)
+	n, err := io.ReadFull(r, first)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return int64(n), retry(o.MaxRetries, func() error { return u.Put(first[:n]) })
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := retry(o.MaxRetries, u.Start); err != nil {
+		return 0, fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+	size, parts, err := uploadParts(r, first, o, u)
+	if err == nil {
+		err = retry(o.MaxRetries, func() error { return u.Complete(parts) })
+	}
+	if err != nil {
+		if aerr := u.Abort(); aerr != nil {
+			logrus.Warningf("failed to abort multipart upload: %v", aerr)
+		}
+		return 0, err
+	}
+	return size, nil
+}
+
+// uploadParts uploads first and the rest of r as parts, up to o.Concurrency at once.
+// It returns the number of bytes and parts uploaded.
+func uploadParts(r io.Reader, first []byte, o Options, u Uploader) (int64, int, error) {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		uerr error
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return uerr != nil
+	}
+
+	// bufs returns the buffers of the uploaded parts, so that they are reused for the next parts.
+	bufs := make(chan []byte, o.Concurrency)
+	allocated := 1
+	next := func() []byte {
+		if allocated < o.Concurrency {
+			allocated++
+			return make([]byte, o.PartSize)
+		}
+		return <-bufs
+	}
+
+	var (
+		size int64
+		num  int
+		rerr error
+	)
+	b, n := first, len(first)
+	for {
+		num++
+		size += int64(n)
+		wg.Add(1)
+		go func(num int, b []byte, n int) {
+			defer wg.Done()
+			err := retry(o.MaxRetries, func() error { return u.UploadPart(num, b[:n]) })
+			if err != nil {
+				mu.Lock()
+				if uerr == nil {
+					uerr = fmt.Errorf("failed to upload part %d: %v", num, err)
+				}
+				mu.Unlock()
+			}
+			bufs <- b
+		}(num, b, n)
+
+		// Only the last part is shorter than a full part.
+		if n < o.PartSize || failed() {
+			break
+		}
+		b = next()
+		n, rerr = io.ReadFull(r, b)
+		if rerr == io.EOF {
+			rerr = nil
+			break
+		}
+		if rerr == io.ErrUnexpectedEOF {
+			rerr = nil
+		}
+		if rerr != nil {
+			break
+		}
+	}
+	wg.Wait()
+
+	if rerr != nil {
+		return 0, 0, rerr
+	}
+	return size, num, uerr
+}
+
+// retry calls f until it succeeds, up to max retries.
+func retry(max int, f func() error) error {
+	d := retryInterval
+	for i := 0; ; i++ {
+		err := f()
+		if err == nil || i >= max {
+			return err
+		}
+		logrus.Warningf("upload failed, retrying in %v: %v", d, err)
+		time.Sleep(d)
+		d *= 2
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multipart
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeUploader stores the uploaded parts in memory.
+// The upload of a part fails as many times as set in failures.
+type fakeUploader struct {
+	mu       sync.Mutex
+	object   []byte
+	parts    map[int][]byte
+	failures map[int]int
+	aborted  bool
+}
+
+func (u *fakeUploader) Put(b []byte) error {
+	u.object = append([]byte(nil), b...)
+	return nil
+}
+
+func (u *fakeUploader) Start() error {
+	u.parts = map[int][]byte{}
+	return nil
+}
+
+func (u *fakeUploader) UploadPart(num int, b []byte) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.failures[num] > 0 {
+		u.failures[num]--
+		return errors.New("fake upload failure")
+	}
+	u.parts[num] = append([]byte(nil), b...)
+	return nil
+}
+
+func (u *fakeUploader) Complete(parts int) error {
+	for i := 1; i <= parts; i++ {
+		u.object = append(u.object, u.parts[i]...)
+	}
+	return nil
+}
+
+func (u *fakeUploader) Abort() error {
+	u.aborted = true
+	return nil
+}
+
+func TestUpload(t *testing.T) {
+	retryInterval = 0
+	data := make([]byte, 10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	tests := []struct {
+		size     int
+		failures map[int]int
+		parts    int
+		err      bool
+	}{
+		// fits in a single part
+		{0, nil, 0, false},
+		{3, nil, 0, false},
+		// ends with a full part
+		{4, nil, 1, false},
+		{8, nil, 2, false},
+		// ends with a short part
+		{10, nil, 3, false},
+		// a part is retried
+		{10, map[int]int{2: DefaultMaxRetries}, 3, false},
+		// a part fails after all the retries
+		{10, map[int]int{2: DefaultMaxRetries + 1}, 0, true},
+	}
+	for i, tt := range tests {
+		u := &fakeUploader{failures: tt.failures}
+		n, err := Upload(bytes.NewReader(data[:tt.size]), Options{PartSize: 4, Concurrency: 2}, u)
+		if tt.err {
+			if err == nil {
+				t.Errorf("#%d: expect error", i)
+			}
+			if !u.aborted {
+				t.Errorf("#%d: expect the upload to be aborted", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if n != int64(tt.size) {
+			t.Errorf("#%d: size want = %d, get = %d", i, tt.size, n)
+		}
+		if len(u.parts) != tt.parts {
+			t.Errorf("#%d: parts want = %d, get = %d", i, tt.parts, len(u.parts))
+		}
+		if !bytes.Equal(u.object, data[:tt.size]) {
+			t.Errorf("#%d: object want = %v, get = %v", i, data[:tt.size], u.object)
+		}
+	}
+}
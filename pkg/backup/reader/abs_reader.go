@@ -0,0 +1,61 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+// ensure absReader satisfies reader interface.
+var _ Reader = &absReader{}
+
+// absReader provides Reader imlementation for reading a file from ABS
+type absReader struct {
+	abs *storage.BlobStorageClient
+}
+
+func NewABSReader(abs *storage.BlobStorageClient) Reader {
+	return &absReader{abs}
+}
+
+// Open opens the file on path where path must be in the format "<abs-container-name>/<key>"
+// and verifies it against its manifest while it is read.
+func (ar *absReader) Open(path string) (io.ReadCloser, error) {
+	return manifest.Open(ar.open, path)
+}
+
+func (ar *absReader) open(path string) (io.ReadCloser, error) {
+	container, key, err := util.ParseBucketAndKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse abs container and key: %v", err)
+	}
+	blob := ar.abs.GetContainerReference(container).GetBlobReference(key)
+	rc, err := blob.Get(&storage.GetBlobOptions{})
+	if serr, ok := err.(storage.AzureStorageServiceError); ok && serr.StatusCode == http.StatusNotFound {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
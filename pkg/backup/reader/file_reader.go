@@ -0,0 +1,57 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+)
+
+// ensure fileReader satisfies reader interface.
+var _ Reader = &fileReader{}
+
+// fileReader provides Reader imlementation for reading a file from a directory,
+// e.g. the mount point of a backup volume.
+type fileReader struct {
+	dir string
+}
+
+func NewFileReader(dir string) Reader {
+	return &fileReader{filepath.Clean(dir)}
+}
+
+// Open opens the file on path where path must be within the directory of the reader
+// and verifies it against its manifest while it is read.
+// A relative path is relative to the directory of the reader.
+func (fr *fileReader) Open(path string) (io.ReadCloser, error) {
+	return manifest.Open(fr.open, path)
+}
+
+func (fr *fileReader) open(path string) (io.ReadCloser, error) {
+	p := path
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(fr.dir, p)
+	}
+	p = filepath.Clean(p)
+	if p != fr.dir && !strings.HasPrefix(p, fr.dir+string(filepath.Separator)) {
+		return nil, fmt.Errorf("path (%v) is not within %v", path, fr.dir)
+	}
+	return os.Open(p)
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+)
+
+// ensure gcsReader satisfies reader interface.
+var _ Reader = &gcsReader{}
+
+// gcsReader provides Reader imlementation for reading a file from GCS
+type gcsReader struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewGCSReader returns a Reader of the GCS JSON API at endpoint.
+// The client is expected to authorize its requests.
+func NewGCSReader(endpoint string, client *http.Client) Reader {
+	return &gcsReader{endpoint: endpoint, client: client}
+}
+
+// Open opens the file on path where path must be in the format "<gcs-bucket-name>/<key>"
+// and verifies it against its manifest while it is read.
+func (gr *gcsReader) Open(path string) (io.ReadCloser, error) {
+	return manifest.Open(gr.open, path)
+}
+
+func (gr *gcsReader) open(path string) (io.ReadCloser, error) {
+	bucket, key, err := util.ParseBucketAndKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse gcs bucket and key: %v", err)
+	}
+	u := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", gr.endpoint, url.PathEscape(bucket), url.PathEscape(key))
+	resp, err := gr.client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code (%d): %s", resp.StatusCode, b)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+)
+
+// ensure httpReader satisfies reader interface.
+var _ Reader = &httpReader{}
+
+// HTTPCredentials are the credentials an HTTP reader authenticates its requests with.
+// Token takes precedence over Username and Password.
+type HTTPCredentials struct {
+	// Token is sent as a bearer token.
+	Token string
+	// Username and Password are sent with basic auth.
+	Username string
+	Password string
+}
+
+// httpReader provides Reader imlementation for reading a file from an HTTP server
+type httpReader struct {
+	client *http.Client
+	server string
+	creds  HTTPCredentials
+}
+
+// NewHTTPReader returns a Reader of the files served at server, e.g. "https://backups.example.com".
+func NewHTTPReader(client *http.Client, server string, creds HTTPCredentials) Reader {
+	return &httpReader{client: client, server: server, creds: creds}
+}
+
+// Open opens the file on path where path is the URL path of the file on the server
+// and verifies it against its manifest while it is read.
+func (hr *httpReader) Open(path string) (io.ReadCloser, error) {
+	return manifest.Open(hr.open, path)
+}
+
+func (hr *httpReader) open(path string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", hr.server+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(hr.creds.Token) != 0:
+		req.Header.Set("Authorization", "Bearer "+hr.creds.Token)
+	case len(hr.creds.Username) != 0:
+		req.SetBasicAuth(hr.creds.Username, hr.creds.Password)
+	}
+	resp, err := hr.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	if resp.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code (%d): %s", resp.StatusCode, b)
+	}
+	return resp.Body, nil
+}
@@ -19,5 +19,6 @@ import "io"
 // Reader defines required reader operations
 type Reader interface {
 	// Open opens up a backup file for reading.
+	// Reading fails with a *manifest.MismatchError if the backup does not match its manifest.
 	Open(path string) (rc io.ReadCloser, err error)
 }
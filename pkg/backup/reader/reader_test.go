@@ -0,0 +1,214 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/gophercloud/gophercloud"
+)
+
+const (
+	testBackup    = "v1/default/example/3.1.8_0000000000000001_etcd.backup"
+	testCorrupted = "v1/default/example/3.1.8_0000000000000002_etcd.backup"
+	testMissing   = "v1/default/example/3.1.8_0000000000000003_etcd.backup"
+)
+
+var testData = []byte("snapshot")
+
+// testObjects returns the objects of a store holding a backup,
+// and a backup that does not match its manifest.
+func testObjects() map[string][]byte {
+	objects := make(map[string][]byte)
+	for _, name := range []string{testBackup, testCorrupted} {
+		h := manifest.NewHasher(bytes.NewReader(testData))
+		b, _ := ioutil.ReadAll(h)
+		objects[name] = b
+		objects[manifest.Name(name)], _ = json.Marshal(h.Complete(manifest.Manifest{EtcdVersion: "3.1.8", Revision: 1}))
+	}
+	objects[testCorrupted] = []byte("snapsh0t")
+	return objects
+}
+
+// serveObjects returns a handler serving the objects named by name(r).
+func serveObjects(objects map[string][]byte, name func(r *http.Request) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, ok := objects[name(r)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b)
+	}
+}
+
+// testReader checks that r reads the backup at prefix+testBackup, fails to open a missing backup
+// with an os.IsNotExist error, and fails to read a backup that does not match its manifest.
+func testReader(t *testing.T, r Reader, prefix string) {
+	rc, err := r.Open(prefix + testBackup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, testData) {
+		t.Errorf("backup = %q, want %q", b, testData)
+	}
+
+	if _, err = r.Open(prefix + testMissing); !os.IsNotExist(err) {
+		t.Errorf("open missing backup: expect not exist error, get %v", err)
+	}
+
+	rc, err = r.Open(prefix + testCorrupted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(rc)
+	rc.Close()
+	if _, ok := err.(*manifest.MismatchError); !ok {
+		t.Errorf("read corrupted backup: expect mismatch error, get %v", err)
+	}
+}
+
+// hostTransport sends all the requests to host.
+type hostTransport struct {
+	host string
+}
+
+func (ht *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Host = ht.host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestABSReader(t *testing.T) {
+	objects := testObjects()
+	srv := httptest.NewServer(serveObjects(objects, func(r *http.Request) string {
+		return strings.TrimPrefix(r.URL.Path, "/backups/")
+	}))
+	defer srv.Close()
+
+	cli, err := storage.NewClient("testaccount", base64.StdEncoding.EncodeToString([]byte("key")), "example.com", storage.DefaultAPIVersion, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.HTTPClient = &http.Client{Transport: &hostTransport{strings.TrimPrefix(srv.URL, "http://")}}
+	bs := cli.GetBlobService()
+	testReader(t, NewABSReader(&bs), "backups/")
+}
+
+func TestSwiftReader(t *testing.T) {
+	objects := testObjects()
+	srv := httptest.NewServer(serveObjects(objects, func(r *http.Request) string {
+		return strings.TrimPrefix(r.URL.Path, "/backups/")
+	}))
+	defer srv.Close()
+
+	cli := &gophercloud.ServiceClient{
+		ProviderClient: &gophercloud.ProviderClient{},
+		Endpoint:       srv.URL + "/",
+	}
+	testReader(t, NewSwiftReader(cli), "backups/")
+}
+
+func TestGCSReader(t *testing.T) {
+	objects := testObjects()
+	srv := httptest.NewServer(serveObjects(objects, func(r *http.Request) string {
+		if r.URL.Query().Get("alt") != "media" {
+			return ""
+		}
+		return strings.TrimPrefix(r.URL.Path, "/storage/v1/b/backups/o/")
+	}))
+	defer srv.Close()
+
+	testReader(t, NewGCSReader(srv.URL, srv.Client()), "backups/")
+}
+
+func TestFileReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-backup-reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, b := range testObjects() {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewFileReader(dir)
+	testReader(t, r, dir+"/")
+	testReader(t, r, "")
+	if _, err := r.Open(filepath.Join(dir, "..", filepath.Base(dir), "..", "etc", "passwd")); err == nil || os.IsNotExist(err) {
+		t.Errorf("open file outside of the reader directory: expect error, get %v", err)
+	}
+}
+
+func TestHTTPReader(t *testing.T) {
+	objects := testObjects()
+	tests := []struct {
+		creds HTTPCredentials
+		auth  func(r *http.Request) bool
+	}{{
+		creds: HTTPCredentials{},
+		auth:  func(r *http.Request) bool { return len(r.Header.Get("Authorization")) == 0 },
+	}, {
+		creds: HTTPCredentials{Token: "secret"},
+		auth:  func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer secret" },
+	}, {
+		creds: HTTPCredentials{Username: "etcd", Password: "secret"},
+		auth: func(r *http.Request) bool {
+			u, p, ok := r.BasicAuth()
+			return ok && u == "etcd" && p == "secret"
+		},
+	}}
+	for i, tt := range tests {
+		handler := serveObjects(objects, func(r *http.Request) string {
+			return strings.TrimPrefix(r.URL.Path, "/backups/")
+		})
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !tt.auth(r) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			handler(w, r)
+		}))
+
+		r := NewHTTPReader(srv.Client(), srv.URL, tt.creds)
+		testReader(t, r, "/backups/")
+		if _, err := NewHTTPReader(srv.Client(), srv.URL, HTTPCredentials{Token: "wrong"}).Open("/backups/" + testBackup); err == nil {
+			t.Errorf("#%d: open with wrong credentials: expect error", i)
+		}
+		srv.Close()
+	}
+}
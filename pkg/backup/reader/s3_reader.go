@@ -17,10 +17,13 @@ package reader
 import (
 	"fmt"
 	"io"
+	"os"
 
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
@@ -37,7 +40,12 @@ func NewS3Reader(s3 *s3.S3) Reader {
 }
 
 // Open opens the file on path where path must be in the format "<s3-bucket-name>/<key>"
+// and verifies it against its manifest while it is read.
 func (s3r *s3Reader) Open(path string) (io.ReadCloser, error) {
+	return manifest.Open(s3r.open, path)
+}
+
+func (s3r *s3Reader) open(path string) (io.ReadCloser, error) {
 	bucket, key, err := util.ParseBucketAndKey(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse s3 bucket and key: %v", err)
@@ -46,6 +54,9 @@ func (s3r *s3Reader) Open(path string) (io.ReadCloser, error) {
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,60 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/objectstorage/v1/objects"
+)
+
+// ensure swiftReader satisfies reader interface.
+var _ Reader = &swiftReader{}
+
+// swiftReader provides Reader imlementation for reading a file from Swift
+type swiftReader struct {
+	swift *gophercloud.ServiceClient
+}
+
+func NewSwiftReader(swift *gophercloud.ServiceClient) Reader {
+	return &swiftReader{swift}
+}
+
+// Open opens the file on path where path must be in the format "<swift-container-name>/<key>"
+// and verifies it against its manifest while it is read.
+func (sr *swiftReader) Open(path string) (io.ReadCloser, error) {
+	return manifest.Open(sr.open, path)
+}
+
+func (sr *swiftReader) open(path string) (io.ReadCloser, error) {
+	container, key, err := util.ParseBucketAndKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse swift container and key: %v", err)
+	}
+	resp := objects.Download(sr.swift, container, key, nil)
+	if _, ok := resp.Err.(gophercloud.ErrDefault404); ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+	return resp.Body, nil
+}
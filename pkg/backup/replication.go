@@ -0,0 +1,393 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/abs"
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/gcs"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/multipart"
+	"github.com/coreos/etcd-operator/pkg/backup/s3"
+	"github.com/coreos/etcd-operator/pkg/backup/swift"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/cluster/backupstorage"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// replicationInterval is the interval between two replications, besides the one following every backup.
+const replicationInterval = time.Minute
+
+// newDestinationBackend returns the backend of a secondary destination of the backups.
+// Unlike for the primary storage, the credentials are read from the secret of the destination.
+func newDestinationBackend(kubecli kubernetes.Interface, ns, clusterName string, d api.BackupDestination, uo multipart.Options) (backend.Backend, error) {
+	switch d.StorageType {
+	case api.BackupStorageTypeS3:
+		cli, err := s3factory.NewClientFromSecret(kubecli, ns, d.S3.AWSSecret, d.S3.S3Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		prefix := backupapi.ToS3Prefix(d.S3.Prefix, ns, clusterName)
+		s3cli := s3.NewFromClient(d.S3.S3Bucket, prefix, cli.S3)
+		s3cli.SetUploadOptions(uo)
+		return backend.NewS3Backend(s3cli), nil
+	case api.BackupStorageTypeABS:
+		account, key, err := backupstorage.GetABSCreds(kubecli, ns, d.ABS.ABSSecret)
+		if err != nil {
+			return nil, err
+		}
+		cli, err := abs.New(d.ABS.ABSContainer, account, key, path.Join(ns, clusterName))
+		if err != nil {
+			return nil, err
+		}
+		cli.SetUploadOptions(uo)
+		return backend.NewAbsBackend(cli), nil
+	case api.BackupStorageTypeSwift:
+		ao, err := backupstorage.GetSwiftCreds(kubecli, ns, d.Swift.SwiftSecret)
+		if err != nil {
+			return nil, err
+		}
+		cli, err := swift.NewFromAuthOpt(d.Swift.SwiftContainer, d.Swift.SwiftRegion, path.Join(ns, clusterName), ao)
+		if err != nil {
+			return nil, err
+		}
+		cli.SetUploadOptions(uo)
+		return backend.NewSwiftBackend(cli), nil
+	case api.BackupStorageTypeGCS:
+		saJSON, err := backupstorage.GetGCSCreds(kubecli, ns, d.GCS.GCSSecret)
+		if err != nil {
+			return nil, err
+		}
+		cli, err := gcs.New(d.GCS.GCSBucket, path.Join(d.GCS.Prefix, ns, clusterName), saJSON)
+		if err != nil {
+			return nil, err
+		}
+		return backend.NewGCSBackend(cli), nil
+	}
+	return nil, fmt.Errorf("unsupported destination storage type: %v", d.StorageType)
+}
+
+// destination is a secondary storage the backups are replicated to.
+type destination struct {
+	name string
+	be   backend.Backend
+	// retention is nil if the destination keeps all backups.
+	retention *api.BackupRetentionPolicy
+
+	mu     sync.Mutex
+	status backupapi.DestinationStatus
+}
+
+// replicator saves the backups, delta chunks and chains to the primary storage and the destinations at once.
+// It copies the latest backup of the primary storage and its delta chain to the destinations
+// missing them, and purges the backups of the destinations.
+type replicator struct {
+	primary      backend.Backend
+	destinations []*destination
+	// trigger wakes the replicator up after a backup.
+	trigger chan struct{}
+}
+
+func newReplicator(primary backend.Backend) *replicator {
+	return &replicator{
+		primary: primary,
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+func (rp *replicator) addDestination(name string, be backend.Backend, retention *api.BackupRetentionPolicy) {
+	rp.destinations = append(rp.destinations, &destination{
+		name:      name,
+		be:        be,
+		retention: retention,
+		status:    backupapi.DestinationStatus{Name: name},
+	})
+}
+
+// run replicates the backups after every backup and at every replication interval,
+// so that the destinations that were unreachable catch up.
+func (rp *replicator) run() {
+	for {
+		select {
+		case <-time.After(replicationInterval):
+		case <-rp.trigger:
+		}
+		rp.replicate()
+	}
+}
+
+// notify wakes the replicator up without waiting for the replication.
+func (rp *replicator) notify() {
+	select {
+	case rp.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (rp *replicator) replicate() {
+	latest, err := rp.primary.GetLatest()
+	if err != nil {
+		logrus.Errorf("failed to get the latest backup to replicate: %v", err)
+		return
+	}
+	if len(latest) == 0 {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, d := range rp.destinations {
+		wg.Add(1)
+		go func(d *destination) {
+			defer wg.Done()
+			d.replicate(rp.primary, latest)
+		}(d)
+	}
+	wg.Wait()
+}
+
+// save saves the object read from r under the given name with its manifest m to the primary storage
+// and to every destination at once, so that the destinations get it even if the primary storage is unreachable.
+// A storage failing does not stop the others. The outcome of every destination is recorded in its status.
+// It returns the size of the object saved to the primary storage.
+func (rp *replicator) save(name string, r io.Reader, m manifest.Manifest) (int64, error) {
+	bes := append([]backend.Backend{rp.primary}, rp.backends()...)
+	ns := make([]int64, len(bes))
+	errs := make([]error, len(bes))
+	pws := make([]*io.PipeWriter, len(bes))
+	var wg sync.WaitGroup
+	for i, be := range bes {
+		pr, pw := io.Pipe()
+		pws[i] = pw
+		wg.Add(1)
+		go func(i int, be backend.Backend) {
+			defer wg.Done()
+			ns[i], errs[i] = be.Save(name, pr, m)
+			// Fail the writes to a storage that stopped reading.
+			pr.CloseWithError(errStorageDone)
+		}(i, be)
+	}
+	f := &fanout{ws: make([]io.Writer, len(pws))}
+	for i, pw := range pws {
+		f.ws[i] = pw
+	}
+	_, err := io.Copy(f, r)
+	for _, pw := range pws {
+		pw.CloseWithError(err)
+	}
+	wg.Wait()
+
+	for i, d := range rp.destinations {
+		d.saved(name, m, ns[i+1], errs[i+1])
+	}
+	if errs[0] != nil {
+		return 0, errs[0]
+	}
+	return ns[0], err
+}
+
+var errStorageDone = errors.New("storage stopped reading")
+
+// fanout writes to every writer that has not failed yet. It fails once they all have.
+type fanout struct {
+	ws []io.Writer
+}
+
+func (f *fanout) Write(p []byte) (int, error) {
+	ok := false
+	for i, w := range f.ws {
+		if w == nil {
+			continue
+		}
+		if _, err := w.Write(p); err != nil {
+			f.ws[i] = nil
+			continue
+		}
+		ok = true
+	}
+	if !ok {
+		return 0, errors.New("every storage failed")
+	}
+	return len(p), nil
+}
+
+// status returns the statuses of the destinations.
+func (rp *replicator) status() []backupapi.DestinationStatus {
+	var ss []backupapi.DestinationStatus
+	for _, d := range rp.destinations {
+		d.mu.Lock()
+		ss = append(ss, d.status)
+		d.mu.Unlock()
+	}
+	return ss
+}
+
+// backends returns the backends of the destinations.
+func (rp *replicator) backends() []backend.Backend {
+	var bes []backend.Backend
+	for _, d := range rp.destinations {
+		bes = append(bes, d.be)
+	}
+	return bes
+}
+
+// saved records the outcome of saving the object of the given name to the destination as it was taken.
+func (d *destination) saved(name string, m manifest.Manifest, size int64, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		logrus.Errorf("failed to save %s to destination %s: %v", name, d.name, err)
+		d.status.LastReplicationError = err.Error()
+		return
+	}
+	if util.IsBackup(name) {
+		d.status.RecentBackup = &backupapi.BackupStatus{
+			CreationTime: m.CreationTime,
+			Size:         util.ToMB(size),
+			Revision:     m.Revision,
+			Version:      m.EtcdVersion,
+		}
+		d.status.LagInRevisions = 0
+	}
+	d.status.LastReplicationTime = time.Now().Format(time.RFC3339)
+	d.status.LastReplicationError = ""
+}
+
+// replicate copies the backup latest of the primary storage to the destination if it does not have it,
+// purges the backups of the destination and updates its status.
+func (d *destination) replicate(primary backend.Backend, latest string) {
+	rb, total, err := d.sync(primary, latest)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if rb != nil {
+		d.status.RecentBackup = rb
+		d.status.Backups = total
+	}
+	d.status.LagInRevisions = util.MustParseRevision(latest)
+	if d.status.RecentBackup != nil {
+		d.status.LagInRevisions -= d.status.RecentBackup.Revision
+	}
+	if err != nil {
+		logrus.Errorf("failed to replicate backup %s to destination %s: %v", latest, d.name, err)
+		d.status.LastReplicationError = err.Error()
+		return
+	}
+	d.status.LastReplicationTime = time.Now().Format(time.RFC3339)
+	d.status.LastReplicationError = ""
+}
+
+// sync makes the destination have the backup latest of the primary storage, or a newer one.
+// It returns the status of the most recent backup of the destination and its total number of backups.
+func (d *destination) sync(primary backend.Backend, latest string) (*backupapi.BackupStatus, int, error) {
+	name, err := d.be.GetLatest()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(name) == 0 || util.MustParseRevision(name) < util.MustParseRevision(latest) {
+		if err := copyBackup(primary, d.be, latest); err != nil {
+			return nil, 0, err
+		}
+		logrus.Infof("replicated backup %s to destination %s", latest, d.name)
+		name = latest
+	}
+	if name == latest {
+		if err := copyChain(primary, d.be, latest); err != nil {
+			return nil, 0, fmt.Errorf("failed to replicate delta chain of backup %s: %v", latest, err)
+		}
+	}
+	if d.retention != nil {
+		if err := d.be.Purge(*d.retention); err != nil {
+			return nil, 0, fmt.Errorf("failed to purge backups: %v", err)
+		}
+	}
+
+	m, err := d.be.Manifest(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := d.be.Total()
+	if err != nil {
+		return nil, 0, err
+	}
+	return &backupapi.BackupStatus{
+		CreationTime: m.CreationTime,
+		Size:         util.ToMB(m.Size),
+		Revision:     m.Revision,
+		Version:      m.EtcdVersion,
+	}, total, nil
+}
+
+// copyChain copies the delta chain of the given backup from src to dst, with the delta chunks dst is missing.
+func copyChain(src, dst backend.Backend, backup string) error {
+	cname, err := src.GetChain(backup)
+	if err != nil || len(cname) == 0 {
+		return err
+	}
+	c, err := loadChain(src, cname)
+	if err != nil {
+		return err
+	}
+	end := c.Revision
+	dname, err := dst.GetChain(backup)
+	if err != nil {
+		return err
+	}
+	if len(dname) != 0 {
+		dc, err := loadChain(dst, dname)
+		if err != nil {
+			logrus.Warningf("copying the whole delta chain of backup %s: %v", backup, err)
+		} else {
+			end = dc.EndRevision()
+		}
+	}
+	if c.EndRevision() <= end {
+		return nil
+	}
+	for _, l := range c.Deltas {
+		if l.EndRevision <= end {
+			continue
+		}
+		if err := copyBackup(src, dst, l.Name); err != nil {
+			return err
+		}
+	}
+	return copyBackup(src, dst, cname)
+}
+
+// copyBackup copies the backup, delta chunk or chain of the given name and its manifest from src to dst.
+func copyBackup(src, dst backend.Backend, name string) error {
+	m, err := src.Manifest(name)
+	if err != nil {
+		return err
+	}
+	rc, err := src.Open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = dst.Save(name, rc, *m)
+	return err
+}
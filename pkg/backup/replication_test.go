@@ -0,0 +1,215 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+)
+
+func TestReplicate(t *testing.T) {
+	src, err := ioutil.TempDir("", "backupdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "backupdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	for _, d := range []string{src, dst} {
+		// file backend writes backups to "backupdir/tmp" before moving them.
+		if err := os.MkdirAll(filepath.Join(d, util.BackupTmpDir), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	primary := backend.NewFileBackend(src)
+	rp := newReplicator(primary)
+	rp.addDestination("dr", backend.NewFileBackend(dst), &api.BackupRetentionPolicy{Last: 2})
+
+	tests := []struct {
+		rev     int64
+		backups int
+	}{
+		{1, 1},
+		{2, 2},
+		// the destination keeps the last 2 backups.
+		{3, 2},
+	}
+	for i, tt := range tests {
+		m := manifest.Manifest{EtcdVersion: "3.1.0", Revision: tt.rev, CreationTime: time.Now().Format(time.RFC3339)}
+		if _, err := primary.Save(util.MakeBackupName("3.1.0", tt.rev), strings.NewReader("snapshot"), m); err != nil {
+			t.Fatal(err)
+		}
+		rp.replicate()
+
+		s := rp.status()[0]
+		if len(s.LastReplicationError) != 0 {
+			t.Fatalf("#%d: replication error: %s", i, s.LastReplicationError)
+		}
+		if s.RecentBackup == nil || s.RecentBackup.Revision != tt.rev {
+			t.Errorf("#%d: recent backup want revision %d, get %+v", i, tt.rev, s.RecentBackup)
+		}
+		if s.LagInRevisions != 0 {
+			t.Errorf("#%d: lag want = 0, get = %d", i, s.LagInRevisions)
+		}
+		if s.Backups != tt.backups {
+			t.Errorf("#%d: backups want = %d, get = %d", i, tt.backups, s.Backups)
+		}
+	}
+
+	// The replicated backup must be readable, that is match its manifest.
+	name := util.MakeBackupName("3.1.0", 3)
+	rc, err := backend.NewFileBackend(dst).Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "snapshot" {
+		t.Errorf("replicated backup want = %q, get = %q", "snapshot", b)
+	}
+	if _, err := os.Stat(filepath.Join(dst, manifest.Name(util.MakeBackupName("3.1.0", 1)))); !os.IsNotExist(err) {
+		t.Errorf("expect the oldest backup to be purged from the destination, got %v", err)
+	}
+}
+
+func TestReplicatorSaveWithPrimaryDown(t *testing.T) {
+	dst, err := ioutil.TempDir("", "backupdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	if err := os.MkdirAll(filepath.Join(dst, util.BackupTmpDir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// the primary storage is unreachable.
+	rp := newReplicator(backend.NewFileBackend(filepath.Join(dst, "missing")))
+	rp.addDestination("dr", backend.NewFileBackend(dst), nil)
+
+	name := util.MakeBackupName("3.1.0", 1)
+	m := manifest.Manifest{EtcdVersion: "3.1.0", Revision: 1, CreationTime: time.Now().Format(time.RFC3339)}
+	if _, err := rp.save(name, strings.NewReader("snapshot"), m); err == nil {
+		t.Error("expect error saving to the unreachable primary storage")
+	}
+
+	s := rp.status()[0]
+	if len(s.LastReplicationError) != 0 {
+		t.Errorf("replication error: %s", s.LastReplicationError)
+	}
+	if s.RecentBackup == nil || s.RecentBackup.Revision != 1 {
+		t.Errorf("recent backup want revision 1, get %+v", s.RecentBackup)
+	}
+	rc, err := backend.NewFileBackend(dst).Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(b) != "snapshot" {
+		t.Errorf("saved backup = %q (%v), want %q", b, err, "snapshot")
+	}
+}
+
+func TestReplicateChain(t *testing.T) {
+	src, err := ioutil.TempDir("", "backupdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "backupdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	for _, d := range []string{src, dst} {
+		if err := os.MkdirAll(filepath.Join(d, util.BackupTmpDir), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	primary := backend.NewFileBackend(src)
+	backup := util.MakeBackupName("3.1.0", 1)
+	dname := util.MakeDeltaName("3.1.0", 1, 5)
+	c := &delta.Chain{
+		Backup:   backup,
+		Revision: 1,
+		Deltas:   []delta.Link{{Name: dname, StartRevision: 2, EndRevision: 5}},
+	}
+	cb, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := []struct {
+		name string
+		data string
+	}{
+		{backup, "snapshot"},
+		{dname, "delta"},
+		{util.ChainName(backup), string(cb)},
+	}
+	for _, o := range objs {
+		if _, err := primary.Save(o.name, strings.NewReader(o.data), manifest.Manifest{EtcdVersion: "3.1.0", Revision: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rp := newReplicator(primary)
+	rp.addDestination("dr", backend.NewFileBackend(dst), nil)
+	rp.replicate()
+	if s := rp.status()[0]; len(s.LastReplicationError) != 0 {
+		t.Fatalf("replication error: %s", s.LastReplicationError)
+	}
+
+	dbe := backend.NewFileBackend(dst)
+	cname, err := dbe.GetChain(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadChain(dbe, cname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("replicated chain = %+v, want %+v", got, c)
+	}
+	rc, err := dbe.Open(dname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(b) != "delta" {
+		t.Errorf("replicated delta = %q (%v), want %q", b, err, "delta")
+	}
+}
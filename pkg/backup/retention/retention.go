@@ -0,0 +1,123 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retention decides which backups a generational retention policy keeps.
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+)
+
+// Backup is the metadata of a backup the retention policy is evaluated over.
+type Backup struct {
+	Name     string
+	Revision int64
+	// CreationTime is the time the backup was taken.
+	// If it is zero, the creation time is unknown, and a generational policy keeps the backup
+	// rather than purge it by age.
+	CreationTime time.Time
+}
+
+// Decision tells whether a backup is kept and why.
+type Decision struct {
+	Name   string
+	Keep   bool
+	Reason string
+}
+
+type generation struct {
+	unit   string
+	count  int
+	period func(t time.Time) string
+}
+
+func generations(p api.BackupRetentionPolicy) []generation {
+	return []generation{
+		{unit: "hour", count: p.Hourly, period: func(t time.Time) string { return t.Format("2006-01-02T15") }},
+		{unit: "day", count: p.Daily, period: func(t time.Time) string { return t.Format("2006-01-02") }},
+		{unit: "week", count: p.Weekly, period: func(t time.Time) string {
+			y, w := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", y, w)
+		}},
+		{unit: "month", count: p.Monthly, period: func(t time.Time) string { return t.Format("2006-01") }},
+	}
+}
+
+// Evaluate returns the decision of the policy p for every backup, newest first.
+// A backup is kept if it is one of the p.Last newest backups,
+// or the newest backup of one of the most recent hours, days, weeks or months kept by p.
+func Evaluate(p api.BackupRetentionPolicy, backups []Backup) []Decision {
+	bs := append([]Backup(nil), backups...)
+	sort.Slice(bs, func(i, j int) bool { return bs[i].Revision > bs[j].Revision })
+
+	keeps := make([][]string, len(bs))
+	purges := make([][]string, len(bs))
+	if p.Last > 0 {
+		for i := range bs {
+			if i < p.Last {
+				keeps[i] = append(keeps[i], fmt.Sprintf("one of the %d newest backups", p.Last))
+			} else {
+				purges[i] = append(purges[i], fmt.Sprintf("not one of the %d newest backups", p.Last))
+			}
+		}
+	}
+	generational := false
+	for _, g := range generations(p) {
+		if g.count <= 0 {
+			continue
+		}
+		generational = true
+		// kept maps the periods kept so far to the backup kept for them.
+		kept := make(map[string]string, g.count)
+		for i, b := range bs {
+			if b.CreationTime.IsZero() {
+				continue
+			}
+			per := g.period(b.CreationTime.UTC())
+			switch newer, ok := kept[per]; {
+			case ok:
+				purges[i] = append(purges[i], fmt.Sprintf("%s %s is kept by the newer backup %s", g.unit, per, newer))
+			case len(kept) < g.count:
+				kept[per] = b.Name
+				keeps[i] = append(keeps[i], fmt.Sprintf("newest backup of %s %s", g.unit, per))
+			default:
+				purges[i] = append(purges[i], fmt.Sprintf("%s %s is older than the %d %ss kept", g.unit, per, g.count, g.unit))
+			}
+		}
+	}
+
+	if generational {
+		for i, b := range bs {
+			if b.CreationTime.IsZero() {
+				keeps[i] = append(keeps[i], "unknown creation time")
+			}
+		}
+	}
+
+	ds := make([]Decision, len(bs))
+	for i, b := range bs {
+		ds[i] = Decision{Name: b.Name, Keep: len(keeps[i]) != 0}
+		if ds[i].Keep {
+			ds[i].Reason = strings.Join(keeps[i], "; ")
+		} else {
+			ds[i].Reason = strings.Join(purges[i], "; ")
+		}
+	}
+	return ds
+}
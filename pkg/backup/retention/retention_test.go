@@ -0,0 +1,87 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+)
+
+func TestEvaluate(t *testing.T) {
+	at := func(month, day, hour int) time.Time {
+		return time.Date(2017, time.Month(month), day, hour, 0, 0, 0, time.UTC)
+	}
+	// Nov 6 2017 is a Monday, starting ISO week 45.
+	backups := []Backup{
+		{Name: "a", Revision: 1, CreationTime: at(10, 31, 23)},
+		{Name: "b", Revision: 2, CreationTime: at(11, 5, 10)},
+		{Name: "c", Revision: 3, CreationTime: at(11, 6, 8)},
+		{Name: "d", Revision: 4, CreationTime: at(11, 6, 9)},
+		{Name: "e", Revision: 5, CreationTime: at(11, 6, 9)},
+		{Name: "f", Revision: 6},
+	}
+	tests := []struct {
+		policy api.BackupRetentionPolicy
+		kept   []string
+	}{
+		{api.BackupRetentionPolicy{Last: 2}, []string{"f", "e"}},
+		// backups with an unknown creation time are not purged by age
+		{api.BackupRetentionPolicy{Hourly: 2}, []string{"f", "e", "c"}},
+		{api.BackupRetentionPolicy{Daily: 10}, []string{"f", "e", "b", "a"}},
+		{api.BackupRetentionPolicy{Weekly: 2}, []string{"f", "e", "b"}},
+		{api.BackupRetentionPolicy{Monthly: 2}, []string{"f", "e", "a"}},
+		{api.BackupRetentionPolicy{Last: 1, Hourly: 1, Monthly: 2}, []string{"f", "e", "a"}},
+	}
+	for i, tt := range tests {
+		ds := Evaluate(tt.policy, backups)
+		if len(ds) != len(backups) {
+			t.Fatalf("#%d: got %d decisions, want %d", i, len(ds), len(backups))
+		}
+		var kept []string
+		for _, d := range ds {
+			if len(d.Reason) == 0 {
+				t.Errorf("#%d: decision for %s has no reason", i, d.Name)
+			}
+			if d.Keep {
+				kept = append(kept, d.Name)
+			}
+		}
+		if !reflect.DeepEqual(kept, tt.kept) {
+			t.Errorf("#%d: kept %v, want %v", i, kept, tt.kept)
+		}
+	}
+}
+
+func TestEvaluateReasons(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2017, 11, 6, hour, 0, 0, 0, time.UTC)
+	}
+	backups := []Backup{
+		{Name: "a", Revision: 1, CreationTime: at(8)},
+		{Name: "b", Revision: 2, CreationTime: at(9)},
+		{Name: "c", Revision: 3, CreationTime: at(9)},
+	}
+	want := []Decision{
+		{Name: "c", Keep: true, Reason: "newest backup of hour 2017-11-06T09"},
+		{Name: "b", Reason: "hour 2017-11-06T09 is kept by the newer backup c"},
+		{Name: "a", Reason: "hour 2017-11-06T08 is older than the 1 hours kept"},
+	}
+	if got := Evaluate(api.BackupRetentionPolicy{Hourly: 1}, backups); !reflect.DeepEqual(got, want) {
+		t.Errorf("decisions = %+v, want %+v", got, want)
+	}
+}
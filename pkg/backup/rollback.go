@@ -0,0 +1,103 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+
+	"github.com/sirupsen/logrus"
+)
+
+// retireAfter retires the backups of be taken after the revision rev the cluster was rolled back to,
+// so that they are not mistaken for backups of the rolled back cluster:
+// each is kept under its retired name, without its delta chain, and deleted.
+// The delta chain of the newest backup left is cut at rev.
+func retireAfter(be backend.Backend, rev int64) error {
+	names, err := be.List()
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %v", err)
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if util.MustParseRevision(name) <= rev {
+			return cutChain(be, name, rev)
+		}
+		if err := retire(be, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retire keeps the backup under its retired name and deletes it.
+func retire(be backend.Backend, name string) error {
+	m, err := be.Manifest(name)
+	if os.IsNotExist(err) {
+		m = &manifest.Manifest{Revision: util.MustParseRevision(name)}
+	} else if err != nil {
+		return fmt.Errorf("failed to retire backup %s: %v", name, err)
+	}
+	rc, err := be.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to retire backup %s: %v", name, err)
+	}
+	defer rc.Close()
+	rname := util.MakeRetiredName(name)
+	if _, err := be.Save(rname, rc, *m); err != nil {
+		return fmt.Errorf("failed to retire backup %s: %v", name, err)
+	}
+	if err := be.Delete(name); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete retired backup %s: %v", name, err)
+	}
+	logrus.Infof("retired backup %s as %s", name, rname)
+	return nil
+}
+
+// cutChain drops the deltas ending after rev from the delta chain of the given backup.
+func cutChain(be backend.Backend, backup string, rev int64) error {
+	cname, err := be.GetChain(backup)
+	if err != nil || len(cname) == 0 {
+		return err
+	}
+	c, err := loadChain(be, cname)
+	if err != nil {
+		return err
+	}
+	if c.EndRevision() <= rev {
+		return nil
+	}
+	m, err := be.Manifest(cname)
+	if err != nil {
+		return fmt.Errorf("failed to get manifest of delta chain %s: %v", cname, err)
+	}
+	c.Deltas = c.Upto(rev)
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	m.Revision = c.EndRevision()
+	if _, err := be.Save(cname, bytes.NewReader(b), *m); err != nil {
+		return fmt.Errorf("failed to cut delta chain %s: %v", cname, err)
+	}
+	logrus.Infof("cut delta chain %s at revision %d", cname, c.EndRevision())
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+)
+
+func TestRetireAfter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backupdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, util.BackupTmpDir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	be := backend.NewFileBackend(dir)
+
+	for _, rev := range []int64{10, 40, 50} {
+		name := util.MakeBackupName("3.1.0", rev)
+		if _, err := be.Save(name, strings.NewReader(name), manifest.Manifest{EtcdVersion: "3.1.0", Revision: rev}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backup := util.MakeBackupName("3.1.0", 10)
+	c := &delta.Chain{
+		Backup:   backup,
+		Revision: 10,
+		Deltas: []delta.Link{
+			{Name: util.MakeDeltaName("3.1.0", 10, 20), StartRevision: 11, EndRevision: 20},
+			{Name: util.MakeDeltaName("3.1.0", 10, 30), StartRevision: 21, EndRevision: 30},
+		},
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := be.Save(util.ChainName(backup), bytes.NewReader(b), manifest.Manifest{EtcdVersion: "3.1.0", Revision: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cluster was rolled back to revision 25.
+	if err := retireAfter(be, 25); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := be.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{backup}) {
+		t.Errorf("backups = %v, want %v", names, []string{backup})
+	}
+	for _, rev := range []int64{40, 50} {
+		name := util.MakeBackupName("3.1.0", rev)
+		rc, err := be.Open(util.MakeRetiredName(name))
+		if err != nil {
+			t.Fatalf("retired backup %s: %v", name, err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil || string(b) != name {
+			t.Errorf("retired backup %s = %q (%v), want %q", name, b, err, name)
+		}
+	}
+	cut, err := loadChain(be, util.ChainName(backup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cut.Deltas, c.Deltas[:1]) {
+		t.Errorf("deltas = %+v, want %+v", cut.Deltas, c.Deltas[:1])
+	}
+}
@@ -17,11 +17,18 @@ package s3
 import (
 	"fmt"
 	"io"
+	"os"
 	"path"
 
+	"github.com/coreos/etcd-operator/pkg/backup/multipart"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
 // S3 is a helper layer to wrap complex S3 logic.
@@ -29,6 +36,7 @@ type S3 struct {
 	bucket string
 	prefix string
 	client *s3.S3
+	upload multipart.Options
 }
 
 // New returns a S3 translator from default shared config.
@@ -60,21 +68,45 @@ func NewFromClient(bucket, prefix string, cli *s3.S3) *S3 {
 	}
 }
 
-func (s *S3) Put(key string, rs io.ReadSeeker) error {
-	_, err := s.client.PutObject(&s3.PutObjectInput{
+// SetUploadOptions sets the part size, concurrency and retries of the uploads of Put.
+func (s *S3) SetUploadOptions(o multipart.Options) {
+	s.upload = o
+}
+
+// Put uploads the content of r at key. The content is uploaded in parts as it is read,
+// so that it is never held whole in memory or on disk.
+func (s *S3) Put(key string, r io.Reader) error {
+	o := s.upload.WithDefaults()
+	uploader := s3manager.NewUploaderWithClient(s.client, func(u *s3manager.Uploader) {
+		u.PartSize = int64(o.PartSize)
+		u.Concurrency = o.Concurrency
+		// Every request, including the upload of every part, is retried on its own.
+		u.RequestOptions = append(u.RequestOptions, func(r *request.Request) {
+			r.Retryer = client.DefaultRetryer{NumMaxRetries: o.MaxRetries}
+		})
+	})
+	_, err := uploader.Upload(&s3manager.UploadInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(path.Join(s.prefix, key)),
-		Body:   rs,
+		Body:   r,
 	})
-
 	return err
 }
 
+// Path returns the "<bucket>/<key>" path of the object stored at key.
+func (s *S3) Path(key string) string {
+	return path.Join(s.bucket, s.prefix, key)
+}
+
+// Get opens the object at key. It fails with an error satisfying os.IsNotExist if there is none.
 func (s *S3) Get(key string) (io.ReadCloser, error) {
 	resp, err := s.client.GetObject(&s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(path.Join(s.prefix, key)),
 	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, &os.PathError{Op: "get", Path: key, Err: os.ErrNotExist}
+	}
 	if err != nil {
 		return nil, err
 	}
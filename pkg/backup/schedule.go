@@ -0,0 +1,124 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/cronutil"
+)
+
+type schedule interface {
+	// Next returns the first activation time strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// intervalSchedule activates at the multiples of the interval since the zero time,
+// so that backup times do not drift with the time backups take.
+type intervalSchedule time.Duration
+
+func (d intervalSchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(d)).Add(time.Duration(d))
+}
+
+// backupScheduler tells when the next scheduled backup is due.
+// The activation times of the schedule are delayed by a jitter fixed per cluster.
+type backupScheduler struct {
+	schedule schedule
+	jitter   time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newBackupScheduler returns the scheduler of the backup policy.
+// The jitter is derived from key, which identifies the cluster.
+func newBackupScheduler(bp *api.BackupPolicy, key string) (*backupScheduler, error) {
+	s := &backupScheduler{}
+	maxJitter := time.Duration(bp.JitterInSecond) * time.Second
+	if len(bp.Schedule) != 0 {
+		cs, err := cronutil.Parse(bp.Schedule)
+		if err != nil {
+			return nil, err
+		}
+		if cs.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("schedule %q never activates", bp.Schedule)
+		}
+		s.schedule = cs
+	} else {
+		interval := constants.DefaultSnapshotInterval
+		if bp.BackupIntervalInSecond != 0 {
+			interval = time.Duration(bp.BackupIntervalInSecond) * time.Second
+		}
+		s.schedule = intervalSchedule(interval)
+		if maxJitter == 0 {
+			maxJitter = interval
+		}
+	}
+	s.jitter = clusterJitter(key, maxJitter)
+	return s, nil
+}
+
+// clusterJitter returns a delay in [0, max) derived from key.
+func clusterJitter(key string, max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return time.Duration(h.Sum64() % uint64(max))
+}
+
+// after returns the first scheduled time strictly after t.
+func (s *backupScheduler) after(t time.Time) time.Time {
+	return s.schedule.Next(t.Add(-s.jitter)).Add(s.jitter)
+}
+
+// start sets the first scheduled backup time. last is the creation time of the latest backup,
+// or the zero time if there is none. If a scheduled backup was missed since the latest backup,
+// the backup is due right away, once.
+// It returns true if the backup is overdue.
+func (s *backupScheduler) start(last, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !last.IsZero() && !s.after(last).After(now) {
+		s.next = now
+		return true
+	}
+	s.next = s.after(now)
+	return false
+}
+
+// advance sets the next scheduled backup time to the first one after now,
+// skipping those missed while backing up.
+func (s *backupScheduler) advance(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.next.After(now) {
+		s.next = s.after(s.next)
+	}
+}
+
+// nextTime returns the next scheduled backup time.
+func (s *backupScheduler) nextTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
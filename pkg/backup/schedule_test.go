@@ -0,0 +1,83 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+)
+
+func TestBackupSchedulerJitter(t *testing.T) {
+	bp := &api.BackupPolicy{BackupIntervalInSecond: 3600}
+	s1, err := newBackupScheduler(bp, "uid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := newBackupScheduler(bp, "uid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s1.jitter != s2.jitter {
+		t.Errorf("jitter of the same cluster differs: %v, %v", s1.jitter, s2.jitter)
+	}
+	if s1.jitter < 0 || s1.jitter >= time.Hour {
+		t.Errorf("jitter %v out of [0, 1h)", s1.jitter)
+	}
+
+	bp = &api.BackupPolicy{Schedule: "0 * * * *"}
+	s, err := newBackupScheduler(bp, "uid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.jitter != 0 {
+		t.Errorf("jitter of a schedule without jitter = %v, want 0", s.jitter)
+	}
+}
+
+func TestBackupSchedulerStart(t *testing.T) {
+	now := time.Date(2017, 11, 6, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		last    time.Time
+		overdue bool
+		next    time.Time
+	}{
+		// no backup yet
+		{time.Time{}, false, time.Date(2017, 11, 6, 11, 5, 0, 0, time.UTC)},
+		{time.Date(2017, 11, 6, 10, 5, 0, 0, time.UTC), false, time.Date(2017, 11, 6, 11, 5, 0, 0, time.UTC)},
+		// the backup of 10:05 was missed
+		{time.Date(2017, 11, 6, 9, 5, 0, 0, time.UTC), true, now},
+	}
+	for i, tt := range tests {
+		s := &backupScheduler{schedule: intervalSchedule(time.Hour), jitter: 5 * time.Minute}
+		if overdue := s.start(tt.last, now); overdue != tt.overdue {
+			t.Errorf("#%d: overdue = %v, want %v", i, overdue, tt.overdue)
+		}
+		if next := s.nextTime(); !next.Equal(tt.next) {
+			t.Errorf("#%d: next = %v, want %v", i, next, tt.next)
+		}
+	}
+}
+
+func TestBackupSchedulerAdvance(t *testing.T) {
+	s := &backupScheduler{schedule: intervalSchedule(time.Hour), jitter: 5 * time.Minute}
+	s.start(time.Time{}, time.Date(2017, 11, 6, 10, 30, 0, 0, time.UTC))
+	// the backup of 11:05 took until 13:20, the missed ones are skipped.
+	s.advance(time.Date(2017, 11, 6, 13, 20, 0, 0, time.UTC))
+	if next, want := s.nextTime(), time.Date(2017, 11, 6, 14, 5, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("next = %v, want %v", next, want)
+	}
+}
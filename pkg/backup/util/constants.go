@@ -14,8 +14,22 @@
 
 package util
 
+import api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+
 const (
 	BackupTmpDir         = "tmp"
 	BackupFilePerm       = 0600
 	BackupFilenameSuffix = "etcd.backup"
+	DeltaFilenameSuffix  = "etcd.delta"
+	ChainFilenameSuffix  = "etcd.chain"
+	// RetiredSuffix is appended to the name of the backups retired by a rollback of the cluster,
+	// so that they are no longer listed as backups but can still be restored from by name.
+	RetiredSuffix = ".retired"
 )
+
+// compressionSuffixes maps a compression codec to the suffix appended to
+// the name of the backups compressed with it.
+var compressionSuffixes = map[string]string{
+	api.BackupCompressionGzip: ".gz",
+	api.BackupCompressionZstd: ".zst",
+}
@@ -16,10 +16,14 @@ package util
 
 import (
 	"fmt"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
 
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -32,13 +36,95 @@ func GetLatestBackupName(names []string) string {
 }
 
 func IsBackup(name string) bool {
-	return strings.HasSuffix(name, BackupFilenameSuffix)
+	return strings.HasSuffix(trimCompressionSuffix(name), BackupFilenameSuffix)
 }
 
 func MakeBackupName(ver string, rev int64) string {
 	return fmt.Sprintf("%s_%016x_%s", ver, rev, BackupFilenameSuffix)
 }
 
+// MakeCompressedBackupName returns the name of a backup compressed with the given codec,
+// e.g. "3.1.8_0000000000000001_etcd.backup.gz" for gzip.
+// An empty codec returns the uncompressed backup name.
+func MakeCompressedBackupName(ver string, rev int64, codec string) string {
+	return MakeBackupName(ver, rev) + compressionSuffixes[codec]
+}
+
+// BackupNames returns the names the backup of the given version and revision may have,
+// uncompressed first.
+func BackupNames(ver string, rev int64) []string {
+	names := []string{MakeBackupName(ver, rev)}
+	for _, codec := range []string{api.BackupCompressionGzip, api.BackupCompressionZstd} {
+		names = append(names, MakeCompressedBackupName(ver, rev, codec))
+	}
+	return names
+}
+
+// MakeRetiredName returns the name the given backup is kept under once retired,
+// e.g. "3.1.8_0000000000000001_etcd.backup.gz.retired".
+func MakeRetiredName(backup string) string {
+	return backup + RetiredSuffix
+}
+
+// CompressionFromBackupName returns the codec the backup was compressed with,
+// or an empty string if it is not compressed. The backup may be retired.
+func CompressionFromBackupName(name string) string {
+	name = strings.TrimSuffix(name, RetiredSuffix)
+	for codec, suffix := range compressionSuffixes {
+		if strings.HasSuffix(name, BackupFilenameSuffix+suffix) {
+			return codec
+		}
+	}
+	return ""
+}
+
+// MakeDeltaName returns the name of the delta chunk ending at endRev recorded after
+// the backup of the given version and base revision,
+// e.g. "3.1.8_0000000000000001_0000000000000020_etcd.delta".
+func MakeDeltaName(ver string, baseRev, endRev int64) string {
+	return fmt.Sprintf("%s_%016x_%016x_%s", ver, baseRev, endRev, DeltaFilenameSuffix)
+}
+
+// ChainName returns the name of the delta chain of the given backup,
+// e.g. "3.1.8_0000000000000001_etcd.chain" for "3.1.8_0000000000000001_etcd.backup.gz".
+// A leading path of the backup name is kept.
+func ChainName(backup string) string {
+	dir, name := path.Split(backup)
+	return dir + backupPrefix(name) + ChainFilenameSuffix
+}
+
+// ChainObjects returns the names of the delta chain and delta chunks of the given backup, with their manifests.
+func ChainObjects(names []string, backup string) []string {
+	prefix := backupPrefix(backup)
+	var objs []string
+	for _, n := range names {
+		if !strings.HasPrefix(n, prefix) {
+			continue
+		}
+		s := strings.TrimSuffix(n, manifest.Suffix)
+		if strings.HasSuffix(s, DeltaFilenameSuffix) || strings.HasSuffix(s, ChainFilenameSuffix) {
+			objs = append(objs, n)
+		}
+	}
+	return objs
+}
+
+// backupPrefix returns the "<version>_<revision>_" prefix shared by a backup and its delta chain.
+func backupPrefix(name string) string {
+	parts := strings.SplitN(name, "_", 3)
+	if len(parts) != 3 {
+		return name
+	}
+	return parts[0] + "_" + parts[1] + "_"
+}
+
+func trimCompressionSuffix(name string) string {
+	if codec := CompressionFromBackupName(name); len(codec) != 0 {
+		return strings.TrimSuffix(name, compressionSuffixes[codec])
+	}
+	return name
+}
+
 func MustParseRevision(name string) int64 {
 	rev, err := parseRevision(name)
 	if err != nil {
@@ -17,6 +17,9 @@ package util
 import (
 	"reflect"
 	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 )
 
 func TestFilterAndSortBackups(t *testing.T) {
@@ -83,3 +86,77 @@ func TestGetLatestBackupName(t *testing.T) {
 		t.Errorf("name = %s, want %s", gname, wname)
 	}
 }
+
+func TestCompressedBackupNames(t *testing.T) {
+	names := []string{
+		MakeCompressedBackupName("3.1.0", 4, "zstd"),
+		MakeBackupName("3.1.0", 1),
+		MakeCompressedBackupName("3.1.0", 3, "gzip"),
+		"3.1.0_0000000000000002_etcd.backup.bz2", // unknown codec
+		MakeRetiredName(MakeCompressedBackupName("3.1.0", 5, "gzip")),
+		MakeBackupName("3.1.0", 2),
+	}
+	w := []string{
+		MakeBackupName("3.1.0", 1),
+		MakeBackupName("3.1.0", 2),
+		"3.1.0_0000000000000003_etcd.backup.gz",
+		"3.1.0_0000000000000004_etcd.backup.zst",
+	}
+	got := FilterAndSortBackups(names)
+	if !reflect.DeepEqual(got, w) {
+		t.Errorf("got = %v, want %v", got, w)
+	}
+
+	tests := []struct {
+		name  string
+		codec string
+		rev   int64
+	}{
+		{MakeBackupName("3.1.0", 1), "", 1},
+		{MakeCompressedBackupName("3.1.0", 3, "gzip"), "gzip", 3},
+		{MakeCompressedBackupName("3.1.0", 4, "zstd"), "zstd", 4},
+		{MakeRetiredName(MakeCompressedBackupName("3.1.0", 5, "gzip")), "gzip", 5},
+	}
+	for i, tt := range tests {
+		if codec := CompressionFromBackupName(tt.name); codec != tt.codec {
+			t.Errorf("#%d: codec = %q, want %q", i, codec, tt.codec)
+		}
+		if rev := MustParseRevision(tt.name); rev != tt.rev {
+			t.Errorf("#%d: rev = %d, want %d", i, rev, tt.rev)
+		}
+	}
+}
+
+func TestChainObjects(t *testing.T) {
+	backup := MakeCompressedBackupName("3.1.8", 1, api.BackupCompressionGzip)
+	names := []string{
+		backup,
+		manifest.Name(backup),
+		MakeDeltaName("3.1.8", 1, 20),
+		manifest.Name(MakeDeltaName("3.1.8", 1, 20)),
+		ChainName(backup),
+		manifest.Name(ChainName(backup)),
+		MakeBackupName("3.1.8", 21),
+		MakeDeltaName("3.1.8", 21, 30),
+		ChainName(MakeBackupName("3.1.8", 21)),
+	}
+	w := []string{
+		MakeDeltaName("3.1.8", 1, 20),
+		manifest.Name(MakeDeltaName("3.1.8", 1, 20)),
+		"3.1.8_0000000000000001_etcd.chain",
+		"3.1.8_0000000000000001_etcd.chain" + manifest.Suffix,
+	}
+	if got := ChainObjects(names, backup); !reflect.DeepEqual(got, w) {
+		t.Errorf("got = %v, want %v", got, w)
+	}
+	if got := FilterAndSortBackups(names); len(got) != 2 {
+		t.Errorf("expect delta chain objects not to be taken for backups, got %v", got)
+	}
+}
+
+func TestChainName(t *testing.T) {
+	got := ChainName("bucket/prefix/3.1.8_0000000000000001_etcd.backup.zst")
+	if w := "bucket/prefix/3.1.8_0000000000000001_etcd.chain"; got != w {
+		t.Errorf("got = %s, want %s", got, w)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/retryutil"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultVerificationTimeout = 600 * time.Second
+	verificationPollInterval   = 5 * time.Second
+)
+
+// backupVerifier verifies the latest backup by restoring it into a throwaway etcd pod.
+type backupVerifier struct {
+	kubecli     kubernetes.Interface
+	clusterName string
+	clusterUID  string
+	namespace   string
+	baseImage   string
+	// authSecret is the backup API auth secret the verification pod fetches the backup with.
+	// It is empty if the backup API is not secured.
+	authSecret string
+
+	be     backend.Backend
+	policy api.BackupVerificationPolicy
+
+	mu sync.Mutex
+	// lastVerified is the status of the most recent backup that passed verification.
+	lastVerified *backupapi.BackupStatus
+	// lastErr is the error of the last verification, if it failed.
+	lastErr string
+}
+
+// run verifies the latest backup at every interval of the verification policy.
+func (v *backupVerifier) run() {
+	interval := time.Duration(v.policy.IntervalInSecond) * time.Second
+	for {
+		<-time.After(interval)
+		bs, err := v.verifyLatest()
+		v.mu.Lock()
+		if err != nil {
+			logrus.Errorf("backup verification failed: %v", err)
+			v.lastErr = err.Error()
+		} else if bs != nil {
+			logrus.Infof("verified backup (rev: %v, etcdVersion: %v)", bs.Revision, bs.Version)
+			v.lastVerified = bs
+			v.lastErr = ""
+		}
+		v.mu.Unlock()
+	}
+}
+
+// status returns the status of the most recent verified backup and the error of the last verification.
+func (v *backupVerifier) status() (*backupapi.BackupStatus, string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lastVerified, v.lastErr
+}
+
+// verifyLatest verifies the latest backup and returns its status.
+// If there is no backup, it returns nil status and nil error.
+func (v *backupVerifier) verifyLatest() (*backupapi.BackupStatus, error) {
+	name, err := v.be.GetLatest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest backup: %v", err)
+	}
+	if len(name) == 0 {
+		return nil, nil
+	}
+	m, err := v.be.Manifest(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest of backup %s: %v", name, err)
+	}
+
+	count, rev, err := v.restore(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore backup %s: %v", name, err)
+	}
+	if rev != m.Revision {
+		return nil, fmt.Errorf("backup %s: restored revision %d, the manifest has %d", name, rev, m.Revision)
+	}
+	if count != m.KeyCount {
+		return nil, fmt.Errorf("backup %s: restored %d keys at revision %d, the manifest has %d", name, count, m.Revision, m.KeyCount)
+	}
+
+	return &backupapi.BackupStatus{
+		CreationTime: m.CreationTime,
+		Size:         util.ToMB(m.Size),
+		Revision:     m.Revision,
+		Version:      m.EtcdVersion,
+	}, nil
+}
+
+// restore runs the verification pod for the backup described by m
+// and returns the number of keys of the restored etcd at the revision of the backup, as counted inside the pod,
+// and the revision of the restored etcd.
+func (v *backupVerifier) restore(m *manifest.Manifest) (int64, int64, error) {
+	scheme := "http"
+	if len(v.authSecret) != 0 {
+		scheme = "https"
+	}
+	backupURL := backupapi.NewBackupURL(scheme, k8sutil.BackupServiceAddr(v.clusterName), m.EtcdVersion, m.Revision)
+	owner := (&api.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: v.clusterName, UID: types.UID(v.clusterUID)},
+	}).AsOwner()
+	pod := k8sutil.NewBackupVerificationPod(v.clusterName, v.baseImage, m.EtcdVersion, m.Revision, backupURL, v.authSecret, owner)
+
+	pods := v.kubecli.CoreV1().Pods(v.namespace)
+	// Remove the pod left behind by an interrupted verification.
+	err := pods.Delete(pod.Name, k8sutil.CascadeDeleteOptions(0))
+	if err != nil && !k8sutil.IsKubernetesResourceNotFoundError(err) {
+		return 0, 0, err
+	}
+	err = retryutil.Retry(verificationPollInterval, 12, func() (bool, error) {
+		_, err := pods.Create(pod)
+		if k8sutil.IsKubernetesResourceAlreadyExistError(err) {
+			// The previous pod is still terminating.
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create verification pod: %v", err)
+	}
+	defer func() {
+		if err := pods.Delete(pod.Name, k8sutil.CascadeDeleteOptions(0)); err != nil {
+			logrus.Warningf("failed to delete verification pod %s: %v", pod.Name, err)
+		}
+	}()
+
+	timeout := defaultVerificationTimeout
+	if v.policy.TimeoutInSecond != 0 {
+		timeout = time.Duration(v.policy.TimeoutInSecond) * time.Second
+	}
+	polls := int(timeout / verificationPollInterval)
+	if polls < 1 {
+		polls = 1
+	}
+	var count, rev int64
+	err = retryutil.Retry(verificationPollInterval, polls, func() (bool, error) {
+		p, err := pods.Get(pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		var done bool
+		count, rev, done, err = verificationResult(p)
+		return done, err
+	})
+	if retryutil.IsRetryFailure(err) {
+		return 0, 0, fmt.Errorf("verification did not finish within %v", timeout)
+	}
+	return count, rev, err
+}
+
+// verificationResult returns the number of keys the verification pod counted and the revision of the etcd it restored
+// once it has succeeded, or an error if it has failed. done is false while the pod has not terminated.
+func verificationResult(p *v1.Pod) (count, rev int64, done bool, err error) {
+	switch p.Status.Phase {
+	case v1.PodSucceeded:
+		for _, cs := range p.Status.ContainerStatuses {
+			if cs.Name != k8sutil.BackupVerificationContainerName || cs.State.Terminated == nil {
+				continue
+			}
+			msg := strings.TrimSpace(cs.State.Terminated.Message)
+			fields := strings.Fields(msg)
+			if len(fields) != 2 {
+				return 0, 0, true, fmt.Errorf("unexpected result %q of verification pod: want key count and revision", msg)
+			}
+			count, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				return 0, 0, true, fmt.Errorf("unexpected key count %q of verification pod: %v", fields[0], err)
+			}
+			rev, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, 0, true, fmt.Errorf("unexpected revision %q of verification pod: %v", fields[1], err)
+			}
+			return count, rev, true, nil
+		}
+		return 0, 0, true, errors.New("verification pod succeeded without a result")
+	case v1.PodFailed:
+		return 0, 0, true, fmt.Errorf("verification pod failed: %s", terminationMessage(p))
+	}
+	return 0, 0, false, nil
+}
+
+func terminationMessage(p *v1.Pod) string {
+	for _, cs := range p.Status.InitContainerStatuses {
+		if t := cs.State.Terminated; t != nil && t.ExitCode != 0 {
+			return fmt.Sprintf("init container %s exited with %d: %s", cs.Name, t.ExitCode, t.Message)
+		}
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		if t := cs.State.Terminated; t != nil {
+			return fmt.Sprintf("container %s exited with %d: %s", cs.Name, t.ExitCode, t.Message)
+		}
+	}
+	return "unknown reason"
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+)
+
+func TestVerificationResult(t *testing.T) {
+	failed := v1.PodStatus{
+		Phase: v1.PodFailed,
+		ContainerStatuses: []v1.ContainerStatus{{
+			Name:  "verify-backup",
+			State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Message: "snapshot restore failed"}},
+		}},
+	}
+	succeeded := func(msg string) v1.PodStatus {
+		return v1.PodStatus{
+			Phase: v1.PodSucceeded,
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "verify-backup",
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Message: msg}},
+			}},
+		}
+	}
+	tests := []struct {
+		status v1.PodStatus
+		count  int64
+		rev    int64
+		done   bool
+		werr   bool
+	}{
+		{v1.PodStatus{Phase: v1.PodPending}, 0, 0, false, false},
+		{v1.PodStatus{Phase: v1.PodRunning, PodIP: "10.0.0.1"}, 0, 0, false, false},
+		{succeeded("42 1234"), 42, 1234, true, false},
+		{succeeded("0 7\n"), 0, 7, true, false},
+		// the revision is missing.
+		{succeeded("42 "), 0, 0, true, true},
+		{succeeded("Error: etcdserver: mvcc: required revision is a future revision"), 0, 0, true, true},
+		{v1.PodStatus{Phase: v1.PodSucceeded}, 0, 0, true, true},
+		{failed, 0, 0, true, true},
+	}
+	for i, tt := range tests {
+		count, rev, done, err := verificationResult(&v1.Pod{Status: tt.status})
+		if count != tt.count || rev != tt.rev || done != tt.done {
+			t.Errorf("#%d: count, rev, done = %d, %d, %v, want %d, %d, %v", i, count, rev, done, tt.count, tt.rev, tt.done)
+		}
+		if (err != nil) != tt.werr {
+			t.Errorf("#%d: err = %v, want error %v", i, err, tt.werr)
+		}
+	}
+}
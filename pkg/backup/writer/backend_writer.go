@@ -0,0 +1,37 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"io"
+
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+)
+
+type backendWriter struct {
+	be backend.Backend
+}
+
+// NewBackendWriter creates a writer that saves the backup with the backend of a sidecar storage,
+// so that it is laid out as the backups made by the backup sidecar.
+// The path given to Write is the name of the backup in the backend.
+func NewBackendWriter(be backend.Backend) Writer {
+	return &backendWriter{be}
+}
+
+func (bw *backendWriter) Write(name string, r io.Reader, m manifest.Manifest) (int64, error) {
+	return bw.be.Save(name, r, m)
+}
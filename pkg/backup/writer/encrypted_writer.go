@@ -0,0 +1,36 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"io"
+
+	"github.com/coreos/etcd-operator/pkg/backup/encryption"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+)
+
+type encryptedWriter struct {
+	w       Writer
+	keyring *encryption.Keyring
+}
+
+// NewEncryptedWriter creates a writer that encrypts the backup with the keyring before writing it with w.
+func NewEncryptedWriter(w Writer, kr *encryption.Keyring) Writer {
+	return &encryptedWriter{w, kr}
+}
+
+func (ew *encryptedWriter) Write(path string, r io.Reader, m manifest.Manifest) (int64, error) {
+	return ew.w.Write(path, ew.keyring.Encrypt(r), m)
+}
@@ -15,9 +15,12 @@
 package writer
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
 	"github.com/coreos/etcd-operator/pkg/backup/util"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -34,23 +37,40 @@ func NewS3Writer(s3 *s3.S3) Writer {
 	return &s3Writer{s3}
 }
 
-// Write writes the backup file to the given s3 path, "<s3-bucket-name>/<key>".
-func (s3w *s3Writer) Write(path string, r io.Reader) (int64, error) {
+// Write writes the backup file to the given s3 path, "<s3-bucket-name>/<key>",
+// and its manifest next to it.
+func (s3w *s3Writer) Write(path string, r io.Reader, m manifest.Manifest) (int64, error) {
 	bk, key, err := util.ParseBucketAndKey(path)
 	if err != nil {
 		return 0, err
 	}
 
-	_, err = s3manager.NewUploaderWithClient(s3w.s3).Upload(
+	h := manifest.NewHasher(r)
+	uploader := s3manager.NewUploaderWithClient(s3w.s3)
+	_, err = uploader.Upload(
 		&s3manager.UploadInput{
 			Bucket: aws.String(bk),
 			Key:    aws.String(key),
-			Body:   r,
+			Body:   h,
 		})
 	if err != nil {
 		return 0, err
 	}
 
+	b, err := json.Marshal(h.Complete(m))
+	if err != nil {
+		return 0, err
+	}
+	_, err = uploader.Upload(
+		&s3manager.UploadInput{
+			Bucket: aws.String(bk),
+			Key:    aws.String(manifest.Name(key)),
+			Body:   bytes.NewReader(b),
+		})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write manifest: %v", err)
+	}
+
 	resp, err := s3w.s3.GetObject(&s3.GetObjectInput{
 		Bucket: aws.String(bk),
 		Key:    aws.String(key),
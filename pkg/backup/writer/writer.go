@@ -14,10 +14,15 @@
 
 package writer
 
-import "io"
+import (
+	"io"
+
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+)
 
 // Writer defines the required writer operations.
 type Writer interface {
-	// Write writes a backup file to the given path and returns size of written file.
-	Write(path string, r io.Reader) (int64, error)
+	// Write writes a backup file to the given path, and the manifest m completed with
+	// the SHA-256 and size of the written file next to it. It returns size of written file.
+	Write(path string, r io.Reader, m manifest.Manifest) (int64, error)
 }
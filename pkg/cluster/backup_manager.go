@@ -42,6 +42,7 @@ var (
 	errNoS3ConfigForBackup   = errors.New("no backup could be created due to S3 configuration not set")
 	errNoABSCredsForBackup   = errors.New("no backup could be created due to ABS credentials not set")
 	errNoSwiftCredsForBackup = errors.New("no backup could be created due to Swift configuration not set")
+	errNoGCSCredsForBackup   = errors.New("no backup could be created due to GCS configuration not set")
 )
 
 type backupManager struct {
@@ -50,6 +51,8 @@ type backupManager struct {
 	config  Config
 	cluster *api.EtcdCluster
 	s       backupstorage.Storage
+	// destinations are the storages of the secondary destinations of the backups.
+	destinations []backupstorage.Storage
 
 	bc experimentalclient.Backup
 }
@@ -59,22 +62,70 @@ func newBackupManager(c Config, cl *api.EtcdCluster, l *logrus.Entry) (*backupMa
 		config:  c,
 		cluster: cl,
 		logger:  l,
-		bc:      experimentalclient.NewBackup(&http.Client{}, "http", cl.GetName()),
 	}
 	var err error
+	bm.bc, err = bm.newBackupClient()
+	if err != nil {
+		return nil, err
+	}
 	bm.s, err = bm.setupStorage()
 	if err != nil {
 		return nil, err
 	}
+	bm.destinations, err = bm.setupDestinations()
+	if err != nil {
+		return nil, err
+	}
 	return bm, nil
 }
 
+// newBackupClient returns the client of the backup sidecar API.
+// If the backup policy secures the API, it provisions the auth secret
+// the client and the restoring members authenticate with.
+func (bm *backupManager) newBackupClient() (experimentalclient.Backup, error) {
+	cl := bm.cluster
+	ap := cl.Spec.Backup.API
+	if ap == nil {
+		return experimentalclient.NewBackup(&http.Client{}, "http", cl.Name), nil
+	}
+	name := k8sutil.BackupAPIAuthSecretName(cl.Name)
+	err := k8sutil.EnsureBackupAPIAuthSecret(bm.config.KubeCli, cl.Namespace, name, ap.ServerSecret, cl.AsOwner())
+	if err != nil {
+		return nil, err
+	}
+	c, err := k8sutil.NewBackupAPIClient(bm.config.KubeCli, cl.Namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return experimentalclient.NewBackup(c, "https", cl.Name), nil
+}
+
 // setupStorage will only set up the necessary structs in order for backup manager to
 // use the storage. It doesn't creates the actual storage here.
 func (bm *backupManager) setupStorage() (s backupstorage.Storage, err error) {
+	return bm.newStorage(bm.cluster.Spec.Backup)
+}
+
+// setupDestinations sets up the storages of the secondary destinations of the backups.
+func (bm *backupManager) setupDestinations() ([]backupstorage.Storage, error) {
+	b := bm.cluster.Spec.Backup
+	var ss []backupstorage.Storage
+	for _, d := range b.Destinations {
+		p := *b
+		p.StorageType = d.StorageType
+		p.StorageSource = d.StorageSource
+		s, err := bm.newStorage(&p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up backup destination (%s): %v", d.Name, err)
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+func (bm *backupManager) newStorage(b *api.BackupPolicy) (s backupstorage.Storage, err error) {
 	cl, c := bm.cluster, bm.config
 
-	b := cl.Spec.Backup
 	switch b.StorageType {
 	case api.BackupStorageTypePersistentVolume, api.BackupStorageTypeDefault:
 		storageClass := b.PV.StorageClass
@@ -94,6 +145,11 @@ func (bm *backupManager) setupStorage() (s backupstorage.Storage, err error) {
 			return nil, errNoSwiftCredsForBackup
 		}
 		s, err = backupstorage.NewSwiftStorage(c.KubeCli, cl.Name, cl.Namespace, *b)
+	case api.BackupStorageTypeGCS:
+		if b.GCS == nil {
+			return nil, errNoGCSCredsForBackup
+		}
+		s, err = backupstorage.NewGCSStorage(c.KubeCli, cl.Name, cl.Namespace, *b)
 	}
 	return s, err
 }
@@ -143,10 +199,18 @@ func (bm *backupManager) updateSidecar(cl *api.EtcdCluster) error {
 	// change local structs
 	bm.cluster = cl
 	var err error
+	bm.bc, err = bm.newBackupClient()
+	if err != nil {
+		return err
+	}
 	bm.s, err = bm.setupStorage()
 	if err != nil {
 		return err
 	}
+	bm.destinations, err = bm.setupDestinations()
+	if err != nil {
+		return err
+	}
 	ns, n := cl.Namespace, k8sutil.BackupSidecarName(cl.Name)
 	// change k8s objects
 	uf := func(d *appsv1beta1.Deployment) {
@@ -157,7 +221,7 @@ func (bm *backupManager) updateSidecar(cl *api.EtcdCluster) error {
 
 func (bm *backupManager) makeSidecarDeployment() *appsv1beta1.Deployment {
 	cl := bm.cluster
-	podTemplate := k8sutil.NewBackupPodTemplate(cl.Name, bm.config.ServiceAccount, cl.Spec)
+	podTemplate := k8sutil.NewBackupPodTemplate(cl.Name, cl.UID, bm.config.ServiceAccount, cl.Spec)
 	switch cl.Spec.Backup.StorageType {
 	case api.BackupStorageTypeDefault, api.BackupStorageTypePersistentVolume:
 		k8sutil.PodSpecWithPV(&podTemplate.Spec, cl.Name)
@@ -173,6 +237,10 @@ func (bm *backupManager) makeSidecarDeployment() *appsv1beta1.Deployment {
 		if ws := cl.Spec.Backup.Swift; ws != nil {
 			k8sutil.AttachSwiftToPodSpec(&podTemplate.Spec, *ws)
 		}
+	case api.BackupStorageTypeGCS:
+		if gs := cl.Spec.Backup.GCS; gs != nil {
+			k8sutil.AttachGCSToPodSpec(&podTemplate.Spec, *gs)
+		}
 	}
 	name := k8sutil.BackupSidecarName(cl.Name)
 	dplSel := k8sutil.LabelsForCluster(cl.Name)
@@ -196,6 +264,11 @@ func (bm *backupManager) cleanup() error {
 	if err != nil {
 		return fmt.Errorf("fail to delete backup storage: %v", err)
 	}
+	for i, s := range bm.destinations {
+		if err := s.Delete(); err != nil {
+			return fmt.Errorf("fail to delete backup destination (%s): %v", bm.cluster.Spec.Backup.Destinations[i].Name, err)
+		}
+	}
 	return nil
 }
 
@@ -211,6 +284,14 @@ func (bm *backupManager) checkBackupExist(ver string) (bool, error) {
 	return bm.bc.Exist(ctx, ver)
 }
 
+// findRestoreBackup returns the backup to restore a cluster of the given version from to the target t,
+// and the revision it is restored to. It returns an empty name if there is no backup at or before t.
+func (bm *backupManager) findRestoreBackup(ver string, t api.RestoreTarget) (string, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultBackupHTTPTimeout)
+	defer cancel()
+	return bm.bc.Find(ctx, ver, t)
+}
+
 func (bm *backupManager) getStatus() (*backupapi.ServiceStatus, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultBackupHTTPTimeout)
 	defer cancel()
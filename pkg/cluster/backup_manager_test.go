@@ -55,3 +55,20 @@ func TestNewBackupManagerWithoutABSCreds(t *testing.T) {
 		t.Errorf("expect err=%v, get=%v", errNoABSCredsForBackup, err)
 	}
 }
+
+func TestNewBackupManagerWithoutGCSConfig(t *testing.T) {
+	cfg := Config{}
+	cl := &api.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "testing"},
+		Spec: api.ClusterSpec{
+			Backup: &api.BackupPolicy{
+				StorageType: api.BackupStorageTypeGCS,
+				MaxBackups:  1,
+			},
+		},
+	}
+	_, err := newBackupManager(cfg, cl, nil)
+	if err != errNoGCSCredsForBackup {
+		t.Errorf("expect err=%v, get=%v", errNoGCSCredsForBackup, err)
+	}
+}
@@ -37,7 +37,7 @@ func NewABSStorage(kubecli kubernetes.Interface, clusterName, ns string, p api.B
 	prefix := path.Join(ns, clusterName)
 
 	abscli, err := func() (*backupabs.ABS, error) {
-		account, key, err := setupABSCreds(kubecli, ns, p.ABS.ABSSecret)
+		account, key, err := GetABSCreds(kubecli, ns, p.ABS.ABSSecret)
 		if err != nil {
 			return nil, err
 		}
@@ -83,7 +83,8 @@ func (a *abs) Delete() error {
 	return nil
 }
 
-func setupABSCreds(kubecli kubernetes.Interface, ns, secret string) (account, key string, err error) {
+// GetABSCreds returns the storage account and key stored in the given ABS secret.
+func GetABSCreds(kubecli kubernetes.Interface, ns, secret string) (account, key string, err error) {
 	se, err := kubecli.CoreV1().Secrets(ns).Get(secret, metav1.GetOptions{})
 	if err != nil {
 		return "", "", err
@@ -0,0 +1,98 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupstorage
+
+import (
+	"fmt"
+	"path"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	backupgcs "github.com/coreos/etcd-operator/pkg/backup/gcs"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type gcs struct {
+	clusterName  string
+	namespace    string
+	backupPolicy api.BackupPolicy
+	kubecli      kubernetes.Interface
+	gcscli       *backupgcs.GCS
+}
+
+// NewGCSStorage returns a new GCS Storage implementation using the given kubecli, cluster name, namespace and backup policy
+func NewGCSStorage(kubecli kubernetes.Interface, clusterName, ns string, p api.BackupPolicy) (Storage, error) {
+	prefix := path.Join(p.GCS.Prefix, ns, clusterName)
+
+	gcscli, err := func() (*backupgcs.GCS, error) {
+		saJSON, err := GetGCSCreds(kubecli, ns, p.GCS.GCSSecret)
+		if err != nil {
+			return nil, err
+		}
+		return backupgcs.New(p.GCS.GCSBucket, prefix, saJSON)
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &gcs{
+		kubecli:      kubecli,
+		clusterName:  clusterName,
+		backupPolicy: p,
+		namespace:    ns,
+		gcscli:       gcscli,
+	}
+	return g, nil
+}
+
+func (g *gcs) Create() error {
+	// The bucket is checked to exist when the client is created.
+	return nil
+}
+
+func (g *gcs) Clone(from string) error {
+	prefix := path.Join(g.backupPolicy.GCS.Prefix, g.namespace, from)
+	return g.gcscli.CopyPrefix(prefix)
+}
+
+func (g *gcs) Delete() error {
+	if g.backupPolicy.AutoDelete {
+		names, err := g.gcscli.List()
+		if err != nil {
+			return err
+		}
+		for _, n := range names {
+			err = g.gcscli.Delete(n)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetGCSCreds returns the service account JSON key stored in the given GCS secret.
+func GetGCSCreds(kubecli kubernetes.Interface, ns, secret string) ([]byte, error) {
+	se, err := kubecli.CoreV1().Secrets(ns).Get(secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	saJSON, ok := se.Data[api.GCSServiceAccountJSON]
+	if !ok {
+		return nil, fmt.Errorf("secret (%s) has no %s key", secret, api.GCSServiceAccountJSON)
+	}
+	return saJSON, nil
+}
@@ -34,7 +34,7 @@ type s3 struct {
 }
 
 func NewS3Storage(kubecli kubernetes.Interface, clusterName, ns string, p api.BackupPolicy) (Storage, error) {
-	cli, err := s3factory.NewClientFromSecret(kubecli, ns, p.S3.AWSSecret)
+	cli, err := s3factory.NewClientFromSecret(kubecli, ns, p.S3.AWSSecret, p.S3.S3Endpoint)
 	if err != nil {
 		return nil, err
 	}
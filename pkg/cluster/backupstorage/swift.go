@@ -43,7 +43,7 @@ func NewSwiftStorage(kubecli kubernetes.Interface, clusterName, ns string, p api
 	prefix := path.Join(ns, clusterName)
 
 	swiftCli, err := func() (*backupswift.Swift, error) {
-		authOptions, err := setupSwiftCreds(kubecli, ns, p.Swift.SwiftSecret)
+		authOptions, err := GetSwiftCreds(kubecli, ns, p.Swift.SwiftSecret)
 		if err != nil {
 			return nil, err
 		}
@@ -63,7 +63,8 @@ func NewSwiftStorage(kubecli kubernetes.Interface, clusterName, ns string, p api
 	return s, nil
 }
 
-func setupSwiftCreds(kubecli kubernetes.Interface, ns, secret string) (gophercloud.AuthOptions, error) {
+// GetSwiftCreds returns the Openstack credentials stored in the given Swift secret.
+func GetSwiftCreds(kubecli kubernetes.Interface, ns, secret string) (gophercloud.AuthOptions, error) {
 	se, err := kubecli.CoreV1().Secrets(ns).Get(secret, metav1.GetOptions{})
 	if err != nil {
 		return gophercloud.AuthOptions{}, err
@@ -89,6 +89,16 @@ type Cluster struct {
 	members etcdutil.MemberSet
 	volumes VolumeSet
 
+	// memberRestarts counts how many times a dead member has been restarted in place
+	// on its PVC since it was last seen ready for minMemberUptime.
+	memberRestarts map[string]int
+	// scaleDownRules picks the member to remove when scaling down.
+	// If nil, defaultScaleDownRules are used.
+	scaleDownRules []scaleDownRule
+
+	// storageNearlyFull tracks the members already reported with a nearly full database.
+	storageNearlyFull map[string]bool
+
 	bm *backupManager
 
 	tlsConfig *tls.Config
@@ -117,6 +127,9 @@ func New(config Config, cl *api.EtcdCluster) *Cluster {
 		eventsCli:   config.KubeCli.Core().Events(cl.Namespace),
 		members:     etcdutil.NewMemberSet(),
 		volumes:     NewVolumeSet(),
+
+		memberRestarts:    make(map[string]int),
+		storageNearlyFull: make(map[string]bool),
 	}
 
 	go func() {
@@ -289,10 +302,19 @@ func (c *Cluster) run() {
 
 			if c.cluster.Spec.Paused {
 				c.status.PauseControl()
+				// Report that control is paused, so that whoever paused the cluster knows
+				// the operator no longer touches its members.
+				if err := c.updateCRStatus(); err != nil {
+					c.logger.Warningf("failed to report paused control: %v", err)
+				}
 				c.logger.Infof("control is paused, skipping reconciliation")
 				continue
-			} else {
+			} else if c.status.ControlPaused {
 				c.status.Control()
+				// The members might have been replaced while control was paused, e.g. by an in-place restore.
+				// Reload the membership from the running members.
+				c.members = etcdutil.NewMemberSet()
+				c.logger.Infof("control is resumed, reloading membership")
 			}
 
 			running, pending, err := c.pollPods()
@@ -338,6 +360,9 @@ func (c *Cluster) run() {
 				c.logger.Warningf("failed to update local backup service status: %v", err)
 			}
 			c.updateMemberStatus(c.members)
+			if c.IsPodPVEnabled() {
+				c.updateStorageCondition(c.members)
+			}
 			if err := c.updateCRStatus(); err != nil {
 				c.logger.Warningf("periodic update CR status failed: %v", err)
 			}
@@ -451,12 +476,19 @@ func (c *Cluster) prepareVolume() (*Volume, error) {
 			Name:       volumeName,
 			Namespace:  c.cluster.Namespace,
 			IsAttached: false,
+			SizeInMB:   c.cluster.Spec.Pod.PV.VolumeSizeInMB,
 		}
 	}
 
 	if err := c.createPVC(v.Name); err != nil && !apierrors.IsAlreadyExists(err) {
 		return nil, fmt.Errorf("failed to create persistent volume claim for seed member (%s): %v", v.Name, err)
 	}
+	if c.isWALEnabled() {
+		v.WALName = k8sutil.WALClaimName(v.Name)
+		if err := c.createWALPVC(v.WALName); err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create WAL persistent volume claim (%s): %v", v.WALName, err)
+		}
+	}
 	//We don't want to skip volume count in case of already existing volume
 	if c.volumes[v.Name] == nil {
 		c.volumeCounter++
@@ -490,10 +522,24 @@ func (c *Cluster) isSecureClient() bool {
 	return c.cluster.Spec.TLS.IsSecureClient()
 }
 
+// restoreTarget returns the target of the restore policy of the cluster,
+// if the cluster has yet to be restored to it.
+func (c *Cluster) restoreTarget() (api.RestoreTarget, bool) {
+	r := c.cluster.Spec.Restore
+	if r == nil || !r.IsSet() || c.status.RestoredSnapshot != nil {
+		return api.RestoreTarget{}, false
+	}
+	return r.RestoreTarget, true
+}
+
 func (c *Cluster) IsPodPVEnabled() bool {
 	return c.cluster.Spec.Pod != nil && c.cluster.Spec.Pod.PV != nil
 }
 
+func (c *Cluster) isWALEnabled() bool {
+	return c.IsPodPVEnabled() && c.cluster.Spec.Pod.WAL != nil
+}
+
 // bootstrap creates the seed etcd member for a new cluster.
 func (c *Cluster) bootstrap() error {
 	return c.startSeedMember(false)
@@ -539,20 +585,40 @@ func (c *Cluster) createPVC(pvcName string) error {
 	return err
 }
 
+func (c *Cluster) createWALPVC(pvcName string) error {
+	pvc := k8sutil.NewWALPVC(pvcName, c.cluster.Spec, c.cluster.Name, c.cluster.Namespace, c.cluster.AsOwner())
+	_, err := c.config.KubeCli.Core().PersistentVolumeClaims(c.cluster.Namespace).Create(pvc)
+	return err
+}
+
 func (c *Cluster) createPod(members etcdutil.MemberSet, m *etcdutil.Member, state string, needRecovery bool, v *Volume) error {
+	cs := c.memberSpec(v)
 	var pod *v1.Pod
 	if state == "new" {
-		var backupURL *url.URL
+		var (
+			backupURL  *url.URL
+			authSecret string
+		)
 		if needRecovery {
-			serviceAddr := k8sutil.BackupServiceAddr(c.cluster.Name)
-			backupURL = backupapi.NewBackupURL("http", serviceAddr, c.cluster.Spec.Version, -1)
+			serviceAddr, scheme := k8sutil.BackupServiceAddr(c.cluster.Name), "http"
+			if b := c.cluster.Spec.Backup; b != nil && b.API != nil {
+				scheme, authSecret = "https", k8sutil.BackupAPIAuthSecretName(c.cluster.Name)
+			}
+			if t, ok := c.restoreTarget(); ok {
+				backupURL = backupapi.NewBackupURLAt(scheme, serviceAddr, c.cluster.Spec.Version, t)
+			} else {
+				backupURL = backupapi.NewBackupURL(scheme, serviceAddr, c.cluster.Spec.Version, -1)
+			}
 		}
-		pod = k8sutil.NewSeedMemberPod(c.cluster.Name, members, m, c.cluster.Spec, c.cluster.AsOwner(), backupURL)
+		pod = k8sutil.NewSeedMemberPod(c.cluster.Name, members, m, cs, c.cluster.AsOwner(), backupURL, authSecret)
 	} else {
-		pod = k8sutil.NewEtcdPod(m, members.PeerURLPairs(), c.cluster.Name, state, "", c.cluster.Spec, c.cluster.AsOwner())
+		pod = k8sutil.NewEtcdPod(m, members.PeerURLPairs(), c.cluster.Name, state, "", cs, c.cluster.AsOwner())
 	}
 	if c.IsPodPVEnabled() {
 		k8sutil.AddEtcdVolumeToPod(pod, m, v.Name)
+		if cs.Pod.WAL != nil {
+			k8sutil.AddEtcdWALVolumeToPod(pod, v.WALName)
+		}
 	} else {
 		k8sutil.AddEtcdVolumeToPod(pod, m, "")
 	}
@@ -560,6 +626,19 @@ func (c *Cluster) createPod(members etcdutil.MemberSet, m *etcdutil.Member, stat
 	return err
 }
 
+// memberSpec returns the cluster spec the pod of a member on the volume v is created with.
+// A volume created before the WAL was given its own volume has no WAL volume:
+// its WAL is still in the data directory, so the member runs without --wal-dir until it is replaced.
+func (c *Cluster) memberSpec(v *Volume) api.ClusterSpec {
+	cs := c.cluster.Spec
+	if c.isWALEnabled() && v != nil && len(v.WALName) == 0 {
+		pod := *cs.Pod
+		pod.WAL = nil
+		cs.Pod = &pod
+	}
+	return cs
+}
+
 func (c *Cluster) removePod(name string) error {
 	ns := c.cluster.Namespace
 	opts := metav1.NewDeleteOptions(podTerminationGracePeriod)
@@ -579,6 +658,19 @@ func (c *Cluster) removePod(name string) error {
 	return nil
 }
 
+// removeVolume deletes the PVCs backing the volume and drops it from the volume set.
+func (c *Cluster) removeVolume(name string) error {
+	v := c.volumes[name]
+	c.volumes.Remove(name)
+	if err := c.removePVC(name); err != nil {
+		return err
+	}
+	if v != nil && len(v.WALName) != 0 {
+		return c.removePVC(v.WALName)
+	}
+	return nil
+}
+
 func (c *Cluster) removePVC(name string) error {
 	ns := c.cluster.Namespace
 	err := c.config.KubeCli.Core().PersistentVolumeClaims(ns).Delete(name, nil)
@@ -56,3 +56,34 @@ func TestUpdateEventUpdateLocalClusterObj(t *testing.T) {
 		t.Errorf("expect version=%s, get=%s", newVersion, c.cluster.ResourceVersion)
 	}
 }
+
+func TestMemberSpecWithoutWALVolume(t *testing.T) {
+	c := &Cluster{
+		cluster: &api.EtcdCluster{
+			Spec: api.ClusterSpec{
+				Pod: &api.PodPolicy{
+					PV:  &api.PVSource{VolumeSizeInMB: 512},
+					WAL: &api.PVSource{VolumeSizeInMB: 512},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		v   *Volume
+		wal bool
+	}{
+		{&Volume{Name: "test-0000-pvc", WALName: "test-0000-wal-pvc"}, true},
+		// the volume was created before the WAL was enabled on the cluster.
+		{&Volume{Name: "test-0001-pvc"}, false},
+	}
+	for i, tt := range tests {
+		cs := c.memberSpec(tt.v)
+		if wal := cs.Pod.WAL != nil; wal != tt.wal {
+			t.Errorf("#%d: WAL enabled = %v, want %v", i, wal, tt.wal)
+		}
+	}
+	if c.cluster.Spec.Pod.WAL == nil {
+		t.Error("the spec of the cluster must not be modified")
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 	"github.com/coreos/etcd/etcdserver/etcdserverpb"
 	"github.com/pkg/errors"
 
@@ -57,9 +58,9 @@ func (c *Cluster) updateMembers(known etcdutil.MemberSet) error {
 		}
 
 		if c.IsPodPVEnabled() {
-			volumeName := known[m.Name].Volume
-			if c.volumes[volumeName] != nil {
-				c.linkVolumeToMember(c.volumes[volumeName], newMember)
+			// A dead member has no running pod, so its volume is not known here.
+			if km, ok := known[name]; ok && c.volumes[km.Volume] != nil {
+				c.linkVolumeToMember(c.volumes[km.Volume], newMember)
 			}
 		}
 		c.members[name] = newMember
@@ -81,10 +82,7 @@ func podsToMemberSet(pods []*v1.Pod, sc bool) etcdutil.MemberSet {
 	members := etcdutil.MemberSet{}
 	for _, pod := range pods {
 		m := &etcdutil.Member{Name: pod.Name, Namespace: pod.Namespace, SecureClient: sc}
-		if len(pod.Spec.Volumes) > 1 && pod.Spec.Volumes[0].VolumeSource.PersistentVolumeClaim != nil {
-			pvc := pod.Spec.Volumes[0].VolumeSource.PersistentVolumeClaim
-			m.Volume = pvc.ClaimName
-		}
+		m.Volume = k8sutil.GetEtcdVolumeClaimName(pod)
 		members.Add(m)
 	}
 	return members
@@ -0,0 +1,57 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+)
+
+// isMigratingToPV tells whether PV has been enabled on a cluster that still has members on emptyDir.
+func (c *Cluster) isMigratingToPV() bool {
+	if !c.IsPodPVEnabled() || c.cluster.Spec.SelfHosted != nil {
+		return false
+	}
+	return pickOneMemberOnEmptyDir(c.members) != nil
+}
+
+// migrateOneMemberToPV adds a PV backed member to the cluster. The next reconcile finds the
+// cluster above its desired size and removes a member on emptyDir, see removeOneMember.
+// Adding before removing keeps the failure tolerance of the cluster during the migration,
+// and makes the migration possible for a single member cluster.
+func (c *Cluster) migrateOneMemberToPV() error {
+	var migrated int
+	for _, m := range c.members {
+		if len(m.Volume) != 0 {
+			migrated++
+		}
+	}
+	c.status.SetMigratingCondition(migrated, c.cluster.Spec.Size)
+
+	if len(c.status.Members.Unready) != 0 {
+		c.logger.Infof("waiting for unready members (%v) before migrating to persistent volumes", c.status.Members.Unready)
+		return nil
+	}
+	c.logger.Infof("migrating to persistent volumes: %d of %d members migrated", migrated, c.cluster.Spec.Size)
+	return c.addMember()
+}
+
+func pickOneMemberOnEmptyDir(ms etcdutil.MemberSet) *etcdutil.Member {
+	for _, m := range ms {
+		if len(m.Volume) == 0 {
+			return m
+		}
+	}
+	return nil
+}
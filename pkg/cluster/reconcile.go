@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/util/constants"
@@ -27,11 +28,22 @@ import (
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/etcdserver/api/v3rpc/rpctypes"
 	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
+// maxDeadMemberRestarts is the number of times a dead member is restarted in place
+// on its PVC before its data is considered unusable and the member is replaced.
+const maxDeadMemberRestarts = 3
+
+// minMemberUptime is how long a restarted member has to stay ready for its restarts to be forgotten,
+// so that a member crashing shortly after it starts is eventually replaced.
+const minMemberUptime = 5 * time.Minute
+
 // reconcile reconciles cluster current state to desired state specified by spec.
 // - it tries to reconcile the cluster to desired size.
 // - if the cluster needs for upgrade, it tries to upgrade old member one by one.
+// - if PV is enabled on a cluster running on emptyDir, it replaces members one by one with PV backed ones.
+// - if the data volumes are expanded, it restarts members one by one to apply the new backend quota.
 func (c *Cluster) reconcile(pods []*v1.Pod) error {
 	c.logger.Infoln("Start reconciling")
 	defer c.logger.Infoln("Finish reconciling")
@@ -42,6 +54,9 @@ func (c *Cluster) reconcile(pods []*v1.Pod) error {
 
 	sp := c.cluster.Spec
 	running := podsToMemberSet(pods, c.isSecureClient())
+	for _, name := range upMembers(pods, time.Now()) {
+		delete(c.memberRestarts, name)
+	}
 	if !running.IsEqual(c.members) || c.members.Size() != sp.Size {
 		return c.reconcileMembers(running)
 	}
@@ -55,6 +70,18 @@ func (c *Cluster) reconcile(pods []*v1.Pod) error {
 	}
 	c.status.ClearCondition(api.ClusterConditionUpgrading)
 
+	if c.isMigratingToPV() {
+		return c.migrateOneMemberToPV()
+	}
+	c.status.ClearCondition(api.ClusterConditionMigrating)
+
+	if c.IsPodPVEnabled() {
+		c.expandVolumes()
+		if pod := pickOneMemberWithStaleQuota(pods, c.volumes, sp); pod != nil {
+			return c.restartMemberForQuota(pod)
+		}
+	}
+
 	c.status.SetVersion(sp.Version)
 	c.status.SetReadyCondition()
 
@@ -68,8 +95,9 @@ func (c *Cluster) reconcile(pods []*v1.Pod) error {
 // 1. Remove all pods from running set that does not belong to member set.
 // 2. L consist of remaining pods of runnings
 // 3. If L = members, the current state matches the membership state. END.
-// 4. If len(L) < len(members)/2 + 1, quorum lost. Go to recovery process.
-// 5. Add one missing member. END.
+// 4. If PV is enabled and a dead member still has a usable volume, restart it in place. END.
+// 5. If len(L) < len(members)/2 + 1, quorum lost. Go to recovery process.
+// 6. Remove one dead member. END.
 func (c *Cluster) reconcileMembers(running etcdutil.MemberSet) error {
 	c.logger.Infof("running members: %s", running)
 	c.logger.Infof("cluster membership: %v", c.members)
@@ -93,6 +121,11 @@ func (c *Cluster) reconcileMembers(running etcdutil.MemberSet) error {
 		return c.resize()
 	}
 	c.logger.Infof("running size :%v, member size :%v, volume size :%v", L.Size(), c.members.Size(), c.volumes.Size())
+	if c.IsPodPVEnabled() {
+		if m := pickDeadMemberToRestart(c.members.Diff(L), c.volumes, c.memberRestarts); m != nil {
+			return c.restartDeadMember(m)
+		}
+	}
 	// Case quoram is lost.
 	if L.Size() < c.members.Size()/2+1 {
 		//We assume PVCs are still there. So mark PVC available.
@@ -145,6 +178,10 @@ func (c *Cluster) resize() error {
 func (c *Cluster) addOneMember() error {
 	c.status.SetScalingUpCondition(c.members.Size(), c.cluster.Spec.Size)
 
+	return c.addMember()
+}
+
+func (c *Cluster) addMember() error {
 	cfg := clientv3.Config{
 		Endpoints:   c.members.ClientURLs(),
 		DialTimeout: constants.DefaultDialTimeout,
@@ -191,9 +228,22 @@ func (c *Cluster) addOneMember() error {
 }
 
 func (c *Cluster) removeOneMember() error {
+	if c.IsPodPVEnabled() {
+		// Members on emptyDir go first. This completes the replacement of a member during
+		// the migration to PV, which adds the PV backed member before removing the old one.
+		if m := pickOneMemberOnEmptyDir(c.members); m != nil {
+			c.logger.Infof("removing member (%s) that is not on a persistent volume", m.Name)
+			return c.removeMember(m, false)
+		}
+	}
+
 	c.status.SetScalingDownCondition(c.members.Size(), c.cluster.Spec.Size)
 
-	return c.removeMember(c.members.PickOne(), true)
+	m, err := c.pickOneMemberToRemove()
+	if err != nil {
+		return fmt.Errorf("fail to pick member to remove: %v", err)
+	}
+	return c.removeMember(m, true)
 }
 
 func (c *Cluster) removeDeadMember(toRemove *etcdutil.Member) error {
@@ -226,7 +276,74 @@ func (c *Cluster) removeDeadMember(toRemove *etcdutil.Member) error {
 		c.logger.Errorf("failed to create replacing dead member event: %v", err)
 	}
 
-	return c.removeMember(toRemove, false)
+	// The member could not come back on its volume after repeated restarts.
+	// Its data is unusable, so the volume must not be handed to the replacement.
+	deleteVolume := c.memberRestarts[toRemove.Name] >= maxDeadMemberRestarts
+	if v := c.volumes[toRemove.Volume]; v != nil && v.IsCorrupt {
+		deleteVolume = true
+	}
+	return c.removeMember(toRemove, deleteVolume)
+}
+
+// restartDeadMember recreates the pod of a dead member on its existing PVC.
+// The member keeps its name and ID, so etcd resumes from the data on the volume
+// instead of a new member being added and receiving a full snapshot.
+func (c *Cluster) restartDeadMember(m *etcdutil.Member) error {
+	if c.isWALEnabled() && len(c.volumes[m.Volume].WALName) == 0 {
+		// The member predates the WAL volume, e.g. the WAL was enabled on the running cluster.
+		c.logger.Infof("dead member %q has no WAL volume, restarting it with the WAL in its data directory", m.Name)
+	}
+
+	c.logger.Infof("restarting dead member %q on volume %q", m.Name, m.Volume)
+
+	// Pods use RestartPolicyNever, so the pod of the dead member is still around in a terminal phase.
+	if err := c.removePod(m.Name); err != nil {
+		return err
+	}
+	err := c.createPod(c.members, m, "existing", false, c.volumes[m.Volume])
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			c.logger.Infof("waiting for the old pod of member (%s) to be deleted", m.Name)
+			return nil
+		}
+		return fmt.Errorf("fail to restart member's pod (%s): %v", m.Name, err)
+	}
+	c.memberRestarts[m.Name]++
+
+	_, err = c.eventsCli.Create(k8sutil.RestartingDeadMemberEvent(m.Name, c.cluster))
+	if err != nil {
+		c.logger.Errorf("failed to create restarting dead member event: %v", err)
+	}
+	return nil
+}
+
+// upMembers returns the names of the members whose pods have been ready for at least minMemberUptime.
+func upMembers(pods []*v1.Pod, now time.Time) []string {
+	var names []string
+	for _, pod := range pods {
+		if since, ok := k8sutil.PodReadySince(pod); ok && now.Sub(since) >= minMemberUptime {
+			names = append(names, pod.Name)
+		}
+	}
+	return names
+}
+
+// pickDeadMemberToRestart returns a dead member whose volume is still usable,
+// or nil if every dead member has to be replaced.
+// A volume is considered unusable once it is marked corrupt or its member has
+// exhausted maxDeadMemberRestarts without staying ready for minMemberUptime.
+func pickDeadMemberToRestart(dead etcdutil.MemberSet, volumes VolumeSet, restarts map[string]int) *etcdutil.Member {
+	for _, m := range dead {
+		v := volumes[m.Volume]
+		if v == nil || v.IsCorrupt {
+			continue
+		}
+		if restarts[m.Name] >= maxDeadMemberRestarts {
+			continue
+		}
+		return m
+	}
+	return nil
 }
 
 func (c *Cluster) removeMember(toRemove *etcdutil.Member, deleteVolume bool) error {
@@ -241,6 +358,7 @@ func (c *Cluster) removeMember(toRemove *etcdutil.Member, deleteVolume bool) err
 		}
 	}
 	c.members.Remove(toRemove.Name)
+	delete(c.memberRestarts, toRemove.Name)
 	_, err = c.eventsCli.Create(k8sutil.MemberRemoveEvent(toRemove.Name, c.cluster))
 	if err != nil {
 		c.logger.Errorf("failed to create remove member event: %v", err)
@@ -250,13 +368,13 @@ func (c *Cluster) removeMember(toRemove *etcdutil.Member, deleteVolume bool) err
 	}
 
 	//RETHINK removeVolume
-	if c.IsPodPVEnabled() {
+	if c.IsPodPVEnabled() && len(toRemove.Volume) != 0 {
 		if deleteVolume {
-			c.volumes.Remove(toRemove.Volume)
-			c.removePVC(toRemove.Volume)
+			if err := c.removeVolume(toRemove.Volume); err != nil {
+				c.logger.Errorf("fail to remove volume (%v) of member (%v): %v", toRemove.Volume, toRemove.Name, err)
+			}
 		} else {
-			c.volumes[toRemove.Volume].IsAttached = false
-			c.volumes[toRemove.Volume].Member = ""
+			c.unlinkVolumeFromMember(c.volumes[toRemove.Volume], toRemove)
 		}
 	}
 
@@ -283,8 +401,21 @@ func (c *Cluster) disasterRecovery(left etcdutil.MemberSet) error {
 		}
 	}
 	exist := false
+	// snap is the snapshot the cluster is restored to if it is restored to the target of its restore policy.
+	var snap *api.RestoredSnapshot
 	if backupNow {
 		c.logger.Info("made a latest backup")
+	} else if t, ok := c.restoreTarget(); ok {
+		name, rev, err := c.bm.findRestoreBackup(c.cluster.Spec.Version, t)
+		if err != nil {
+			c.logger.Errorln(err)
+			return err
+		}
+		if len(name) == 0 {
+			return newFatalError(fmt.Sprintf("no backup at or before the restore target (targetRevision: %d, targetTime: %q)", t.TargetRevision, t.TargetTime))
+		}
+		exist = true
+		snap = &api.RestoredSnapshot{Backup: name, Revision: rev}
 	} else if c.cluster.Spec.Backup != nil {
 		// We don't return error if backupnow failed. Instead, we ask if there is previous backup.
 		// If so, we can still continue. Otherwise, it's fatal error.
@@ -301,18 +432,24 @@ func (c *Cluster) disasterRecovery(left etcdutil.MemberSet) error {
 		}
 	}
 	for _, v := range c.volumes {
-		err = c.removePVC(v.Name)
+		err = c.removeVolume(v.Name)
 		if err != nil {
 			return err
 		}
-		c.volumes.Remove(v.Name)
 	}
 	if !exist {
 		c.logger.Warnf("no backup exist for disaster recovery")
 		c.logger.Warnf("Recovering by restarting cluster.")
 		return c.bootstrap()
 	}
-	return c.recover()
+	if err := c.recover(); err != nil {
+		return err
+	}
+	if snap != nil {
+		c.status.RestoredSnapshot = snap
+		c.logger.Infof("restored cluster from backup (%s) to revision %d", snap.Backup, snap.Revision)
+	}
+	return nil
 }
 
 func needUpgrade(pods []*v1.Pod, cs api.ClusterSpec) bool {
@@ -0,0 +1,119 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPickDeadMemberToRestart(t *testing.T) {
+	tests := []struct {
+		volume   *Volume
+		restarts int
+		restart  bool
+	}{{
+		volume:  &Volume{Name: "test-0000-pvc"},
+		restart: true,
+	}, {
+		volume:   &Volume{Name: "test-0000-pvc"},
+		restarts: maxDeadMemberRestarts - 1,
+		restart:  true,
+	}, {
+		volume:   &Volume{Name: "test-0000-pvc"},
+		restarts: maxDeadMemberRestarts,
+		restart:  false,
+	}, {
+		volume:  &Volume{Name: "test-0000-pvc", IsCorrupt: true},
+		restart: false,
+	}, {
+		// volume of the member is not known, e.g. the PVC has been deleted.
+		volume:  nil,
+		restart: false,
+	}}
+
+	for i, tt := range tests {
+		m := &etcdutil.Member{Name: "test-0000", Volume: "test-0000-pvc"}
+		volumes := NewVolumeSet()
+		if tt.volume != nil {
+			volumes.Add(tt.volume)
+		}
+		restarts := map[string]int{m.Name: tt.restarts}
+
+		picked := pickDeadMemberToRestart(etcdutil.NewMemberSet(m), volumes, restarts)
+		if (picked != nil) != tt.restart {
+			t.Errorf("#%d: restart want=%v, get=%v", i, tt.restart, picked != nil)
+		}
+	}
+}
+
+func TestUpMembers(t *testing.T) {
+	now := time.Now()
+	newPod := func(name string, ready v1.ConditionStatus, since time.Duration) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				Conditions: []v1.PodCondition{{
+					Type:               v1.PodReady,
+					Status:             ready,
+					LastTransitionTime: metav1.NewTime(now.Add(-since)),
+				}},
+			},
+		}
+	}
+	pods := []*v1.Pod{
+		newPod("test-0000", v1.ConditionTrue, minMemberUptime),
+		// running, but crashes shortly after it starts.
+		newPod("test-0001", v1.ConditionTrue, time.Second),
+		newPod("test-0002", v1.ConditionFalse, minMemberUptime),
+		{ObjectMeta: metav1.ObjectMeta{Name: "test-0003"}, Status: v1.PodStatus{Phase: v1.PodRunning}},
+	}
+	want := []string{"test-0000"}
+	if got := upMembers(pods, now); !reflect.DeepEqual(got, want) {
+		t.Errorf("up members want=%v, get=%v", want, got)
+	}
+}
+
+func TestPickOneMemberOnEmptyDir(t *testing.T) {
+	tests := []struct {
+		volumes []string
+		picked  bool
+	}{
+		{[]string{"test-0000-pvc", "test-0001-pvc"}, false},
+		{[]string{"test-0000-pvc", ""}, true},
+		{[]string{"", ""}, true},
+	}
+
+	for i, tt := range tests {
+		ms := etcdutil.NewMemberSet()
+		for j, v := range tt.volumes {
+			ms.Add(&etcdutil.Member{Name: etcdutil.CreateMemberName("test", j), Volume: v})
+		}
+		m := pickOneMemberOnEmptyDir(ms)
+		if (m != nil) != tt.picked {
+			t.Errorf("#%d: picked want=%v, get=%v", i, tt.picked, m != nil)
+		}
+		if m != nil && len(m.Volume) != 0 {
+			t.Errorf("#%d: picked member (%s) on volume %s", i, m.Name, m.Volume)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// scaleDownCandidate is a member that could be removed when the cluster scales down,
+// along with what the scale down rules need to know about it.
+type scaleDownCandidate struct {
+	member *etcdutil.Member
+	ready  bool
+	leader bool
+	// node is the node the member's pod runs on, and zone is the failure domain of that node.
+	node string
+	zone string
+	// zoneMembers is the number of members in the zone, the leader included.
+	zoneMembers int
+	// nodeLoad is the number of pods running on the node.
+	nodeLoad int
+	// counter is the member counter in the member name. Newer members have higher counters.
+	counter int
+}
+
+// scaleDownRule narrows down the candidates for removal.
+// prefer returns the candidates the rule would rather remove. Returning all or none of
+// the candidates means the rule has no preference among them.
+type scaleDownRule struct {
+	reason string
+	prefer func(cands []*scaleDownCandidate) []*scaleDownCandidate
+}
+
+// defaultScaleDownRules are applied in order until a single candidate is left.
+var defaultScaleDownRules = []scaleDownRule{
+	{reason: "member is unready", prefer: preferUnready},
+	{reason: "member breaks topology spread", prefer: preferMostPopulatedZone},
+	{reason: "member is on the most loaded node", prefer: preferMostLoadedNode},
+	{reason: "member is the newest", prefer: preferNewest},
+}
+
+var errNoScaleDownCandidate = errors.New("no member can be removed without removing the leader")
+
+// pickScaleDownVictim picks the member to remove. The leader is never picked.
+// It returns the reasons that led to the decision.
+func pickScaleDownVictim(cands []*scaleDownCandidate, rules []scaleDownRule) (*scaleDownCandidate, []string, error) {
+	// The leader stays in its zone, so it counts towards the spread.
+	perZone := map[string]int{}
+	for _, c := range cands {
+		if len(c.zone) != 0 {
+			perZone[c.zone]++
+		}
+	}
+	for _, c := range cands {
+		c.zoneMembers = perZone[c.zone]
+	}
+
+	var left []*scaleDownCandidate
+	for _, c := range cands {
+		if !c.leader {
+			left = append(left, c)
+		}
+	}
+	if len(left) == 0 {
+		return nil, nil, errNoScaleDownCandidate
+	}
+
+	var reasons []string
+	for _, r := range rules {
+		if len(left) == 1 {
+			break
+		}
+		preferred := r.prefer(left)
+		if len(preferred) == 0 || len(preferred) == len(left) {
+			continue
+		}
+		left = preferred
+		reasons = append(reasons, r.reason)
+	}
+	return left[0], reasons, nil
+}
+
+func preferUnready(cands []*scaleDownCandidate) []*scaleDownCandidate {
+	var res []*scaleDownCandidate
+	for _, c := range cands {
+		if !c.ready {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// preferMostPopulatedZone prefers members in the zone hosting the most members,
+// since removing one of them evens out the spread across zones.
+func preferMostPopulatedZone(cands []*scaleDownCandidate) []*scaleDownCandidate {
+	max := 0
+	for _, c := range cands {
+		if len(c.zone) != 0 && c.zoneMembers > max {
+			max = c.zoneMembers
+		}
+	}
+	var res []*scaleDownCandidate
+	for _, c := range cands {
+		if len(c.zone) != 0 && c.zoneMembers == max {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func preferMostLoadedNode(cands []*scaleDownCandidate) []*scaleDownCandidate {
+	max := 0
+	for _, c := range cands {
+		if c.nodeLoad > max {
+			max = c.nodeLoad
+		}
+	}
+	var res []*scaleDownCandidate
+	for _, c := range cands {
+		if c.nodeLoad == max {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func preferNewest(cands []*scaleDownCandidate) []*scaleDownCandidate {
+	newest := cands[0]
+	for _, c := range cands[1:] {
+		if c.counter > newest.counter {
+			newest = c
+		}
+	}
+	return []*scaleDownCandidate{newest}
+}
+
+// pickOneMemberToRemove picks the member to remove when scaling down.
+func (c *Cluster) pickOneMemberToRemove() (*etcdutil.Member, error) {
+	cands, err := c.scaleDownCandidates()
+	if err != nil {
+		return nil, err
+	}
+	rules := c.scaleDownRules
+	if rules == nil {
+		rules = defaultScaleDownRules
+	}
+	victim, reasons, err := pickScaleDownVictim(cands, rules)
+	if err != nil {
+		return nil, err
+	}
+	if len(reasons) == 0 {
+		reasons = []string{"no preference among members"}
+	}
+	c.logger.Infof("picked member (%s) to remove: %v", victim.member.Name, reasons)
+	return victim.member, nil
+}
+
+func (c *Cluster) scaleDownCandidates() ([]*scaleDownCandidate, error) {
+	leaderID, err := etcdutil.GetLeaderID(c.members.ClientURLs(), c.tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the leader: %v", err)
+	}
+	ready := map[string]bool{}
+	for _, name := range c.status.Members.Ready {
+		ready[name] = true
+	}
+
+	podList, err := c.config.KubeCli.CoreV1().Pods(c.cluster.Namespace).List(k8sutil.ClusterListOpt(c.cluster.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	pods := map[string]*v1.Pod{}
+	for i := range podList.Items {
+		pods[podList.Items[i].Name] = &podList.Items[i]
+	}
+
+	nodeLoad := map[string]int{}
+	nodeZone := map[string]string{}
+	var cands []*scaleDownCandidate
+	for _, m := range c.members {
+		ct, err := etcdutil.GetCounterFromMemberName(m.Name)
+		if err != nil {
+			return nil, err
+		}
+		cand := &scaleDownCandidate{
+			member:  m,
+			ready:   ready[m.Name],
+			leader:  m.ID == leaderID,
+			counter: ct,
+		}
+		if pod := pods[m.Name]; pod != nil && len(pod.Spec.NodeName) != 0 {
+			cand.node = pod.Spec.NodeName
+			if _, ok := nodeLoad[cand.node]; !ok {
+				nodeLoad[cand.node], nodeZone[cand.node] = c.nodeLoadAndZone(cand.node)
+			}
+			cand.nodeLoad, cand.zone = nodeLoad[cand.node], nodeZone[cand.node]
+		}
+		cands = append(cands, cand)
+	}
+	return cands, nil
+}
+
+// nodeLoadAndZone returns the number of pods on the node and its zone.
+// Failures, e.g. when the operator is not allowed to list the pods of all namespaces or to get nodes,
+// are logged and leave the rules depending on them without preference.
+func (c *Cluster) nodeLoadAndZone(name string) (int, string) {
+	var load int
+	opts := metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String()}
+	pods, err := c.config.KubeCli.CoreV1().Pods(metav1.NamespaceAll).List(opts)
+	if err != nil {
+		c.logger.Warningf("failed to list pods on node (%s), scale down ignores node load: %v", name, err)
+	} else {
+		for _, p := range pods.Items {
+			if p.Status.Phase != v1.PodSucceeded && p.Status.Phase != v1.PodFailed {
+				load++
+			}
+		}
+	}
+
+	node, err := c.config.KubeCli.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+	if err != nil {
+		c.logger.Warningf("failed to get node (%s), scale down ignores topology spread: %v", name, err)
+		return load, ""
+	}
+	return load, node.Labels[k8sutil.ZoneLabel]
+}
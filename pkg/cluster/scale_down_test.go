@@ -0,0 +1,121 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+)
+
+func newCandidate(counter int, ready, leader bool, zone string, nodeLoad int) *scaleDownCandidate {
+	return &scaleDownCandidate{
+		member:   &etcdutil.Member{Name: etcdutil.CreateMemberName("test", counter)},
+		ready:    ready,
+		leader:   leader,
+		zone:     zone,
+		nodeLoad: nodeLoad,
+		counter:  counter,
+	}
+}
+
+func TestPickScaleDownVictim(t *testing.T) {
+	tests := []struct {
+		cands   []*scaleDownCandidate
+		victim  string
+		reasons []string
+	}{{
+		// unready member goes first, even if it is the oldest one.
+		cands: []*scaleDownCandidate{
+			newCandidate(0, false, false, "a", 10),
+			newCandidate(1, true, false, "b", 20),
+			newCandidate(2, true, true, "c", 30),
+			newCandidate(3, true, false, "a", 40),
+		},
+		victim:  "test-0000",
+		reasons: []string{"member is unready"},
+	}, {
+		// the leader is never removed, even if it is unready.
+		cands: []*scaleDownCandidate{
+			newCandidate(0, true, false, "a", 10),
+			newCandidate(1, false, true, "b", 10),
+			newCandidate(2, true, false, "c", 10),
+		},
+		victim:  "test-0002",
+		reasons: []string{"member is the newest"},
+	}, {
+		// zone "a" hosts two members.
+		cands: []*scaleDownCandidate{
+			newCandidate(0, true, false, "a", 10),
+			newCandidate(1, true, true, "b", 10),
+			newCandidate(2, true, false, "a", 10),
+			newCandidate(3, true, false, "c", 10),
+		},
+		victim:  "test-0002",
+		reasons: []string{"member breaks topology spread", "member is the newest"},
+	}, {
+		// zone "b" hosts three members, the leader included.
+		cands: []*scaleDownCandidate{
+			newCandidate(0, true, true, "b", 10),
+			newCandidate(1, true, false, "b", 10),
+			newCandidate(2, true, false, "b", 10),
+			newCandidate(3, true, false, "a", 10),
+			newCandidate(4, true, false, "a", 10),
+		},
+		victim:  "test-0002",
+		reasons: []string{"member breaks topology spread", "member is the newest"},
+	}, {
+		cands: []*scaleDownCandidate{
+			newCandidate(0, true, false, "a", 10),
+			newCandidate(1, true, true, "a", 10),
+			newCandidate(2, true, false, "a", 50),
+			newCandidate(3, true, false, "a", 20),
+		},
+		victim:  "test-0002",
+		reasons: []string{"member is on the most loaded node"},
+	}, {
+		// no zone and node information.
+		cands: []*scaleDownCandidate{
+			newCandidate(3, true, false, "", 0),
+			newCandidate(1, true, true, "", 0),
+			newCandidate(5, true, false, "", 0),
+			newCandidate(4, true, false, "", 0),
+		},
+		victim:  "test-0005",
+		reasons: []string{"member is the newest"},
+	}}
+
+	for i, tt := range tests {
+		victim, reasons, err := pickScaleDownVictim(tt.cands, defaultScaleDownRules)
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if victim.member.Name != tt.victim {
+			t.Errorf("#%d: victim want=%s, get=%s", i, tt.victim, victim.member.Name)
+		}
+		if !reflect.DeepEqual(reasons, tt.reasons) {
+			t.Errorf("#%d: reasons want=%v, get=%v", i, tt.reasons, reasons)
+		}
+	}
+}
+
+func TestPickScaleDownVictimOnlyLeader(t *testing.T) {
+	_, _, err := pickScaleDownVictim([]*scaleDownCandidate{newCandidate(0, true, true, "", 0)}, defaultScaleDownRules)
+	if err != errNoScaleDownCandidate {
+		t.Errorf("expect error=%v, get=%v", errNoScaleDownCandidate, err)
+	}
+}
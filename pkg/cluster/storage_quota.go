@@ -0,0 +1,137 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// expandVolumes grows the data PVCs to the PV size in the spec.
+// PVCs cannot shrink, so a size smaller than the current one is ignored.
+// Failures are only logged since the storage class might not allow expansion.
+func (c *Cluster) expandVolumes() {
+	want := c.cluster.Spec.Pod.PV.VolumeSizeInMB
+	for _, v := range c.volumes {
+		if v.SizeInMB == 0 || v.SizeInMB >= want {
+			continue
+		}
+		if err := c.expandPVC(v.Name, want); err != nil {
+			c.logger.Warningf("failed to expand volume (%s) from %dMi to %dMi: %v", v.Name, v.SizeInMB, want, err)
+			continue
+		}
+		c.logger.Infof("expanded volume (%s) from %dMi to %dMi", v.Name, v.SizeInMB, want)
+		v.SizeInMB = want
+	}
+}
+
+func (c *Cluster) expandPVC(name string, sizeInMB int) error {
+	pvcs := c.config.KubeCli.CoreV1().PersistentVolumeClaims(c.cluster.Namespace)
+	pvc, err := pvcs.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	pvc.Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse(fmt.Sprintf("%dMi", sizeInMB))
+	_, err = pvcs.Update(pvc)
+	return err
+}
+
+// pickOneMemberWithStaleQuota returns a member whose backend quota differs from the one
+// derived from the spec, once its volume has been expanded to the size in the spec.
+// Members that were not started with an operator managed quota are left alone.
+func pickOneMemberWithStaleQuota(pods []*v1.Pod, volumes VolumeSet, cs api.ClusterSpec) *v1.Pod {
+	quota := k8sutil.QuotaBackendBytes(cs)
+	if quota == 0 {
+		return nil
+	}
+	want := strconv.FormatInt(quota, 10)
+	for _, pod := range pods {
+		cur := k8sutil.GetQuotaBackendBytes(pod)
+		if len(cur) == 0 || cur == want {
+			continue
+		}
+		v := volumes[k8sutil.GetEtcdVolumeClaimName(pod)]
+		if v == nil || v.SizeInMB < cs.Pod.PV.VolumeSizeInMB {
+			continue
+		}
+		return pod
+	}
+	return nil
+}
+
+// restartMemberForQuota deletes the pod of a member so that it is restarted in place
+// on its volume with the backend quota derived from the current spec.
+func (c *Cluster) restartMemberForQuota(pod *v1.Pod) error {
+	c.logger.Infof("restarting member %s to change backend quota from %s to %d bytes",
+		pod.Name, k8sutil.GetQuotaBackendBytes(pod), k8sutil.QuotaBackendBytes(c.cluster.Spec))
+	if err := c.removePod(pod.Name); err != nil {
+		return err
+	}
+	_, err := c.eventsCli.Create(k8sutil.VolumeExpandedEvent(pod.Name, c.cluster.Spec.Pod.PV.VolumeSizeInMB, c.cluster))
+	if err != nil {
+		c.logger.Errorf("failed to create volume expanded event: %v", err)
+	}
+	return nil
+}
+
+// updateStorageCondition sets the StorageNearlyFull condition if the database of any member
+// grows beyond the nearly full threshold of the backend quota, and clears it otherwise.
+func (c *Cluster) updateStorageCondition(members etcdutil.MemberSet) {
+	quota := k8sutil.QuotaBackendBytes(c.cluster.Spec)
+	if quota == 0 {
+		c.status.ClearCondition(api.ClusterConditionStorageNearlyFull)
+		return
+	}
+	percent := c.cluster.Spec.Pod.StorageQuota.NearlyFull()
+	threshold := quota * int64(percent) / 100
+
+	var full []string
+	for _, m := range members {
+		size, err := etcdutil.GetDBSize(m.ClientURL(), c.tlsConfig)
+		if err != nil {
+			c.logger.Warningf("failed to get database size of member (%s): %v", m.Name, err)
+			continue
+		}
+		if size < threshold {
+			delete(c.storageNearlyFull, m.Name)
+			continue
+		}
+		full = append(full, m.Name)
+		if c.storageNearlyFull[m.Name] {
+			continue
+		}
+		c.storageNearlyFull[m.Name] = true
+		_, err = c.eventsCli.Create(k8sutil.StorageNearlyFullEvent(m.Name, size, quota, c.cluster))
+		if err != nil {
+			c.logger.Errorf("failed to create storage nearly full event: %v", err)
+		}
+	}
+
+	if len(full) == 0 {
+		c.status.ClearCondition(api.ClusterConditionStorageNearlyFull)
+		return
+	}
+	sort.Strings(full)
+	c.status.SetStorageNearlyFullCondition(full, percent)
+}
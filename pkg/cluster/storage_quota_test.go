@@ -0,0 +1,109 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"strconv"
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuotaBackendBytes(t *testing.T) {
+	tests := []struct {
+		pod   *api.PodPolicy
+		quota int64
+	}{{
+		pod:   nil,
+		quota: 0,
+	}, {
+		pod:   &api.PodPolicy{PV: &api.PVSource{VolumeSizeInMB: 1024}},
+		quota: 768 * 1024 * 1024,
+	}, {
+		pod: &api.PodPolicy{
+			PV:           &api.PVSource{VolumeSizeInMB: 1024},
+			StorageQuota: &api.StorageQuotaPolicy{HeadroomPercent: 50},
+		},
+		quota: 512 * 1024 * 1024,
+	}, {
+		// capped at the largest quota recommended by etcd.
+		pod:   &api.PodPolicy{PV: &api.PVSource{VolumeSizeInMB: 100 * 1024}},
+		quota: 8 * 1024 * 1024 * 1024,
+	}, {
+		// quota set by the user through the environment.
+		pod: &api.PodPolicy{
+			PV:      &api.PVSource{VolumeSizeInMB: 1024},
+			EtcdEnv: []v1.EnvVar{{Name: "ETCD_QUOTA_BACKEND_BYTES", Value: "1000"}},
+		},
+		quota: 0,
+	}}
+
+	for i, tt := range tests {
+		quota := k8sutil.QuotaBackendBytes(api.ClusterSpec{Pod: tt.pod})
+		if quota != tt.quota {
+			t.Errorf("#%d: quota want=%d, get=%d", i, tt.quota, quota)
+		}
+	}
+}
+
+func TestPickOneMemberWithStaleQuota(t *testing.T) {
+	oldSpec := api.ClusterSpec{Pod: &api.PodPolicy{PV: &api.PVSource{VolumeSizeInMB: 1024}}}
+	newSpec := api.ClusterSpec{Pod: &api.PodPolicy{PV: &api.PVSource{VolumeSizeInMB: 2048}}}
+
+	tests := []struct {
+		// quota annotation of the pod; empty if the pod predates operator managed quotas.
+		quota      string
+		volumeSize int
+		stale      bool
+	}{{
+		quota:      strconv.FormatInt(k8sutil.QuotaBackendBytes(newSpec), 10),
+		volumeSize: 2048,
+		stale:      false,
+	}, {
+		quota:      strconv.FormatInt(k8sutil.QuotaBackendBytes(oldSpec), 10),
+		volumeSize: 2048,
+		stale:      true,
+	}, {
+		// volume expansion has not been accepted yet.
+		quota:      strconv.FormatInt(k8sutil.QuotaBackendBytes(oldSpec), 10),
+		volumeSize: 1024,
+		stale:      false,
+	}, {
+		quota:      "",
+		volumeSize: 2048,
+		stale:      false,
+	}}
+
+	for i, tt := range tests {
+		m := &etcdutil.Member{Name: "test-0000", Namespace: metav1.NamespaceDefault}
+		pod := k8sutil.NewEtcdPod(m, nil, "test", "existing", "", oldSpec, metav1.OwnerReference{})
+		k8sutil.AddEtcdVolumeToPod(pod, m, "test-0000-pvc")
+		pod.Annotations = map[string]string{}
+		if len(tt.quota) != 0 {
+			pod.Annotations["etcd.quota-backend-bytes"] = tt.quota
+		}
+		volumes := NewVolumeSet(&Volume{Name: "test-0000-pvc", SizeInMB: tt.volumeSize})
+
+		picked := pickOneMemberWithStaleQuota([]*v1.Pod{pod}, volumes, newSpec)
+		if (picked != nil) != tt.stale {
+			t.Errorf("#%d: stale want=%v, get=%v", i, tt.stale, picked != nil)
+		}
+	}
+}
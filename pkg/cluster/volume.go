@@ -15,6 +15,10 @@ type Volume struct {
 	Member     string
 	IsCorrupt  bool
 	IsAttached bool
+	// WALName is the name of the PVC holding the etcd WAL, if the WAL is on a separate volume.
+	WALName string
+	// SizeInMB is the storage requested by the data PVC.
+	SizeInMB int
 }
 
 func (v *Volume) etcdPVCName() string {
@@ -97,17 +101,36 @@ func createVolumeName(clusterName string, volume int) string {
 	return fmt.Sprintf("%s-%04d-pvc", clusterName, volume)
 }
 
+func isWALVolumeName(name string) bool {
+	return strings.HasSuffix(name, "-wal-pvc")
+}
+
+// pvcsToVolumeSet builds a volume per data PVC. WAL PVCs are attached to the volume of their data PVC.
 func pvcsToVolumeSet(pvcs []*v1.PersistentVolumeClaim) VolumeSet {
 	volumes := VolumeSet{}
 
 	for _, pvc := range pvcs {
+		if isWALVolumeName(pvc.Name) {
+			continue
+		}
 		v := &Volume{
 			Name:       pvc.Name,
 			Namespace:  pvc.Namespace,
 			IsAttached: false,
 		}
+		if q, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]; ok {
+			v.SizeInMB = int(q.Value() / (1024 * 1024))
+		}
 		volumes.Add(v)
 	}
+	for _, pvc := range pvcs {
+		if !isWALVolumeName(pvc.Name) {
+			continue
+		}
+		if v := volumes[strings.TrimSuffix(pvc.Name, "-wal-pvc")+"-pvc"]; v != nil {
+			v.WALName = pvc.Name
+		}
+	}
 	return volumes
 }
 
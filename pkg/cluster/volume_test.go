@@ -0,0 +1,60 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPVCsToVolumeSetPairsWAL(t *testing.T) {
+	newPVC := func(name string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	pvcs := []*v1.PersistentVolumeClaim{
+		newPVC("test-0001-wal-pvc"),
+		newPVC("test-0000-pvc"),
+		newPVC("test-0001-pvc"),
+	}
+
+	vs := pvcsToVolumeSet(pvcs)
+	if vs.Size() != 2 {
+		t.Fatalf("expect 2 volumes, get=%v", vs)
+	}
+	tests := []struct {
+		name    string
+		walName string
+	}{
+		{"test-0000-pvc", ""},
+		{"test-0001-pvc", "test-0001-wal-pvc"},
+	}
+	for i, tt := range tests {
+		v := vs[tt.name]
+		if v == nil {
+			t.Errorf("#%d: volume %s not found", i, tt.name)
+			continue
+		}
+		if v.WALName != tt.walName {
+			t.Errorf("#%d: WAL name want=%s, get=%s", i, tt.walName, v.WALName)
+		}
+	}
+	if k8sutil.WALClaimName("test-0001-pvc") != "test-0001-wal-pvc" {
+		t.Errorf("unexpected WAL claim name: %s", k8sutil.WALClaimName("test-0001-pvc"))
+	}
+}
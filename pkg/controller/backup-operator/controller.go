@@ -16,6 +16,7 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
@@ -41,18 +42,35 @@ func (b *Backup) run(ctx context.Context) {
 		DeleteFunc: b.onDelete,
 	}, cache.Indexers{})
 
+	scheduleSource := cache.NewListWatchFromClient(
+		b.backupCRCli.EtcdV1beta2().RESTClient(),
+		api.EtcdBackupScheduleResourcePlural,
+		b.namespace,
+		fields.Everything(),
+	)
+
+	b.scheduleQueue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "etcd-backup-schedule")
+	b.scheduleIndexer, b.scheduleInformer = cache.NewIndexerInformer(scheduleSource, &api.EtcdBackupSchedule{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc:    b.onAddSchedule,
+		UpdateFunc: b.onUpdateSchedule,
+		DeleteFunc: b.onDeleteSchedule,
+	}, cache.Indexers{})
+
 	defer b.queue.ShutDown()
+	defer b.scheduleQueue.ShutDown()
 
 	b.logger.Info("starting backup controller")
 	go b.informer.Run(ctx.Done())
+	go b.scheduleInformer.Run(ctx.Done())
 
-	if !cache.WaitForCacheSync(ctx.Done(), b.informer.HasSynced) {
+	if !cache.WaitForCacheSync(ctx.Done(), b.informer.HasSynced, b.scheduleInformer.HasSynced) {
 		return
 	}
 
 	const numWorkers = 1
 	for i := 0; i < numWorkers; i++ {
 		go wait.Until(b.runWorker, time.Second, ctx.Done())
+		go wait.Until(b.runScheduleWorker, time.Second, ctx.Done())
 	}
 
 	<-ctx.Done()
@@ -73,12 +91,52 @@ func (b *Backup) onUpdate(oldObj, newObj interface{}) {
 		panic(err)
 	}
 	b.queue.Add(key)
+	// The status of the schedule reports the backups made from it.
+	eb := newObj.(*api.EtcdBackup)
+	if name, ok := eb.Labels[scheduleLabel]; ok {
+		b.scheduleQueue.Add(eb.Namespace + "/" + name)
+	}
 }
 
 func (b *Backup) onDelete(obj interface{}) {
+	// The backup of a deleted EtcdBackup is deleted by its finalizer, before the EtcdBackup is released.
+	// Only the schedule of the EtcdBackup remains to be updated.
+	eb, ok := obj.(*api.EtcdBackup)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			panic(fmt.Sprintf("unknown object from EtcdBackup delete event: %#v", obj))
+		}
+		eb, ok = tombstone.Obj.(*api.EtcdBackup)
+		if !ok {
+			panic(fmt.Sprintf("Tombstone contained object that is not an EtcdBackup: %#v", obj))
+		}
+	}
+	if name, ok := eb.Labels[scheduleLabel]; ok {
+		b.scheduleQueue.Add(eb.Namespace + "/" + name)
+	}
+}
+
+func (b *Backup) onAddSchedule(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		panic(err)
+	}
+	b.scheduleQueue.Add(key)
+}
+
+func (b *Backup) onUpdateSchedule(oldObj, newObj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(newObj)
+	if err != nil {
+		panic(err)
+	}
+	b.scheduleQueue.Add(key)
+}
+
+func (b *Backup) onDeleteSchedule(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
 		panic(err)
 	}
-	b.queue.Add(key)
+	b.scheduleQueue.Add(key)
 }
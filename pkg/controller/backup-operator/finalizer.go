@@ -0,0 +1,94 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"os"
+	"path"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// backupFinalizer is set on the EtcdBackup resources with the Delete deletion policy.
+// It holds a deleted EtcdBackup until the backup it saved is deleted from its storage.
+const backupFinalizer = "etcd.database.coreos.com/delete-backup"
+
+// finalizeRetryInterval is the interval between attempts to delete the backup of a deleted EtcdBackup.
+const finalizeRetryInterval = time.Minute
+
+func hasBackupFinalizer(eb *api.EtcdBackup) bool {
+	for _, f := range eb.Finalizers {
+		if f == backupFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// addBackupFinalizer sets the finalizer of eb.
+func (b *Backup) addBackupFinalizer(eb *api.EtcdBackup) error {
+	eb = eb.DeepCopy()
+	eb.Finalizers = append(eb.Finalizers, backupFinalizer)
+	_, err := b.backupCRCli.EtcdV1beta2().EtcdBackups(eb.Namespace).Update(eb)
+	return err
+}
+
+// finalizeBackup deletes the backup saved by eb from its storage if its deletion policy is Delete,
+// then removes the finalizer of eb so that it is released.
+func (b *Backup) finalizeBackup(eb *api.EtcdBackup) error {
+	if !hasBackupFinalizer(eb) {
+		return nil
+	}
+	if eb.Spec.DeletionPolicy == api.BackupDeletionPolicyDelete {
+		if err := b.deleteStoredBackup(eb); err != nil {
+			return err
+		}
+	}
+
+	eb = eb.DeepCopy()
+	var finalizers []string
+	for _, f := range eb.Finalizers {
+		if f != backupFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	eb.Finalizers = finalizers
+	_, err := b.backupCRCli.EtcdV1beta2().EtcdBackups(eb.Namespace).Update(eb)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// deleteStoredBackup deletes the backup saved by eb from its storage.
+// It does nothing if eb did not save a backup, or if the backup was already deleted.
+func (b *Backup) deleteStoredBackup(eb *api.EtcdBackup) error {
+	if !eb.Status.Succeeded || len(eb.Status.Path) == 0 {
+		return nil
+	}
+	st, err := newBackupStorage(b.kubecli, &eb.Spec, eb.Namespace)
+	if err != nil {
+		return err
+	}
+	defer st.close()
+	if err := st.be.Delete(path.Base(eb.Status.Path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	b.logger.Infof("deleted backup %s of %s", eb.Status.Path, eb.Name)
+	return nil
+}
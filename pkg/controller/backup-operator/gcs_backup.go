@@ -0,0 +1,44 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"path"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/gcs"
+	"github.com/coreos/etcd-operator/pkg/cluster/backupstorage"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// newGCSStorage returns the GCS storage of spec. The path of a backup is "<bucket>/<object>".
+func newGCSStorage(kubecli kubernetes.Interface, spec *api.BackupSpec, namespace string) (*backupStorage, error) {
+	gs, clusterName := spec.GCS, spec.ClusterName
+	saJSON, err := backupstorage.GetGCSCreds(kubecli, namespace, gs.GCSSecret)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := gcs.New(gs.GCSBucket, path.Join(gs.Prefix, namespace, clusterName), saJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &backupStorage{
+		be:    backend.NewGCSBackend(cli),
+		path:  cli.Path,
+		close: func() {},
+	}, nil
+}
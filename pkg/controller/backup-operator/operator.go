@@ -41,6 +41,10 @@ type Backup struct {
 	informer cache.Controller
 	queue    workqueue.RateLimitingInterface
 
+	scheduleIndexer  cache.Indexer
+	scheduleInformer cache.Controller
+	scheduleQueue    workqueue.RateLimitingInterface
+
 	kubecli     kubernetes.Interface
 	backupCRCli versioned.Interface
 	kubeExtCli  apiextensionsclient.Interface
@@ -72,5 +76,12 @@ func (b *Backup) initCRD() error {
 	if err != nil {
 		return fmt.Errorf("failed to create CRD: %v", err)
 	}
-	return k8sutil.WaitCRDReady(b.kubeExtCli, api.EtcdBackupCRDName)
+	if err = k8sutil.WaitCRDReady(b.kubeExtCli, api.EtcdBackupCRDName); err != nil {
+		return err
+	}
+	err = k8sutil.CreateCRD(b.kubeExtCli, api.EtcdBackupScheduleCRDName, api.EtcdBackupScheduleResourceKind, api.EtcdBackupScheduleResourcePlural, "")
+	if err != nil {
+		return fmt.Errorf("failed to create CRD: %v", err)
+	}
+	return k8sutil.WaitCRDReady(b.kubeExtCli, api.EtcdBackupScheduleCRDName)
 }
@@ -15,32 +15,26 @@
 package controller
 
 import (
-	"fmt"
-	"path"
-
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
-	"github.com/coreos/etcd-operator/pkg/backup"
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
-	"github.com/coreos/etcd-operator/pkg/backup/writer"
+	"github.com/coreos/etcd-operator/pkg/backup/s3"
 	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
 
 	"k8s.io/client-go/kubernetes"
 )
 
-// TODO: replace this with generic backend interface for other options (PV, Azure)
-// handleS3 backups up etcd cluster to s3 and return s3 path for the backup file.
-func handleS3(kubecli kubernetes.Interface, s3 *api.S3Source, namespace, clusterName string) (string, error) {
-	cli, err := s3factory.NewClientFromSecret(kubecli, namespace, s3.AWSSecret)
-	if err != nil {
-		return "", err
-	}
-	defer cli.Close()
-	// TODO: support TLS.
-	bm := backup.NewBackupManagerFromWriter(kubecli, writer.NewS3Writer(cli.S3), clusterName, namespace)
-	s3Prefix := backupapi.ToS3Prefix(s3.Prefix, namespace, clusterName)
-	fullPath, err := bm.SaveSnapWithPrefix(path.Join(s3.S3Bucket, s3Prefix))
+// newS3Storage returns the S3 storage of spec. The path of a backup is "<s3-bucket-name>/<key>".
+func newS3Storage(kubecli kubernetes.Interface, spec *api.BackupSpec, namespace string) (*backupStorage, error) {
+	ss, clusterName := spec.S3, spec.ClusterName
+	cli, err := s3factory.NewClientFromSecret(kubecli, namespace, ss.AWSSecret, ss.S3Endpoint)
 	if err != nil {
-		return "", fmt.Errorf("failed to save snapshot (%v)", err)
+		return nil, err
 	}
-	return fullPath, nil
+	s3cli := s3.NewFromClient(ss.S3Bucket, backupapi.ToS3Prefix(ss.Prefix, namespace, clusterName), cli.S3)
+	return &backupStorage{
+		be:    backend.NewS3Backend(s3cli),
+		path:  s3cli.Path,
+		close: cli.Close,
+	}, nil
 }
@@ -0,0 +1,229 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/retention"
+	"github.com/coreos/etcd-operator/pkg/util/cronutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// scheduleLabel is the label of the EtcdBackup resources created from a schedule,
+// set to the name of the schedule.
+const scheduleLabel = "etcd_backup_schedule"
+
+// scheduleTimeFormat formats the scheduled time of a backup into the name of its EtcdBackup.
+// The names of the backups of a schedule sort in the order they were scheduled.
+const scheduleTimeFormat = "20060102-1504"
+
+func (b *Backup) runScheduleWorker() {
+	for b.processNextSchedule() {
+	}
+}
+
+func (b *Backup) processNextSchedule() bool {
+	key, quit := b.scheduleQueue.Get()
+	if quit {
+		return false
+	}
+	defer b.scheduleQueue.Done(key)
+	err := b.processSchedule(key.(string))
+	b.handleErr(b.scheduleQueue, err, key)
+	return true
+}
+
+func (b *Backup) processSchedule(key string) error {
+	obj, exists, err := b.scheduleIndexer.GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	es := obj.(*api.EtcdBackupSchedule)
+	status := es.Status
+	now := time.Now()
+	next, err := b.syncSchedule(es, &status, now)
+	if !reflect.DeepEqual(status, es.Status) {
+		es = es.DeepCopy()
+		es.Status = status
+		if _, uerr := b.backupCRCli.EtcdV1beta2().EtcdBackupSchedules(es.Namespace).Update(es); uerr != nil {
+			b.logger.Warningf("failed to update status of backup schedule %v : (%v)", es.Name, uerr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !next.IsZero() {
+		b.scheduleQueue.AddAfter(key, next.Sub(now))
+	}
+	return nil
+}
+
+// syncSchedule creates the EtcdBackup of the last scheduled time of es that is due and has no backup yet,
+// prunes the backups of es, and reports them in status.
+// It returns the next scheduled time, or the zero time if es is invalid.
+func (b *Backup) syncSchedule(es *api.EtcdBackupSchedule, status *api.BackupScheduleStatus, now time.Time) (time.Time, error) {
+	if err := es.Spec.Validate(); err != nil {
+		status.Reason = err.Error()
+		status.NextScheduleTime = ""
+		return time.Time{}, nil
+	}
+	status.Reason = ""
+	sched, err := cronutil.Parse(es.Spec.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	last := es.CreationTimestamp.Time
+	if len(status.LastScheduleTime) != 0 {
+		if last, err = time.Parse(time.RFC3339, status.LastScheduleTime); err != nil {
+			return time.Time{}, fmt.Errorf("invalid last schedule time: %v", err)
+		}
+	}
+	// Only the last of the scheduled times missed while the operator was down is backed up.
+	if due := sched.Last(last, now); !due.IsZero() {
+		if err := b.createScheduledBackup(es, due); err != nil {
+			return time.Time{}, err
+		}
+		status.LastScheduleTime = due.Format(time.RFC3339)
+	}
+	next := sched.Next(now)
+	status.NextScheduleTime = next.Format(time.RFC3339)
+
+	backups, err := b.scheduledBackups(es)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, eb := range backups {
+		if eb.Status.Succeeded {
+			status.LastSuccessfulBackup = eb.Name
+			status.LastSuccessfulTime = eb.CreationTimestamp.Format(time.RFC3339)
+		}
+	}
+	if es.Spec.Retention != nil {
+		if err := b.pruneScheduledBackups(*es.Spec.Retention, backups); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return next, nil
+}
+
+func (b *Backup) createScheduledBackup(es *api.EtcdBackupSchedule, t time.Time) error {
+	eb := &api.EtcdBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf("%s-%s", es.Name, t.UTC().Format(scheduleTimeFormat)),
+			Namespace:       es.Namespace,
+			Labels:          map[string]string{scheduleLabel: es.Name},
+			OwnerReferences: []metav1.OwnerReference{es.AsOwner()},
+		},
+		Spec: *es.Spec.BackupSpec.DeepCopy(),
+	}
+	_, err := b.backupCRCli.EtcdV1beta2().EtcdBackups(es.Namespace).Create(eb)
+	if err != nil && !k8sutil.IsKubernetesResourceAlreadyExistError(err) {
+		return fmt.Errorf("failed to create backup %s: %v", eb.Name, err)
+	}
+	b.logger.Infof("created backup %s of schedule %s", eb.Name, es.Name)
+	return nil
+}
+
+// scheduledBackups returns the EtcdBackup resources of es in the order they were scheduled,
+// leaving out the ones being deleted.
+func (b *Backup) scheduledBackups(es *api.EtcdBackupSchedule) ([]*api.EtcdBackup, error) {
+	var backups []*api.EtcdBackup
+	sel := labels.SelectorFromSet(labels.Set{scheduleLabel: es.Name})
+	err := cache.ListAllByNamespace(b.indexer, es.Namespace, sel, func(obj interface{}) {
+		if eb := obj.(*api.EtcdBackup); eb.DeletionTimestamp == nil {
+			backups = append(backups, eb)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name < backups[j].Name })
+	return backups, nil
+}
+
+// pruneScheduledBackups deletes the successful backups not kept by the retention policy rp,
+// and the failed backups scheduled before the last successful backup.
+// The EtcdBackup of a backup is deleted once the backup is deleted from its storage.
+func (b *Backup) pruneScheduledBackups(rp api.BackupRetentionPolicy, backups []*api.EtcdBackup) error {
+	var (
+		rbs           []retention.Backup
+		byName        = make(map[string]*api.EtcdBackup)
+		lastSucceeded = -1
+	)
+	for i, eb := range backups {
+		if !eb.Status.Succeeded {
+			continue
+		}
+		byName[eb.Name] = eb
+		// The backups are in the order they were scheduled.
+		rbs = append(rbs, retention.Backup{Name: eb.Name, Revision: int64(i), CreationTime: eb.CreationTimestamp.Time})
+		lastSucceeded = i
+	}
+
+	type purge struct {
+		eb     *api.EtcdBackup
+		reason string
+	}
+	var purges []purge
+	for _, eb := range backups[:lastSucceeded+1] {
+		if len(eb.Status.Reason) != 0 {
+			purges = append(purges, purge{eb, "failed before the last successful backup"})
+		}
+	}
+	for _, d := range retention.Evaluate(rp, rbs) {
+		if !d.Keep {
+			purges = append(purges, purge{byName[d.Name], d.Reason})
+		}
+	}
+
+	for _, p := range purges {
+		if rp.DryRun {
+			b.logger.Infof("retention dry run: would delete backup %s: %s", p.eb.Name, p.reason)
+			continue
+		}
+		b.logger.Infof("deleting backup %s: %s", p.eb.Name, p.reason)
+		if err := b.deleteBackup(p.eb); err != nil {
+			return fmt.Errorf("failed to delete backup %s: %v", p.eb.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteBackup deletes the backup saved by eb from its storage, then eb.
+func (b *Backup) deleteBackup(eb *api.EtcdBackup) error {
+	if err := b.deleteStoredBackup(eb); err != nil {
+		return err
+	}
+	err := b.backupCRCli.EtcdV1beta2().EtcdBackups(eb.Namespace).Delete(eb.Name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
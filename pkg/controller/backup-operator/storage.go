@@ -0,0 +1,50 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// backupStorage is the storage the backups of an EtcdBackup are saved to.
+// Backups are laid out as the backups of the backup sidecar of their cluster.
+type backupStorage struct {
+	be backend.Backend
+	// path returns the path of the backup of the given name, reported in the status.
+	path func(name string) string
+	// close releases the resources of the storage.
+	close func()
+}
+
+func newBackupStorage(kubecli kubernetes.Interface, spec *api.BackupSpec, namespace string) (*backupStorage, error) {
+	switch spec.StorageType {
+	case api.BackupStorageTypeS3:
+		return newS3Storage(kubecli, spec, namespace)
+	case api.BackupStorageTypeABS:
+		return newABSStorage(kubecli, spec, namespace)
+	case api.BackupStorageTypeSwift:
+		return newSwiftStorage(kubecli, spec, namespace)
+	case api.BackupStorageTypeGCS:
+		return newGCSStorage(kubecli, spec, namespace)
+	case api.BackupStorageTypePersistentVolume:
+		return newPVStorage(spec, namespace)
+	}
+	return nil, fmt.Errorf("unknown StorageType: %v", spec.StorageType)
+}
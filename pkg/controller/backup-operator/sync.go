@@ -15,9 +15,15 @@
 package controller
 
 import (
+	"fmt"
+
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup"
+	"github.com/coreos/etcd-operator/pkg/backup/writer"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 
-	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
 )
 
 const (
@@ -47,7 +53,7 @@ func (b *Backup) processNextItem() bool {
 	defer b.queue.Done(key)
 	err := b.processItem(key.(string))
 	// Handle the error if something went wrong during the execution of the business logic
-	b.handleErr(err, key)
+	b.handleErr(b.queue, err, key)
 	return true
 }
 
@@ -61,6 +67,21 @@ func (b *Backup) processItem(key string) error {
 	}
 
 	eb := obj.(*api.EtcdBackup)
+	if eb.DeletionTimestamp != nil {
+		if err := b.finalizeBackup(eb); err != nil {
+			// The EtcdBackup is only released once its backup is deleted,
+			// so the deletion is retried for as long as it fails.
+			b.logger.Warningf("failed to finalize backup CR %v, retrying in %v: %v", eb.Name, finalizeRetryInterval, err)
+			b.queue.AddAfter(key, finalizeRetryInterval)
+		}
+		return nil
+	}
+	if eb.Spec.DeletionPolicy == api.BackupDeletionPolicyDelete && !hasBackupFinalizer(eb) {
+		// The finalizer is set before the backup is taken so that
+		// the backup is not left behind if the EtcdBackup is deleted meanwhile.
+		// The backup is taken once the update of the EtcdBackup is observed.
+		return b.addBackupFinalizer(eb)
+	}
 	// don't process the CR if it has a status since
 	// having a status means that the backup is either made or failed.
 	if eb.Status.Succeeded || len(eb.Status.Reason) != 0 {
@@ -79,6 +100,7 @@ func (b *Backup) reportBackupStatus(bs *api.BackupCRStatus, berr error, eb *api.
 	} else {
 		eb.Status.Succeeded = true
 		eb.Status.S3Path = bs.S3Path
+		eb.Status.Path = bs.Path
 	}
 	_, err := b.backupCRCli.EtcdV1beta2().EtcdBackups(b.namespace).Update(eb)
 	if err != nil {
@@ -86,40 +108,64 @@ func (b *Backup) reportBackupStatus(bs *api.BackupCRStatus, berr error, eb *api.
 	}
 }
 
-func (b *Backup) handleErr(err error, key interface{}) {
+func (b *Backup) handleErr(queue workqueue.RateLimitingInterface, err error, key interface{}) {
 	if err == nil {
 		// Forget about the #AddRateLimited history of the key on every successful synchronization.
 		// This ensures that future processing of updates for this key is not delayed because of
 		// an outdated error history.
-		b.queue.Forget(key)
+		queue.Forget(key)
 		return
 	}
 
 	// This controller retries maxRetries times if something goes wrong. After that, it stops trying.
-	if b.queue.NumRequeues(key) < maxRetries {
-		b.logger.Errorf("error syncing etcd backup (%v): %v", key, err)
+	if queue.NumRequeues(key) < maxRetries {
+		b.logger.Errorf("error syncing (%v): %v", key, err)
 
 		// Re-enqueue the key rate limited. Based on the rate limiter on the
 		// queue and the re-enqueue history, the key will be processed later again.
-		b.queue.AddRateLimited(key)
+		queue.AddRateLimited(key)
 		return
 	}
 
-	b.queue.Forget(key)
+	queue.Forget(key)
 	// Report that, even after several retries, we could not successfully process this key
-	b.logger.Infof("Dropping etcd backup (%v) out of the queue: %v", key, err)
+	b.logger.Infof("Dropping (%v) out of the queue: %v", key, err)
 }
 
 func (b *Backup) handleBackup(spec *api.BackupSpec) (*api.BackupCRStatus, error) {
-	switch spec.StorageType {
-	case api.BackupStorageTypeS3:
-		s3path, err := handleS3(b.kubecli, spec.S3, b.namespace, spec.ClusterName)
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	st, err := newBackupStorage(b.kubecli, spec, b.namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer st.close()
+	name, err := saveSnap(b.kubecli, spec, b.namespace, writer.NewBackendWriter(st.be))
+	if err != nil {
+		return nil, err
+	}
+	bs := &api.BackupCRStatus{Path: st.path(name)}
+	if spec.StorageType == api.BackupStorageTypeS3 {
+		bs.S3Path = bs.Path
+	}
+	return bs, nil
+}
+
+// saveSnap saves a snapshot of the etcd cluster of spec with w, and returns the name of the backup.
+func saveSnap(kubecli kubernetes.Interface, spec *api.BackupSpec, namespace string, w writer.Writer) (string, error) {
+	if spec.Encryption != nil {
+		kr, err := k8sutil.GetEncryptionKeyring(kubecli, namespace, spec.Encryption)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-		return &api.BackupCRStatus{S3Path: s3path}, nil
-	default:
-		logrus.Fatalf("unknown StorageType: %v", spec.StorageType)
+		w = writer.NewEncryptedWriter(w, kr)
+	}
+	// TODO: support TLS.
+	bm := backup.NewBackupManagerFromWriter(kubecli, w, spec.ClusterName, namespace, spec.Compression)
+	name, err := bm.SaveSnapWithPrefix("")
+	if err != nil {
+		return "", fmt.Errorf("failed to save snapshot (%v)", err)
 	}
-	return nil, nil
+	return name, nil
 }
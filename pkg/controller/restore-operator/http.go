@@ -19,11 +19,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	pathpkg "path"
+	"strings"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
-	"github.com/coreos/etcd-operator/pkg/backup/reader"
-	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
+	"github.com/coreos/etcd-operator/pkg/backup/compression"
+	"github.com/coreos/etcd-operator/pkg/backup/delta"
+	"github.com/coreos/etcd-operator/pkg/backup/encryption"
+	"github.com/coreos/etcd-operator/pkg/backup/manifest"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,8 +43,34 @@ const (
 
 func (r *Restore) startHTTP() {
 	http.HandleFunc(backupapi.APIV1+"/backup/", r.handleServeBackup)
-	logrus.Infof("listening on %v", listenAddr)
-	panic(http.ListenAndServe(listenAddr, nil))
+	if r.tlsConfig == nil {
+		logrus.Infof("listening on %v", listenAddr)
+		panic(http.ListenAndServe(listenAddr, nil))
+	}
+	srv := &http.Server{
+		Addr:      listenAddr,
+		Handler:   backupapi.Authenticate(http.DefaultServeMux, r.restoreToken),
+		TLSConfig: r.tlsConfig,
+	}
+	logrus.Infof("listening on %v with TLS", listenAddr)
+	panic(srv.ListenAndServeTLS("", ""))
+}
+
+// restoreToken returns the bearer token of the auth secret of the restore CR the request fetches the backup of.
+func (r *Restore) restoreToken(req *http.Request) string {
+	if !strings.HasPrefix(req.URL.Path, backupHTTPPath) {
+		return ""
+	}
+	restoreName := req.URL.Path[len(backupHTTPPath):]
+	if len(restoreName) == 0 {
+		return ""
+	}
+	token, err := k8sutil.GetBackupAPIAuthToken(r.kubecli, r.namespace, k8sutil.RestoreAuthSecretName(restoreName))
+	if err != nil {
+		logrus.Warningf("failed to authenticate request for restore CR %v: %v", restoreName, err)
+		return ""
+	}
+	return token
 }
 
 func (r *Restore) handleServeBackup(w http.ResponseWriter, req *http.Request) {
@@ -50,7 +83,8 @@ func (r *Restore) handleServeBackup(w http.ResponseWriter, req *http.Request) {
 
 // serveBackup parses incoming request url of the form /backup/<restore-name>
 // get the etcd restore name.
-// Then it returns the etcd cluster backup snapshot to the caller.
+// Then it returns the etcd cluster backup snapshot to the caller,
+// at the restore target of the restore CR if it has one.
 func (r *Restore) serveBackup(w http.ResponseWriter, req *http.Request) error {
 	restoreName := string(req.URL.Path[len(backupHTTPPath):])
 	if len(restoreName) == 0 {
@@ -74,37 +108,109 @@ func (r *Restore) serveBackup(w http.ResponseWriter, req *http.Request) error {
 	logrus.Infof("serving backup for restore CR %v", restoreName)
 	cr := v.(*api.EtcdRestore)
 	restoreSource := cr.Spec.RestoreSource
-	var backupReader reader.Reader
-	var path string
-
-	switch {
-	case restoreSource.S3 != nil:
-		s3RestoreSource := restoreSource.S3
-		if len(s3RestoreSource.AWSSecret) == 0 || len(s3RestoreSource.Path) == 0 {
-			return errors.New("invalid s3 restore source field (spec.s3), must specify all required subfields")
+	src, err := newBackupReader(r.kubecli, r.namespace, &restoreSource)
+	if err != nil {
+		return err
+	}
+	defer src.close()
+	backupReader, path := src.r, src.path
+	// replayRev is the revision to replay the deltas of the backup up to, all of them if negative.
+	replayRev := int64(-1)
+	if cr.Spec.RestoreTarget.IsSet() {
+		snap := cr.Status.Snapshot
+		if snap == nil {
+			// The status of the restore CR might not be synced yet.
+			if snap, err = r.findSnapshot(cr); err != nil {
+				return err
+			}
 		}
+		path, replayRev = snap.Backup, snap.Revision
+	}
 
-		s3Cli, err := s3factory.NewClientFromSecret(r.kubecli, r.namespace, s3RestoreSource.AWSSecret)
+	var kr *encryption.Keyring
+	if restoreSource.Encryption != nil {
+		kr, err = k8sutil.GetEncryptionKeyring(r.kubecli, r.namespace, restoreSource.Encryption)
 		if err != nil {
-			return fmt.Errorf("failed to create S3 client: %v", err)
+			return err
 		}
-		defer s3Cli.Close()
-
-		backupReader = reader.NewS3Reader(s3Cli.S3)
-		path = s3RestoreSource.Path
-	default:
-		return errors.New("restore CR must have a restore source specified")
+	}
+	// open opens and decrypts the backup object at the given path.
+	open := func(name string) (io.ReadCloser, error) {
+		rc, err := backupReader.Open(name)
+		if err != nil || kr == nil {
+			return rc, err
+		}
+		dr, err := kr.Decrypt(rc)
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("failed to decrypt backup file(%v): %v", name, err)
+		}
+		return readCloser{dr, rc}, nil
 	}
 
-	rc, err := backupReader.Open(path)
+	rc, err := open(path)
 	if err != nil {
 		return fmt.Errorf("failed to read backup file(%v): %v", path, err)
 	}
 	defer rc.Close()
 
-	_, err = io.Copy(w, rc)
+	chain, err := readChain(open, path)
 	if err != nil {
-		return fmt.Errorf("failed to write backup to %s: %v", req.RemoteAddr, err)
+		return err
+	}
+
+	var br io.Reader = rc
+	codec := util.CompressionFromBackupName(path)
+	if chain != nil && len(chain.Deltas) != 0 {
+		dr, err := compression.Decompress(rc, codec)
+		if err != nil {
+			return err
+		}
+		defer dr.Close()
+		// The deltas of the chain are stored next to the backup.
+		openDelta := func(name string) (io.ReadCloser, error) {
+			return open(pathpkg.Join(pathpkg.Dir(path), name))
+		}
+		f, rev, err := delta.Restore(dr, chain, openDelta, replayRev)
+		if err != nil {
+			return fmt.Errorf("failed to replay deltas of backup file(%v): %v", path, err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		logrus.Infof("replayed deltas of backup file(%v) up to revision %d", path, rev)
+		br, codec = f, ""
+	}
+
+	err = compression.WriteResponse(w, req, br, codec)
+	if err != nil {
+		logrus.Errorf("failed to write backup to %s: %v", req.RemoteAddr, err)
+		// The status has been sent already. Abort the response so that the
+		// restoring member does not take a truncated or corrupted backup for a complete one.
+		panic(http.ErrAbortHandler)
 	}
 	return nil
 }
+
+// readChain reads the delta chain of the backup at the given path.
+// It returns nil if the backup has no delta chain.
+func readChain(open manifest.OpenFunc, path string) (*delta.Chain, error) {
+	name := util.ChainName(path)
+	rc, err := open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delta chain(%v): %v", name, err)
+	}
+	defer rc.Close()
+	c, err := delta.ReadChain(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delta chain(%v): %v", name, err)
+	}
+	return c, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
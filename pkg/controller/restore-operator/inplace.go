@@ -0,0 +1,395 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/etcd-operator/client/experimentalclient"
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+	"github.com/coreos/etcd-operator/pkg/util/retryutil"
+
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	inPlacePollInterval = 5 * time.Second
+	// inPlacePhaseTimeout is how long a phase of an in-place restore waits for the cluster.
+	inPlacePhaseTimeout = 10 * time.Minute
+	// safetyBackupTimeout is how long taking the safety backup of an in-place restore can take.
+	safetyBackupTimeout = 15 * time.Minute
+)
+
+var errNoSafetyBackup = errors.New("the cluster has no backup policy to take a safety backup with")
+
+// restoreInPlace restores the existing etcd cluster named after the restore CR in place:
+// - pause the cluster, so that the etcd operator stops touching its members
+// - take a safety backup of the cluster with its backup sidecar
+// - delete the members of the cluster and their volumes
+// - create the seed member restoring the backup, behind the existing services of the cluster
+// - make the backup sidecar retire the backups newer than the seed, the safety backup among them, and back up the seed
+// - resume the cluster, so that the etcd operator grows the seed back to the size of the cluster
+// Every phase is recorded as a condition of the restore CR once it is done,
+// so that a restore interrupted by a restart of the restore operator picks up where it stopped.
+// If the restore fails before the members are stopped, the cluster is resumed.
+func (r *Restore) restoreInPlace(er *api.EtcdRestore) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("in-place restore failed: %v", err)
+		}
+	}()
+
+	clusterName := er.Name
+	ec, err := r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Get(clusterName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get etcd cluster (%s): %v", clusterName, err)
+	}
+	if ec.Status.Phase != api.ClusterPhaseRunning {
+		return fmt.Errorf("etcd cluster (%s) is not running: phase %q", clusterName, ec.Status.Phase)
+	}
+	if ec.Spec.Backup == nil && !er.Status.HasCondition(api.RestoreConditionSafetyBackupTaken) {
+		return errNoSafetyBackup
+	}
+	if err = r.checkTLSSecrets(ec.Spec.TLS); err != nil {
+		return err
+	}
+	if err = er.Spec.RestoreTarget.Validate(); err != nil {
+		return err
+	}
+	if er.Spec.RestoreTarget.IsSet() && er.Status.Snapshot == nil {
+		// Pick the snapshot before touching the cluster, so that the restore fails early if there is none.
+		if er.Status.Snapshot, err = r.findSnapshot(er); err != nil {
+			return err
+		}
+	}
+
+	phases := []struct {
+		cond api.RestoreConditionType
+		run  func() (string, error)
+	}{
+		{api.RestoreConditionPaused, func() (string, error) { return "", r.pauseCluster(clusterName) }},
+		{api.RestoreConditionSafetyBackupTaken, func() (string, error) { return r.takeSafetyBackup(ec) }},
+		{api.RestoreConditionMembersStopped, func() (string, error) { return r.stopMembers(ec) }},
+		{api.RestoreConditionSeedRestored, func() (string, error) { return r.restoreSeedInPlace(ec) }},
+		{api.RestoreConditionBackupsRolledBack, func() (string, error) { return r.rollBackBackups(ec) }},
+		{api.RestoreConditionResumed, func() (string, error) { return "", r.setClusterPaused(clusterName, false) }},
+		{api.RestoreConditionMembersRegrown, func() (string, error) { return "", r.waitMembersRegrown(clusterName) }},
+	}
+	for _, p := range phases {
+		if er.Status.HasCondition(p.cond) {
+			continue
+		}
+		r.logger.Infof("in-place restore of cluster (%s): waiting for %s", clusterName, p.cond)
+		msg, err := p.run()
+		if err != nil {
+			if !er.Status.HasCondition(api.RestoreConditionMembersStopped) {
+				// The members of the cluster are untouched, so hand them back to the etcd operator.
+				if rerr := r.setClusterPaused(clusterName, false); rerr != nil {
+					r.logger.Errorf("failed to resume cluster (%s): %v", clusterName, rerr)
+				}
+			}
+			return fmt.Errorf("%s: %v", p.cond, err)
+		}
+		if err = r.setRestoreCondition(er, p.cond, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRestoreCondition records the condition of the given type in the status of the restore CR.
+func (r *Restore) setRestoreCondition(er *api.EtcdRestore, t api.RestoreConditionType, message string) error {
+	er.Status.SetCondition(t, message)
+	n, err := r.etcdCRCli.EtcdV1beta2().EtcdRestores(r.namespace).Update(er)
+	if err != nil {
+		return fmt.Errorf("failed to set condition %s of restore CR: %v", t, err)
+	}
+	*er = *n
+	r.logger.Infof("in-place restore of cluster (%s): %s %s", er.Name, t, message)
+	return nil
+}
+
+// pauseCluster pauses the etcd cluster and waits for the etcd operator to report that it stopped managing it.
+func (r *Restore) pauseCluster(clusterName string) error {
+	if err := r.setClusterPaused(clusterName, true); err != nil {
+		return err
+	}
+	return r.waitCluster(clusterName, func(ec *api.EtcdCluster) (bool, error) {
+		return ec.Status.ControlPaused, nil
+	})
+}
+
+// setClusterPaused sets spec.paused of the etcd cluster.
+func (r *Restore) setClusterPaused(clusterName string, paused bool) error {
+	// Retry on conflicts with the status updates of the etcd operator.
+	err := retryutil.Retry(time.Second, 5, func() (bool, error) {
+		ec, err := r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Get(clusterName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if ec.Spec.Paused == paused {
+			return true, nil
+		}
+		ec.Spec.Paused = paused
+		_, err = r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Update(ec)
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update etcdcluster CR to spec.paused=%v: %v", paused, err)
+	}
+	return nil
+}
+
+// waitCluster polls the etcd cluster until cond holds.
+func (r *Restore) waitCluster(clusterName string, cond func(ec *api.EtcdCluster) (bool, error)) error {
+	return retryutil.Retry(inPlacePollInterval, int(inPlacePhaseTimeout/inPlacePollInterval), func() (bool, error) {
+		ec, err := r.etcdCRCli.EtcdV1beta2().EtcdClusters(r.namespace).Get(clusterName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return cond(ec)
+	})
+}
+
+// takeSafetyBackup makes the backup sidecar of the etcd cluster take a backup of it.
+func (r *Restore) takeSafetyBackup(ec *api.EtcdCluster) (string, error) {
+	bc, err := r.newBackupClient(ec)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), safetyBackupTimeout)
+	defer cancel()
+	if err := bc.Request(ctx); err != nil {
+		return "", err
+	}
+	return r.recentBackupMessage(ctx, bc, ec.Name), nil
+}
+
+// rollBackBackups makes the backup sidecar of the etcd cluster retire the backups taken after the revision
+// the seed was restored to, so that they are not mistaken for backups of the restored cluster,
+// and take a backup of the seed. Until then, the sidecar keeps recording the deltas and comparing
+// the revisions of its backups against the cluster as it was before the restore.
+func (r *Restore) rollBackBackups(ec *api.EtcdCluster) (string, error) {
+	if ec.Spec.Backup == nil {
+		return "the cluster has no backup sidecar", nil
+	}
+	bc, err := r.newBackupClient(ec)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), safetyBackupTimeout)
+	defer cancel()
+	if err := bc.Rollback(ctx); err != nil {
+		return "", err
+	}
+	return r.recentBackupMessage(ctx, bc, ec.Name), nil
+}
+
+// newBackupClient returns a client of the backup sidecar of the etcd cluster.
+func (r *Restore) newBackupClient(ec *api.EtcdCluster) (experimentalclient.Backup, error) {
+	if ec.Spec.Backup.API == nil {
+		return experimentalclient.NewBackup(&http.Client{}, "http", ec.Name), nil
+	}
+	c, err := k8sutil.NewBackupAPIClient(r.kubecli, r.namespace, k8sutil.BackupAPIAuthSecretName(ec.Name))
+	if err != nil {
+		return nil, err
+	}
+	return experimentalclient.NewBackup(c, "https", ec.Name), nil
+}
+
+// recentBackupMessage returns the message reporting the backup the sidecar just took.
+func (r *Restore) recentBackupMessage(ctx context.Context, bc experimentalclient.Backup, clusterName string) string {
+	s, err := bc.ServiceStatus(ctx)
+	if err != nil || s.RecentBackup == nil {
+		r.logger.Warningf("failed to get the status of the backup of cluster (%s): %v", clusterName, err)
+		return ""
+	}
+	return fmt.Sprintf("took backup at revision %d", s.RecentBackup.Revision)
+}
+
+// stopMembers deletes the member pods and volumes of the etcd cluster and waits for the pods to be gone.
+func (r *Restore) stopMembers(ec *api.EtcdCluster) (string, error) {
+	pods, err := r.listMemberPods(ec)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, pod := range pods {
+		err := r.kubecli.CoreV1().Pods(r.namespace).Delete(pod.Name, metav1.NewDeleteOptions(0))
+		if err != nil && !k8sutil.IsKubernetesResourceNotFoundError(err) {
+			return "", fmt.Errorf("failed to delete member (%s): %v", pod.Name, err)
+		}
+		names = append(names, pod.Name)
+	}
+	pvcs, err := r.listMemberPVCs(ec)
+	if err != nil {
+		return "", err
+	}
+	for _, pvc := range pvcs {
+		err := r.kubecli.CoreV1().PersistentVolumeClaims(r.namespace).Delete(pvc.Name, nil)
+		if err != nil && !k8sutil.IsKubernetesResourceNotFoundError(err) {
+			return "", fmt.Errorf("failed to delete persistent volume claim (%s): %v", pvc.Name, err)
+		}
+	}
+
+	err = retryutil.Retry(inPlacePollInterval, int(inPlacePhaseTimeout/inPlacePollInterval), func() (bool, error) {
+		pods, err := r.listMemberPods(ec)
+		return len(pods) == 0, err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for members to be deleted: %v", err)
+	}
+	return fmt.Sprintf("deleted members %v", names), nil
+}
+
+// restoreSeedInPlace creates the seed member of the etcd cluster restoring the backup, and waits for it to be ready.
+// The seed is named after the highest member counter of the cluster,
+// so that its name and volume do not collide with the ones of the deleted members.
+func (r *Restore) restoreSeedInPlace(ec *api.EtcdCluster) (string, error) {
+	pods, err := r.listMemberPods(ec)
+	if err != nil {
+		return "", err
+	}
+	var seedName string
+	switch len(pods) {
+	case 0:
+		counter, err := r.nextMemberCounter(ec)
+		if err != nil {
+			return "", err
+		}
+		seedName = etcdutil.CreateMemberName(ec.Name, counter)
+		if err := r.createSeedMember(ec.Spec, ec.Name, counter, ec.AsOwner()); err != nil {
+			return "", err
+		}
+	case 1:
+		// The seed was created before the restore operator restarted.
+		seedName = pods[0].Name
+	default:
+		return "", fmt.Errorf("unexpected members %v", k8sutil.GetPodNames(pods))
+	}
+
+	err = retryutil.Retry(inPlacePollInterval, int(inPlacePhaseTimeout/inPlacePollInterval), func() (bool, error) {
+		pod, err := r.kubecli.CoreV1().Pods(r.namespace).Get(seedName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if pod.Status.Phase == v1.PodFailed {
+			return false, fmt.Errorf("seed member (%s) failed: %s", seedName, pod.Status.Reason)
+		}
+		return pod.Status.Phase == v1.PodRunning && k8sutil.IsPodReady(pod), nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for seed member (%s) to be ready: %v", seedName, err)
+	}
+	return "seed member " + seedName, nil
+}
+
+// nextMemberCounter returns the counter following the ones of the members the etcd cluster had and of their volumes.
+func (r *Restore) nextMemberCounter(ec *api.EtcdCluster) (int, error) {
+	var names []string
+	names = append(names, ec.Status.Members.Ready...)
+	names = append(names, ec.Status.Members.Unready...)
+	pvcs, err := r.listMemberPVCs(ec)
+	if err != nil {
+		return 0, err
+	}
+	for _, pvc := range pvcs {
+		names = append(names, strings.TrimSuffix(pvc.Name, "-pvc"))
+	}
+	next := 0
+	for _, name := range names {
+		ct, err := etcdutil.GetCounterFromMemberName(name)
+		if err != nil {
+			// e.g. the WAL volume "<member>-wal-pvc" of a member.
+			continue
+		}
+		if ct+1 > next {
+			next = ct + 1
+		}
+	}
+	return next, nil
+}
+
+// waitMembersRegrown waits for the etcd operator to grow the etcd cluster back to its size.
+// The members reported ready must all be running, so that the members deleted by the restore are not counted.
+func (r *Restore) waitMembersRegrown(clusterName string) error {
+	return r.waitCluster(clusterName, func(ec *api.EtcdCluster) (bool, error) {
+		if ec.Status.ControlPaused || len(ec.Status.Members.Ready) != ec.Spec.Size {
+			return false, nil
+		}
+		pods, err := r.listMemberPods(ec)
+		if err != nil {
+			return false, err
+		}
+		running := make(map[string]bool)
+		for _, pod := range pods {
+			running[pod.Name] = pod.Status.Phase == v1.PodRunning
+		}
+		for _, name := range ec.Status.Members.Ready {
+			if !running[name] {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// listMemberPods lists the member pods of the etcd cluster.
+func (r *Restore) listMemberPods(ec *api.EtcdCluster) ([]*v1.Pod, error) {
+	podList, err := r.kubecli.CoreV1().Pods(r.namespace).List(k8sutil.ClusterListOpt(ec.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list member pods: %v", err)
+	}
+	var pods []*v1.Pod
+	for i := range podList.Items {
+		if pod := &podList.Items[i]; isOwnedBy(pod.ObjectMeta, ec.UID) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// listMemberPVCs lists the persistent volume claims of the members of the etcd cluster.
+// The claim of the backup volume of the cluster is not owned by the cluster, so it is not listed.
+func (r *Restore) listMemberPVCs(ec *api.EtcdCluster) ([]*v1.PersistentVolumeClaim, error) {
+	pvcList, err := r.kubecli.CoreV1().PersistentVolumeClaims(r.namespace).List(k8sutil.ClusterListOpt(ec.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list member persistent volume claims: %v", err)
+	}
+	var pvcs []*v1.PersistentVolumeClaim
+	for i := range pvcList.Items {
+		if pvc := &pvcList.Items[i]; isOwnedBy(pvc.ObjectMeta, ec.UID) {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	return pvcs, nil
+}
+
+func isOwnedBy(om metav1.ObjectMeta, uid types.UID) bool {
+	return len(om.OwnerReferences) != 0 && om.OwnerReferences[0].UID == uid
+}
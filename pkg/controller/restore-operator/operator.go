@@ -16,6 +16,7 @@ package controller
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
@@ -35,6 +36,12 @@ type Restore struct {
 
 	namespace string
 	mySvcAddr string
+	// tlsSecret and clientCASecret are the secrets the backup API is secured with.
+	// The API is served over plain HTTP if tlsSecret is empty.
+	tlsSecret      string
+	clientCASecret string
+	// tlsConfig is the TLS config the backup API is served with, nil if it is not secured.
+	tlsConfig *tls.Config
 	// k8s workqueue pattern
 	indexer  cache.Indexer
 	informer cache.Controller
@@ -46,14 +53,17 @@ type Restore struct {
 }
 
 // New creates a restore operator.
-func New(namespace, mySvcAddr string) *Restore {
+// The backup API it serves to the seed members is secured with the secrets tlsSecret and clientCASecret, if set.
+func New(namespace, mySvcAddr, tlsSecret, clientCASecret string) *Restore {
 	return &Restore{
-		logger:     logrus.WithField("pkg", "controller"),
-		namespace:  namespace,
-		mySvcAddr:  mySvcAddr,
-		kubecli:    k8sutil.MustNewKubeClient(),
-		etcdCRCli:  client.MustNewInCluster(),
-		kubeExtCli: k8sutil.MustNewKubeExtClient(),
+		logger:         logrus.WithField("pkg", "controller"),
+		namespace:      namespace,
+		mySvcAddr:      mySvcAddr,
+		tlsSecret:      tlsSecret,
+		clientCASecret: clientCASecret,
+		kubecli:        k8sutil.MustNewKubeClient(),
+		etcdCRCli:      client.MustNewInCluster(),
+		kubeExtCli:     k8sutil.MustNewKubeExtClient(),
 	}
 }
 
@@ -62,6 +72,13 @@ func (r *Restore) Start(ctx context.Context) error {
 	if err := r.initCRD(); err != nil {
 		return err
 	}
+	if len(r.tlsSecret) != 0 {
+		tc, err := k8sutil.NewBackupAPIServerTLSConfig(r.kubecli, r.namespace, r.tlsSecret, r.clientCASecret)
+		if err != nil {
+			return err
+		}
+		r.tlsConfig = tc
+	}
 	go r.run(ctx)
 	go r.startHTTP()
 	<-ctx.Done()
@@ -0,0 +1,200 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/gcs"
+	"github.com/coreos/etcd-operator/pkg/backup/reader"
+	"github.com/coreos/etcd-operator/pkg/backup/swift"
+	"github.com/coreos/etcd-operator/pkg/cluster/backupstorage"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// backupReader reads the backup of a restore source.
+type backupReader struct {
+	r reader.Reader
+	// path is the path of the backup to open with r.
+	path string
+	// close releases the resources of the reader.
+	close func()
+}
+
+func newBackupReader(kubecli kubernetes.Interface, namespace string, rs *api.RestoreSource) (*backupReader, error) {
+	switch {
+	case rs.S3 != nil:
+		return newS3Reader(kubecli, namespace, rs.S3)
+	case rs.ABS != nil:
+		return newABSReader(kubecli, namespace, rs.ABS)
+	case rs.Swift != nil:
+		return newSwiftReader(kubecli, namespace, rs.Swift)
+	case rs.GCS != nil:
+		return newGCSReader(kubecli, namespace, rs.GCS)
+	case rs.PV != nil:
+		return newPVReader(rs.PV)
+	case rs.HTTP != nil:
+		return newHTTPReader(kubecli, namespace, rs.HTTP)
+	}
+	return nil, errors.New("restore CR must have a restore source specified")
+}
+
+func newS3Reader(kubecli kubernetes.Interface, namespace string, s3 *api.S3RestoreSource) (*backupReader, error) {
+	if len(s3.AWSSecret) == 0 || len(s3.Path) == 0 {
+		return nil, errors.New("invalid s3 restore source field (spec.s3), must specify all required subfields")
+	}
+	s3Cli, err := s3factory.NewClientFromSecret(kubecli, namespace, s3.AWSSecret, s3.S3Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %v", err)
+	}
+	return &backupReader{
+		r:     reader.NewS3Reader(s3Cli.S3),
+		path:  s3.Path,
+		close: s3Cli.Close,
+	}, nil
+}
+
+func newABSReader(kubecli kubernetes.Interface, namespace string, as *api.ABSRestoreSource) (*backupReader, error) {
+	if len(as.ABSSecret) == 0 || len(as.Path) == 0 {
+		return nil, errors.New("invalid abs restore source field (spec.abs), must specify all required subfields")
+	}
+	account, key, err := backupstorage.GetABSCreds(kubecli, namespace, as.ABSSecret)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := storage.NewBasicClient(account, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ABS client: %v", err)
+	}
+	bs := cli.GetBlobService()
+	return &backupReader{
+		r:     reader.NewABSReader(&bs),
+		path:  as.Path,
+		close: func() {},
+	}, nil
+}
+
+func newSwiftReader(kubecli kubernetes.Interface, namespace string, ss *api.SwiftRestoreSource) (*backupReader, error) {
+	if len(ss.SwiftSecret) == 0 || len(ss.Path) == 0 {
+		return nil, errors.New("invalid swift restore source field (spec.swift), must specify all required subfields")
+	}
+	ao, err := backupstorage.GetSwiftCreds(kubecli, namespace, ss.SwiftSecret)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := swift.NewClient(ss.SwiftRegion, ao)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Swift client: %v", err)
+	}
+	return &backupReader{
+		r:     reader.NewSwiftReader(cli),
+		path:  ss.Path,
+		close: func() {},
+	}, nil
+}
+
+func newGCSReader(kubecli kubernetes.Interface, namespace string, gs *api.GCSRestoreSource) (*backupReader, error) {
+	if len(gs.GCSSecret) == 0 || len(gs.Path) == 0 {
+		return nil, errors.New("invalid gcs restore source field (spec.gcs), must specify all required subfields")
+	}
+	saJSON, err := backupstorage.GetGCSCreds(kubecli, namespace, gs.GCSSecret)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := gcs.NewClient(saJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &backupReader{
+		r:     reader.NewGCSReader(gcs.DefaultEndpoint, cli),
+		path:  gs.Path,
+		close: func() {},
+	}, nil
+}
+
+// newPVReader returns the reader of the backup volume mounted at constants.BackupMountDir
+// in the restore operator pod.
+func newPVReader(ps *api.PVRestoreSource) (*backupReader, error) {
+	if len(ps.Path) == 0 {
+		return nil, errors.New("invalid pv restore source field (spec.pv), must specify all required subfields")
+	}
+	if _, err := os.Stat(constants.BackupMountDir); err != nil {
+		return nil, fmt.Errorf("no backup volume is mounted at %s (%v)", constants.BackupMountDir, err)
+	}
+	return &backupReader{
+		r:     reader.NewFileReader(constants.BackupMountDir),
+		path:  ps.Path,
+		close: func() {},
+	}, nil
+}
+
+func newHTTPReader(kubecli kubernetes.Interface, namespace string, hs *api.HTTPRestoreSource) (*backupReader, error) {
+	u, err := url.Parse(hs.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http restore source url (%v): %v", hs.URL, err)
+	}
+	if u.Scheme != "https" || len(u.Host) == 0 || len(u.Path) == 0 || len(u.RawQuery) != 0 || len(u.Fragment) != 0 {
+		return nil, fmt.Errorf("invalid http restore source url (%v): must be an https URL without query", hs.URL)
+	}
+
+	tc := &tls.Config{}
+	if len(hs.CASecret) != 0 {
+		se, err := kubecli.CoreV1().Secrets(namespace).Get(hs.CASecret, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get CA secret (%v): %v", hs.CASecret, err)
+		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(se.Data[api.HTTPCABundleFileName]) {
+			return nil, fmt.Errorf("CA secret (%v) has no valid %s", hs.CASecret, api.HTTPCABundleFileName)
+		}
+	}
+	var creds reader.HTTPCredentials
+	if len(hs.AuthSecret) != 0 {
+		se, err := kubecli.CoreV1().Secrets(namespace).Get(hs.AuthSecret, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get auth secret (%v): %v", hs.AuthSecret, err)
+		}
+		creds = reader.HTTPCredentials{
+			Token:    string(se.Data[api.HTTPAuthToken]),
+			Username: string(se.Data[api.HTTPAuthUsername]),
+			Password: string(se.Data[api.HTTPAuthPassword]),
+		}
+		if len(creds.Token) == 0 && len(creds.Username) == 0 {
+			return nil, fmt.Errorf("auth secret (%v) has neither %s nor %s", hs.AuthSecret, api.HTTPAuthToken, api.HTTPAuthUsername)
+		}
+	}
+
+	cli := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tc,
+	}}
+	return &backupReader{
+		r:     reader.NewHTTPReader(cli, u.Scheme+"://"+u.Host, creds),
+		path:  u.EscapedPath(),
+		close: func() {},
+	}, nil
+}
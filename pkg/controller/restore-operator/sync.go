@@ -76,7 +76,21 @@ func (r *Restore) handleCR(er *api.EtcdRestore, key string) error {
 	if er.Status.Succeeded || len(er.Status.Reason) != 0 {
 		return nil
 	}
-	err := r.prepareSeed(er)
+	var err error
+	if er.Spec.InPlace {
+		// An in-place restore records its phases in the status of the restore CR as it goes,
+		// so it works on the latest version of the restore CR rather than the cached one.
+		er, err = r.etcdCRCli.EtcdV1beta2().EtcdRestores(r.namespace).Get(er.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if er.Status.Succeeded || len(er.Status.Reason) != 0 {
+			return nil
+		}
+		err = r.restoreInPlace(er)
+	} else {
+		err = r.prepareSeed(er)
+	}
 	r.reportStatus(err, er)
 	return err
 }
@@ -139,6 +153,21 @@ func (r *Restore) prepareSeed(er *api.EtcdRestore) (err error) {
 	// Use the restore CR's name as the name of the etcd cluster being restored
 	clusterName := er.Name
 
+	if err = r.checkTLSSecrets(cs.TLS); err != nil {
+		return err
+	}
+
+	if err = er.Spec.RestoreTarget.Validate(); err != nil {
+		return err
+	}
+	if er.Spec.RestoreTarget.IsSet() {
+		er.Status.Snapshot, err = r.findSnapshot(er)
+		if err != nil {
+			return err
+		}
+		r.logger.Infof("restoring cluster (%s) from backup (%s) to revision %d", clusterName, er.Status.Snapshot.Backup, er.Status.Snapshot.Revision)
+	}
+
 	ec := &api.EtcdCluster{
 		ObjectMeta: metav1.ObjectMeta{Name: clusterName},
 		Spec:       cs,
@@ -151,7 +180,10 @@ func (r *Restore) prepareSeed(er *api.EtcdRestore) (err error) {
 		return err
 	}
 
-	r.createSeedMember(cs, r.mySvcAddr, clusterName, ec.AsOwner())
+	err = r.createSeedMember(cs, clusterName, 0, ec.AsOwner())
+	if err != nil {
+		return err
+	}
 
 	// Retry updating the etcdcluster CR spec.paused=false. The etcd-operator will update the CR once so there needs to be a single retry in case of conflict
 	err = retryutil.Retry(2, 1, func() (bool, error) {
@@ -175,20 +207,69 @@ func (r *Restore) prepareSeed(er *api.EtcdRestore) (err error) {
 	return nil
 }
 
-func (r *Restore) createSeedMember(cs api.ClusterSpec, svcAddr, clusterName string, owner metav1.OwnerReference) error {
+// createSeedMember creates the seed member of the given counter restoring the backup of the restore CR named clusterName.
+// If the backup API of the restore operator is secured, the seed fetches the backup with the credentials of the auth secret of the restore CR.
+func (r *Restore) createSeedMember(cs api.ClusterSpec, clusterName string, counter int, owner metav1.OwnerReference) error {
 	m := &etcdutil.Member{
-		Name:      etcdutil.CreateMemberName(clusterName, 0),
-		Namespace: r.namespace,
-		// TODO: support TLS
-		SecurePeer:   false,
-		SecureClient: false,
+		Name:         etcdutil.CreateMemberName(clusterName, counter),
+		Namespace:    r.namespace,
+		SecurePeer:   cs.TLS.IsSecurePeer(),
+		SecureClient: cs.TLS.IsSecureClient(),
 	}
 	ms := etcdutil.NewMemberSet(m)
-	backupURL := backupapi.BackupURLForRestore("http", svcAddr, clusterName)
+	scheme, authSecret := "http", ""
+	if r.tlsConfig != nil {
+		scheme, authSecret = "https", k8sutil.RestoreAuthSecretName(clusterName)
+		if err := k8sutil.EnsureBackupAPIAuthSecret(r.kubecli, r.namespace, authSecret, r.tlsSecret, owner); err != nil {
+			return err
+		}
+	}
+	backupURL := backupapi.BackupURLForRestore(scheme, r.mySvcAddr, clusterName)
 	cs.Cleanup()
-	isPodPVEnabled := cs.Pod != nil && cs.Pod.PV != nil
-	pod := k8sutil.NewSeedMemberPod(clusterName, ms, m, cs, owner, backupURL)
-	k8sutil.AddEtcdVolumeToPod(pod, m, isPodPVEnabled)
+	pod := k8sutil.NewSeedMemberPod(clusterName, ms, m, cs, owner, backupURL, authSecret)
+	if cs.Pod != nil && cs.Pod.PV != nil {
+		// Follow the volume naming of the etcd operator so that it picks up the seed's PVCs.
+		claimName := m.Name + "-pvc"
+		pvc := k8sutil.NewPVC(claimName, cs, clusterName, r.namespace, owner)
+		if _, err := r.kubecli.Core().PersistentVolumeClaims(r.namespace).Create(pvc); err != nil {
+			return fmt.Errorf("failed to create persistent volume claim (%s): %v", claimName, err)
+		}
+		k8sutil.AddEtcdVolumeToPod(pod, m, claimName)
+		if cs.Pod.WAL != nil {
+			walClaimName := k8sutil.WALClaimName(claimName)
+			pvc := k8sutil.NewWALPVC(walClaimName, cs, clusterName, r.namespace, owner)
+			if _, err := r.kubecli.Core().PersistentVolumeClaims(r.namespace).Create(pvc); err != nil {
+				return fmt.Errorf("failed to create WAL persistent volume claim (%s): %v", walClaimName, err)
+			}
+			k8sutil.AddEtcdWALVolumeToPod(pod, walClaimName)
+		}
+	} else {
+		k8sutil.AddEtcdVolumeToPod(pod, m, "")
+	}
 	_, err := r.kubecli.Core().Pods(r.namespace).Create(pod)
 	return err
 }
+
+// checkTLSSecrets checks that the secrets of the static TLS policy tp exist,
+// so that the restore fails instead of leaving a seed member that cannot start.
+func (r *Restore) checkTLSSecrets(tp *api.TLSPolicy) error {
+	if tp == nil || tp.Static == nil {
+		return nil
+	}
+	if err := tp.Validate(); err != nil {
+		return err
+	}
+	var secrets []string
+	if tp.IsSecurePeer() {
+		secrets = append(secrets, tp.Static.Member.PeerSecret)
+	}
+	if tp.IsSecureClient() {
+		secrets = append(secrets, tp.Static.Member.ServerSecret, tp.Static.OperatorSecret)
+	}
+	for _, name := range secrets {
+		if _, err := r.kubecli.CoreV1().Secrets(r.namespace).Get(name, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("failed to get TLS secret (%s): %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/abs"
+	"github.com/coreos/etcd-operator/pkg/backup/backend"
+	"github.com/coreos/etcd-operator/pkg/backup/gcs"
+	"github.com/coreos/etcd-operator/pkg/backup/s3"
+	"github.com/coreos/etcd-operator/pkg/backup/swift"
+	"github.com/coreos/etcd-operator/pkg/backup/util"
+	"github.com/coreos/etcd-operator/pkg/cluster/backupstorage"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
+	"github.com/coreos/etcd-operator/pkg/util/constants"
+	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// v1Prefix is the prefix of the backup paths ABS and GCS storages add to their keys.
+const v1Prefix = "v1/"
+
+// sourceBackend is the backend over the directory of backups of a restore source
+// the backup to restore a cluster to a target is picked from.
+type sourceBackend struct {
+	be backend.Backend
+	// path returns the path of the backup of the given name to open with the backup reader of the restore source.
+	path func(name string) string
+	// close releases the resources of the backend.
+	close func()
+}
+
+// findSnapshot returns the snapshot the restore CR restores its cluster to:
+// the newest backup of its restore source at or before its target, and the revision it is restored to.
+func (r *Restore) findSnapshot(er *api.EtcdRestore) (*api.RestoredSnapshot, error) {
+	sb, err := newSourceBackend(r.kubecli, r.namespace, &er.Spec.RestoreSource)
+	if err != nil {
+		return nil, err
+	}
+	defer sb.close()
+
+	t := er.Spec.RestoreTarget
+	name, err := backend.FindBackupAt(sb.be, t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find backup at restore target: %v", err)
+	}
+	if len(name) == 0 {
+		return nil, fmt.Errorf("no backup at or before the restore target (targetRevision: %d, targetTime: %q)", t.TargetRevision, t.TargetTime)
+	}
+	chain, err := readChain(sb.be.Open, name)
+	if err != nil {
+		return nil, err
+	}
+	rev, err := backend.RestoreRevision(name, chain, t)
+	if err != nil {
+		return nil, err
+	}
+	return &api.RestoredSnapshot{Backup: sb.path(name), Revision: rev}, nil
+}
+
+func newSourceBackend(kubecli kubernetes.Interface, namespace string, rs *api.RestoreSource) (*sourceBackend, error) {
+	var (
+		sb  *sourceBackend
+		err error
+	)
+	switch {
+	case rs.S3 != nil:
+		sb, err = newS3SourceBackend(kubecli, namespace, rs.S3)
+	case rs.ABS != nil:
+		sb, err = newABSSourceBackend(kubecli, namespace, rs.ABS)
+	case rs.Swift != nil:
+		sb, err = newSwiftSourceBackend(kubecli, namespace, rs.Swift)
+	case rs.GCS != nil:
+		sb, err = newGCSSourceBackend(kubecli, namespace, rs.GCS)
+	case rs.PV != nil:
+		sb, err = newPVSourceBackend(rs.PV)
+	case rs.HTTP != nil:
+		return nil, errors.New("restore targets are not supported with http restore sources")
+	default:
+		return nil, errors.New("restore CR must have a restore source specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rs.Encryption != nil {
+		kr, err := k8sutil.GetEncryptionKeyring(kubecli, namespace, rs.Encryption)
+		if err != nil {
+			sb.close()
+			return nil, err
+		}
+		sb.be = backend.NewEncryptedBackend(sb.be, kr)
+	}
+	return sb, nil
+}
+
+// splitSourceDir splits the directory path of a restore source
+// of the form "<bucket-or-container>/<dir>" into its bucket or container and directory.
+func splitSourceDir(p string) (string, string, error) {
+	return util.ParseBucketAndKey(strings.TrimSuffix(path.Clean(p), "/"))
+}
+
+// trimV1Prefix returns the prefix of the storage for the directory dir of ABS and GCS backups,
+// which must be in the "v1/" layout of the backups the etcd operator takes.
+func trimV1Prefix(dir string) (string, error) {
+	if !strings.HasPrefix(dir, v1Prefix) || len(dir) == len(v1Prefix) {
+		return "", fmt.Errorf("backup directory (%v) must be within %v", dir, v1Prefix)
+	}
+	return dir[len(v1Prefix):], nil
+}
+
+func newS3SourceBackend(kubecli kubernetes.Interface, namespace string, ss *api.S3RestoreSource) (*sourceBackend, error) {
+	if len(ss.AWSSecret) == 0 || len(ss.Path) == 0 {
+		return nil, errors.New("invalid s3 restore source field (spec.s3), must specify all required subfields")
+	}
+	bucket, dir, err := splitSourceDir(ss.Path)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := s3factory.NewClientFromSecret(kubecli, namespace, ss.AWSSecret, ss.S3Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %v", err)
+	}
+	s3cli := s3.NewFromClient(bucket, dir, cli.S3)
+	return &sourceBackend{
+		be:    backend.NewS3Backend(s3cli),
+		path:  s3cli.Path,
+		close: cli.Close,
+	}, nil
+}
+
+func newABSSourceBackend(kubecli kubernetes.Interface, namespace string, as *api.ABSRestoreSource) (*sourceBackend, error) {
+	if len(as.ABSSecret) == 0 || len(as.Path) == 0 {
+		return nil, errors.New("invalid abs restore source field (spec.abs), must specify all required subfields")
+	}
+	container, dir, err := splitSourceDir(as.Path)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := trimV1Prefix(dir)
+	if err != nil {
+		return nil, err
+	}
+	account, key, err := backupstorage.GetABSCreds(kubecli, namespace, as.ABSSecret)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := abs.New(container, account, key, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ABS client: %v", err)
+	}
+	return &sourceBackend{
+		be:    backend.NewAbsBackend(cli),
+		path:  cli.Path,
+		close: func() {},
+	}, nil
+}
+
+func newSwiftSourceBackend(kubecli kubernetes.Interface, namespace string, ss *api.SwiftRestoreSource) (*sourceBackend, error) {
+	if len(ss.SwiftSecret) == 0 || len(ss.Path) == 0 {
+		return nil, errors.New("invalid swift restore source field (spec.swift), must specify all required subfields")
+	}
+	container, dir, err := splitSourceDir(ss.Path)
+	if err != nil {
+		return nil, err
+	}
+	ao, err := backupstorage.GetSwiftCreds(kubecli, namespace, ss.SwiftSecret)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := swift.NewFromAuthOpt(container, ss.SwiftRegion, dir, ao)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Swift client: %v", err)
+	}
+	return &sourceBackend{
+		be:    backend.NewSwiftBackend(cli),
+		path:  cli.Path,
+		close: func() {},
+	}, nil
+}
+
+func newGCSSourceBackend(kubecli kubernetes.Interface, namespace string, gs *api.GCSRestoreSource) (*sourceBackend, error) {
+	if len(gs.GCSSecret) == 0 || len(gs.Path) == 0 {
+		return nil, errors.New("invalid gcs restore source field (spec.gcs), must specify all required subfields")
+	}
+	bucket, dir, err := splitSourceDir(gs.Path)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := trimV1Prefix(dir)
+	if err != nil {
+		return nil, err
+	}
+	saJSON, err := backupstorage.GetGCSCreds(kubecli, namespace, gs.GCSSecret)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := gcs.New(bucket, prefix, saJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &sourceBackend{
+		be:    backend.NewGCSBackend(cli),
+		path:  cli.Path,
+		close: func() {},
+	}, nil
+}
+
+// newPVSourceBackend returns the backend over a directory of the backup volume
+// mounted at constants.BackupMountDir in the restore operator pod.
+func newPVSourceBackend(ps *api.PVRestoreSource) (*sourceBackend, error) {
+	if len(ps.Path) == 0 {
+		return nil, errors.New("invalid pv restore source field (spec.pv), must specify all required subfields")
+	}
+	dir := path.Clean(ps.Path)
+	if !path.IsAbs(dir) {
+		dir = path.Join(constants.BackupMountDir, dir)
+	}
+	if !strings.HasPrefix(dir, constants.BackupMountDir+"/") {
+		return nil, fmt.Errorf("backup directory (%v) is not within %v", ps.Path, constants.BackupMountDir)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("failed to read backup directory (%v): %v", dir, err)
+	}
+	return &sourceBackend{
+		be:    backend.NewFileBackend(dir),
+		path:  func(name string) string { return path.Join(dir, name) },
+		close: func() {},
+	}, nil
+}
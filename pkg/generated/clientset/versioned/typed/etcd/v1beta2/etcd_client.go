@@ -25,6 +25,7 @@ import (
 type EtcdV1beta2Interface interface {
 	RESTClient() rest.Interface
 	EtcdBackupsGetter
+	EtcdBackupSchedulesGetter
 	EtcdClustersGetter
 	EtcdRestoresGetter
 }
@@ -38,6 +39,10 @@ func (c *EtcdV1beta2Client) EtcdBackups(namespace string) EtcdBackupInterface {
 	return newEtcdBackups(c, namespace)
 }
 
+func (c *EtcdV1beta2Client) EtcdBackupSchedules(namespace string) EtcdBackupScheduleInterface {
+	return newEtcdBackupSchedules(c, namespace)
+}
+
 func (c *EtcdV1beta2Client) EtcdClusters(namespace string) EtcdClusterInterface {
 	return newEtcdClusters(c, namespace)
 }
@@ -0,0 +1,171 @@
+/*
+Copyright 2017 The etcd-operator Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta2
+
+import (
+	v1beta2 "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	scheme "github.com/coreos/etcd-operator/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// EtcdBackupSchedulesGetter has a method to return a EtcdBackupScheduleInterface.
+// A group's client should implement this interface.
+type EtcdBackupSchedulesGetter interface {
+	EtcdBackupSchedules(namespace string) EtcdBackupScheduleInterface
+}
+
+// EtcdBackupScheduleInterface has methods to work with EtcdBackupSchedule resources.
+type EtcdBackupScheduleInterface interface {
+	Create(*v1beta2.EtcdBackupSchedule) (*v1beta2.EtcdBackupSchedule, error)
+	Update(*v1beta2.EtcdBackupSchedule) (*v1beta2.EtcdBackupSchedule, error)
+	UpdateStatus(*v1beta2.EtcdBackupSchedule) (*v1beta2.EtcdBackupSchedule, error)
+	Delete(name string, options *v1.DeleteOptions) error
+	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
+	Get(name string, options v1.GetOptions) (*v1beta2.EtcdBackupSchedule, error)
+	List(opts v1.ListOptions) (*v1beta2.EtcdBackupScheduleList, error)
+	Watch(opts v1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta2.EtcdBackupSchedule, err error)
+	EtcdBackupScheduleExpansion
+}
+
+// etcdBackupSchedules implements EtcdBackupScheduleInterface
+type etcdBackupSchedules struct {
+	client rest.Interface
+	ns     string
+}
+
+// newEtcdBackupSchedules returns a EtcdBackupSchedules
+func newEtcdBackupSchedules(c *EtcdV1beta2Client, namespace string) *etcdBackupSchedules {
+	return &etcdBackupSchedules{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the etcdBackupSchedule, and returns the corresponding etcdBackupSchedule object, and an error if there is any.
+func (c *etcdBackupSchedules) Get(name string, options v1.GetOptions) (result *v1beta2.EtcdBackupSchedule, err error) {
+	result = &v1beta2.EtcdBackupSchedule{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of EtcdBackupSchedules that match those selectors.
+func (c *etcdBackupSchedules) List(opts v1.ListOptions) (result *v1beta2.EtcdBackupScheduleList, err error) {
+	result = &v1beta2.EtcdBackupScheduleList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested etcdBackupSchedules.
+func (c *etcdBackupSchedules) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+// Create takes the representation of a etcdBackupSchedule and creates it.  Returns the server's representation of the etcdBackupSchedule, and an error, if there is any.
+func (c *etcdBackupSchedules) Create(etcdBackupSchedule *v1beta2.EtcdBackupSchedule) (result *v1beta2.EtcdBackupSchedule, err error) {
+	result = &v1beta2.EtcdBackupSchedule{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		Body(etcdBackupSchedule).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a etcdBackupSchedule and updates it. Returns the server's representation of the etcdBackupSchedule, and an error, if there is any.
+func (c *etcdBackupSchedules) Update(etcdBackupSchedule *v1beta2.EtcdBackupSchedule) (result *v1beta2.EtcdBackupSchedule, err error) {
+	result = &v1beta2.EtcdBackupSchedule{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		Name(etcdBackupSchedule.Name).
+		Body(etcdBackupSchedule).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *etcdBackupSchedules) UpdateStatus(etcdBackupSchedule *v1beta2.EtcdBackupSchedule) (result *v1beta2.EtcdBackupSchedule, err error) {
+	result = &v1beta2.EtcdBackupSchedule{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		Name(etcdBackupSchedule.Name).
+		SubResource("status").
+		Body(etcdBackupSchedule).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the etcdBackupSchedule and deletes it. Returns an error if one occurs.
+func (c *etcdBackupSchedules) Delete(name string, options *v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *etcdBackupSchedules) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched etcdBackupSchedule.
+func (c *etcdBackupSchedules) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta2.EtcdBackupSchedule, err error) {
+	result = &v1beta2.EtcdBackupSchedule{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("etcdbackupschedules").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -29,6 +29,10 @@ func (c *FakeEtcdV1beta2) EtcdBackups(namespace string) v1beta2.EtcdBackupInterf
 	return &FakeEtcdBackups{c, namespace}
 }
 
+func (c *FakeEtcdV1beta2) EtcdBackupSchedules(namespace string) v1beta2.EtcdBackupScheduleInterface {
+	return &FakeEtcdBackupSchedules{c, namespace}
+}
+
 func (c *FakeEtcdV1beta2) EtcdClusters(namespace string) v1beta2.EtcdClusterInterface {
 	return &FakeEtcdClusters{c, namespace}
 }
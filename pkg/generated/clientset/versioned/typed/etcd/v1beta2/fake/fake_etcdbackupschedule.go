@@ -0,0 +1,137 @@
+/*
+Copyright 2017 The etcd-operator Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package fake
+
+import (
+	v1beta2 "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeEtcdBackupSchedules implements EtcdBackupScheduleInterface
+type FakeEtcdBackupSchedules struct {
+	Fake *FakeEtcdV1beta2
+	ns   string
+}
+
+var etcdbackupschedulesResource = schema.GroupVersionResource{Group: "etcd.database.coreos.com", Version: "v1beta2", Resource: "etcdbackupschedules"}
+
+var etcdbackupschedulesKind = schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdBackupSchedule"}
+
+// Get takes name of the etcdBackupSchedule, and returns the corresponding etcdBackupSchedule object, and an error if there is any.
+func (c *FakeEtcdBackupSchedules) Get(name string, options v1.GetOptions) (result *v1beta2.EtcdBackupSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(etcdbackupschedulesResource, c.ns, name), &v1beta2.EtcdBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdBackupSchedule), err
+}
+
+// List takes label and field selectors, and returns the list of EtcdBackupSchedules that match those selectors.
+func (c *FakeEtcdBackupSchedules) List(opts v1.ListOptions) (result *v1beta2.EtcdBackupScheduleList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(etcdbackupschedulesResource, etcdbackupschedulesKind, c.ns, opts), &v1beta2.EtcdBackupScheduleList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta2.EtcdBackupScheduleList{}
+	for _, item := range obj.(*v1beta2.EtcdBackupScheduleList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested etcdBackupSchedules.
+func (c *FakeEtcdBackupSchedules) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(etcdbackupschedulesResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a etcdBackupSchedule and creates it.  Returns the server's representation of the etcdBackupSchedule, and an error, if there is any.
+func (c *FakeEtcdBackupSchedules) Create(etcdBackupSchedule *v1beta2.EtcdBackupSchedule) (result *v1beta2.EtcdBackupSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(etcdbackupschedulesResource, c.ns, etcdBackupSchedule), &v1beta2.EtcdBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdBackupSchedule), err
+}
+
+// Update takes the representation of a etcdBackupSchedule and updates it. Returns the server's representation of the etcdBackupSchedule, and an error, if there is any.
+func (c *FakeEtcdBackupSchedules) Update(etcdBackupSchedule *v1beta2.EtcdBackupSchedule) (result *v1beta2.EtcdBackupSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(etcdbackupschedulesResource, c.ns, etcdBackupSchedule), &v1beta2.EtcdBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdBackupSchedule), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeEtcdBackupSchedules) UpdateStatus(etcdBackupSchedule *v1beta2.EtcdBackupSchedule) (*v1beta2.EtcdBackupSchedule, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(etcdbackupschedulesResource, "status", c.ns, etcdBackupSchedule), &v1beta2.EtcdBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdBackupSchedule), err
+}
+
+// Delete takes name of the etcdBackupSchedule and deletes it. Returns an error if one occurs.
+func (c *FakeEtcdBackupSchedules) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(etcdbackupschedulesResource, c.ns, name), &v1beta2.EtcdBackupSchedule{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeEtcdBackupSchedules) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(etcdbackupschedulesResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &v1beta2.EtcdBackupScheduleList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched etcdBackupSchedule.
+func (c *FakeEtcdBackupSchedules) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta2.EtcdBackupSchedule, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(etcdbackupschedulesResource, c.ns, name, data, subresources...), &v1beta2.EtcdBackupSchedule{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta2.EtcdBackupSchedule), err
+}
@@ -17,6 +17,8 @@ package v1beta2
 
 type EtcdBackupExpansion interface{}
 
+type EtcdBackupScheduleExpansion interface{}
+
 type EtcdClusterExpansion interface{}
 
 type EtcdRestoreExpansion interface{}
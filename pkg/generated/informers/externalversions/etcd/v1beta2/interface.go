@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// EtcdBackups returns a EtcdBackupInformer.
 	EtcdBackups() EtcdBackupInformer
+	// EtcdBackupSchedules returns a EtcdBackupScheduleInformer.
+	EtcdBackupSchedules() EtcdBackupScheduleInformer
 	// EtcdClusters returns a EtcdClusterInformer.
 	EtcdClusters() EtcdClusterInformer
 	// EtcdRestores returns a EtcdRestoreInformer.
@@ -46,6 +48,11 @@ func (v *version) EtcdBackups() EtcdBackupInformer {
 	return &etcdBackupInformer{factory: v.SharedInformerFactory}
 }
 
+// EtcdBackupSchedules returns a EtcdBackupScheduleInformer.
+func (v *version) EtcdBackupSchedules() EtcdBackupScheduleInformer {
+	return &etcdBackupScheduleInformer{factory: v.SharedInformerFactory}
+}
+
 // EtcdClusters returns a EtcdClusterInformer.
 func (v *version) EtcdClusters() EtcdClusterInformer {
 	return &etcdClusterInformer{factory: v.SharedInformerFactory}
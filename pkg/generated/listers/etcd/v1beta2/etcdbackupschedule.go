@@ -0,0 +1,94 @@
+/*
+Copyright 2017 The etcd-operator Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was automatically generated by lister-gen
+
+package v1beta2
+
+import (
+	v1beta2 "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EtcdBackupScheduleLister helps list EtcdBackupSchedules.
+type EtcdBackupScheduleLister interface {
+	// List lists all EtcdBackupSchedules in the indexer.
+	List(selector labels.Selector) (ret []*v1beta2.EtcdBackupSchedule, err error)
+	// EtcdBackupSchedules returns an object that can list and get EtcdBackupSchedules.
+	EtcdBackupSchedules(namespace string) EtcdBackupScheduleNamespaceLister
+	EtcdBackupScheduleListerExpansion
+}
+
+// etcdBackupScheduleLister implements the EtcdBackupScheduleLister interface.
+type etcdBackupScheduleLister struct {
+	indexer cache.Indexer
+}
+
+// NewEtcdBackupScheduleLister returns a new EtcdBackupScheduleLister.
+func NewEtcdBackupScheduleLister(indexer cache.Indexer) EtcdBackupScheduleLister {
+	return &etcdBackupScheduleLister{indexer: indexer}
+}
+
+// List lists all EtcdBackupSchedules in the indexer.
+func (s *etcdBackupScheduleLister) List(selector labels.Selector) (ret []*v1beta2.EtcdBackupSchedule, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta2.EtcdBackupSchedule))
+	})
+	return ret, err
+}
+
+// EtcdBackupSchedules returns an object that can list and get EtcdBackupSchedules.
+func (s *etcdBackupScheduleLister) EtcdBackupSchedules(namespace string) EtcdBackupScheduleNamespaceLister {
+	return etcdBackupScheduleNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// EtcdBackupScheduleNamespaceLister helps list and get EtcdBackupSchedules.
+type EtcdBackupScheduleNamespaceLister interface {
+	// List lists all EtcdBackupSchedules in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1beta2.EtcdBackupSchedule, err error)
+	// Get retrieves the EtcdBackupSchedule from the indexer for a given namespace and name.
+	Get(name string) (*v1beta2.EtcdBackupSchedule, error)
+	EtcdBackupScheduleNamespaceListerExpansion
+}
+
+// etcdBackupScheduleNamespaceLister implements the EtcdBackupScheduleNamespaceLister
+// interface.
+type etcdBackupScheduleNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all EtcdBackupSchedules in the indexer for a given namespace.
+func (s etcdBackupScheduleNamespaceLister) List(selector labels.Selector) (ret []*v1beta2.EtcdBackupSchedule, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta2.EtcdBackupSchedule))
+	})
+	return ret, err
+}
+
+// Get retrieves the EtcdBackupSchedule from the indexer for a given namespace and name.
+func (s etcdBackupScheduleNamespaceLister) Get(name string) (*v1beta2.EtcdBackupSchedule, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1beta2.Resource("etcdbackup"), name)
+	}
+	return obj.(*v1beta2.EtcdBackupSchedule), nil
+}
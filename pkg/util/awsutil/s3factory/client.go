@@ -15,6 +15,7 @@
 package s3factory
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -22,6 +23,7 @@ import (
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,7 +41,8 @@ type S3Client struct {
 }
 
 // NewClientFromSecret returns a S3 client based on given k8s secret containing aws credentials.
-func NewClientFromSecret(kubecli kubernetes.Interface, namespace, awsSecret string) (w *S3Client, err error) {
+// The client talks to the S3-compatible store described by ep if its endpoint is set.
+func NewClientFromSecret(kubecli kubernetes.Interface, namespace, awsSecret string, ep api.S3Endpoint) (w *S3Client, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("new S3 client failed: %v", err)
@@ -54,6 +57,14 @@ func NewClientFromSecret(kubecli kubernetes.Interface, namespace, awsSecret stri
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup aws config: (%v)", err)
 	}
+	var caBundle []byte
+	if len(ep.CASecret) != 0 {
+		caBundle, err = getCABundle(kubecli, namespace, ep.CASecret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ApplyS3Endpoint(so, ep, caBundle)
 	sess, err := session.NewSessionWithOptions(*so)
 	if err != nil {
 		return nil, fmt.Errorf("new AWS session failed: %v", err)
@@ -67,6 +78,35 @@ func (w *S3Client) Close() {
 	os.RemoveAll(w.configDir)
 }
 
+// ApplyS3Endpoint points the session options at the S3-compatible store described by ep.
+// caBundle, if not empty, is used to verify the certificate of the endpoint.
+func ApplyS3Endpoint(so *session.Options, ep api.S3Endpoint, caBundle []byte) {
+	if len(ep.Endpoint) != 0 {
+		so.Config.Endpoint = aws.String(ep.Endpoint)
+	}
+	if len(ep.Region) != 0 {
+		so.Config.Region = aws.String(ep.Region)
+	}
+	if ep.ForcePathStyle {
+		so.Config.S3ForcePathStyle = aws.Bool(true)
+	}
+	if len(caBundle) != 0 {
+		so.CustomCABundle = bytes.NewReader(caBundle)
+	}
+}
+
+func getCABundle(kubecli kubernetes.Interface, ns, secret string) ([]byte, error) {
+	se, err := kubecli.CoreV1().Secrets(ns).Get(secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get S3 CA bundle failed: get k8s secret failed: %v", err)
+	}
+	caBundle := se.Data[api.S3CABundleFileName]
+	if len(caBundle) == 0 {
+		return nil, fmt.Errorf("get S3 CA bundle failed: secret (%s) has no %s", secret, api.S3CABundleFileName)
+	}
+	return caBundle, nil
+}
+
 // setupAWSConfig setup local AWS config/credential files from Kubernetes aws secret.
 func setupAWSConfig(kubecli kubernetes.Interface, ns, secret, configDir string) (*session.Options, error) {
 	options := &session.Options{}
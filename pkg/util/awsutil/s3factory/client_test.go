@@ -0,0 +1,52 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3factory
+
+import (
+	"testing"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+func TestApplyS3Endpoint(t *testing.T) {
+	so := &session.Options{}
+	ApplyS3Endpoint(so, api.S3Endpoint{}, nil)
+	if so.Config.Endpoint != nil || so.Config.Region != nil || so.Config.S3ForcePathStyle != nil || so.CustomCABundle != nil {
+		t.Errorf("empty endpoint should leave the AWS config untouched, get=%+v", so)
+	}
+
+	so = &session.Options{}
+	ep := api.S3Endpoint{
+		Endpoint:       "https://minio.example.com:9000",
+		Region:         "us-east-1",
+		ForcePathStyle: true,
+	}
+	ApplyS3Endpoint(so, ep, []byte("ca"))
+	if aws.StringValue(so.Config.Endpoint) != ep.Endpoint {
+		t.Errorf("endpoint = %s, want %s", aws.StringValue(so.Config.Endpoint), ep.Endpoint)
+	}
+	if aws.StringValue(so.Config.Region) != ep.Region {
+		t.Errorf("region = %s, want %s", aws.StringValue(so.Config.Region), ep.Region)
+	}
+	if !aws.BoolValue(so.Config.S3ForcePathStyle) {
+		t.Error("expect path-style addressing to be forced")
+	}
+	if so.CustomCABundle == nil {
+		t.Error("expect custom CA bundle to be set")
+	}
+}
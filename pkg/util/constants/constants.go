@@ -30,4 +30,8 @@ const (
 	EnvOperatorPodName            = "MY_POD_NAME"
 	EnvOperatorPodNamespace       = "MY_POD_NAMESPACE"
 	EnvRestoreOperatorServiceName = "SERVICE_ADDR"
+	// EnvRestoreOperatorTLSSecret and EnvRestoreOperatorClientCASecret name the secrets
+	// the restore operator secures its backup API with.
+	EnvRestoreOperatorTLSSecret      = "TLS_SECRET"
+	EnvRestoreOperatorClientCASecret = "CLIENT_CA_SECRET"
 )
@@ -0,0 +1,223 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cronutil parses cron expressions and computes their activation times.
+package cronutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression. Its activation times are in UTC.
+type Schedule struct {
+	// The fields are bit sets of the values they match.
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are true if the day of month or the day of week field starts with "*".
+	// If both day fields are restricted, a day matches if either field does.
+	domStar, dowStar bool
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type bounds struct {
+	name     string
+	min, max uint
+}
+
+var (
+	minuteBounds = bounds{"minute", 0, 59}
+	hourBounds   = bounds{"hour", 0, 23}
+	domBounds    = bounds{"day of month", 1, 31}
+	monthBounds  = bounds{"month", 1, 12}
+	// 7 is accepted for Sunday as well as 0.
+	dowBounds = bounds{"day of week", 0, 7}
+)
+
+// Parse parses a standard cron expression of five fields:
+// minute, hour, day of month, month and day of week.
+// A field is "*" or a comma-separated list of values or ranges "a-b",
+// each optionally followed by a step "/n". The macros "@hourly", "@daily",
+// "@midnight", "@weekly", "@monthly", "@yearly" and "@annually" are accepted too.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if m, ok := macros[spec]; ok {
+		spec = m
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, has %d", spec, len(fields))
+	}
+
+	s := &Schedule{
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}
+	var err error
+	for i, f := range []struct {
+		bits *uint64
+		b    bounds
+	}{
+		{&s.minute, minuteBounds},
+		{&s.hour, hourBounds},
+		{&s.dom, domBounds},
+		{&s.month, monthBounds},
+		{&s.dow, dowBounds},
+	} {
+		*f.bits, err = parseField(fields[i], f.b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// Validate returns an error if spec cannot be parsed, or if it never activates from now on, e.g. "0 0 30 2 *".
+func Validate(spec string) error {
+	s, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	if s.Next(time.Now()).IsZero() {
+		return fmt.Errorf("cron expression %q never activates", spec)
+	}
+	return nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, uint(1)
+		hasStep := false
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng, hasStep = part[:i], true
+			step, err = parseValue(part[i+1:], b.name)
+			if err != nil {
+				return 0, err
+			}
+			if step == 0 {
+				return 0, fmt.Errorf("%s step must be > 0", b.name)
+			}
+		}
+
+		var lo, hi uint
+		switch i := strings.Index(rng, "-"); {
+		case rng == "*":
+			lo, hi = b.min, b.max
+		case i >= 0:
+			var err error
+			if lo, err = parseValue(rng[:i], b.name); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(rng[i+1:], b.name); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if lo, err = parseValue(rng, b.name); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if hasStep {
+				// "a/n" means every n from a.
+				hi = b.max
+			}
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, fmt.Errorf("%s range %q is out of %d-%d", b.name, rng, b.min, b.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s, name string) (uint, error) {
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("bad %s value %q", name, s)
+	}
+	return uint(v), nil
+}
+
+// Next returns the first activation time of the schedule strictly after t.
+// It returns the zero time if there is none within five years, e.g. for "0 0 30 2 *".
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// Last returns the last activation time of the schedule strictly after after and at or before now,
+// or the zero time if there is none.
+func (s *Schedule) Last(after, now time.Time) time.Time {
+	var last time.Time
+	for t := s.Next(after); !t.IsZero() && !t.After(now); t = s.Next(t) {
+		last = t
+	}
+	return last
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
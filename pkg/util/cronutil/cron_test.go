@@ -0,0 +1,129 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cronutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 5m",
+	}
+	for i, tt := range tests {
+		if _, err := Parse(tt); err == nil {
+			t.Errorf("#%d: expect error parsing %q", i, tt)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Nov 6 2017 is a Monday.
+	now := time.Date(2017, 11, 6, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2017, 11, 6, 10, 31, 0, 0, time.UTC)},
+		{"30 * * * *", time.Date(2017, 11, 6, 11, 30, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2017, 11, 6, 10, 40, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2017, 11, 6, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2017, 11, 6, 13, 0, 0, 0, time.UTC)},
+		{"0 3,10 * * *", time.Date(2017, 11, 7, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2017, 11, 7, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2017, 11, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2017, 11, 12, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// either day field matches if both are restricted
+		{"0 0 1 * 3", time.Date(2017, 11, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for i, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("#%d: failed to parse %q: %v", i, tt.spec, err)
+		}
+		if next := s.Next(now); !next.Equal(tt.next) {
+			t.Errorf("#%d: next of %q = %v, want %v", i, tt.spec, next, tt.next)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		spec  string
+		valid bool
+	}{
+		{"@daily", true},
+		{"0 0 29 2 *", true},
+		{"* * * *", false},
+		// February 30th never comes.
+		{"0 0 30 2 *", false},
+	}
+	for i, tt := range tests {
+		if err := Validate(tt.spec); (err == nil) != tt.valid {
+			t.Errorf("#%d: validate %q = %v, want valid=%v", i, tt.spec, err, tt.valid)
+		}
+	}
+}
+
+func TestLast(t *testing.T) {
+	after := time.Date(2017, 11, 6, 10, 30, 15, 0, time.UTC)
+	now := time.Date(2017, 11, 6, 13, 10, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		last time.Time
+	}{
+		{"0 * * * *", time.Date(2017, 11, 6, 13, 0, 0, 0, time.UTC)},
+		{"10 13 * * *", now},
+		{"@daily", time.Time{}},
+		// never activates.
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for i, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("#%d: failed to parse %q: %v", i, tt.spec, err)
+		}
+		if last := s.Last(after, now); !last.Equal(tt.last) {
+			t.Errorf("#%d: last of %q = %v, want %v", i, tt.spec, last, tt.last)
+		}
+	}
+}
+
+func TestNextOnActivation(t *testing.T) {
+	s, err := Parse("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2017, 11, 6, 10, 0, 0, 0, time.UTC)
+	if next, want := s.Next(at), at.Add(time.Hour); !next.Equal(want) {
+		t.Errorf("next = %v, want %v", next, want)
+	}
+}
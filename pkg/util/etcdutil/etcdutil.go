@@ -78,3 +78,55 @@ func CheckHealth(url string, tc *tls.Config) (bool, error) {
 	}
 	return true, nil
 }
+
+// GetLeaderID returns the ID of the leader as seen by the first member that answers.
+func GetLeaderID(clientURLs []string, tc *tls.Config) (uint64, error) {
+	cfg := clientv3.Config{
+		Endpoints:   clientURLs,
+		DialTimeout: constants.DefaultDialTimeout,
+		TLS:         tc,
+	}
+	etcdcli, err := clientv3.New(cfg)
+	if err != nil {
+		return 0, fmt.Errorf("get leader failed: creating etcd client failed: %v", err)
+	}
+	defer etcdcli.Close()
+
+	var lastErr error
+	for _, url := range clientURLs {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+		resp, err := etcdcli.Status(ctx, url)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.Leader != 0 {
+			return resp.Leader, nil
+		}
+		lastErr = fmt.Errorf("member at %s has no leader", url)
+	}
+	return 0, fmt.Errorf("get leader failed: %v", lastErr)
+}
+
+// GetDBSize returns the size of the backend database of the member serving at url.
+func GetDBSize(url string, tc *tls.Config) (int64, error) {
+	cfg := clientv3.Config{
+		Endpoints:   []string{url},
+		DialTimeout: constants.DefaultDialTimeout,
+		TLS:         tc,
+	}
+	etcdcli, err := clientv3.New(cfg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create etcd client for %s: %v", url, err)
+	}
+	defer etcdcli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	resp, err := etcdcli.Status(ctx, url)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get status of %s: %v", url, err)
+	}
+	return resp.DbSize, nil
+}
@@ -17,6 +17,7 @@ package k8sutil
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"path"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 )
@@ -38,6 +40,10 @@ const (
 	backupPVVolName           = "etcd-backup-storage"
 	awsCredentialDir          = "/root/.aws/"
 	awsSecretVolName          = "secret-aws"
+	s3CADir                   = "/etc/s3-ca/"
+	s3CAVolName               = "secret-s3-ca"
+	gcsCredentialDir          = "/etc/gcs/"
+	gcsSecretVolName          = "secret-gcs"
 	fromDirMountDir           = "/mnt/backup/from"
 
 	PVBackupV1 = "v1" // TODO: refactor and combine this with pkg/backup.PVBackupV1
@@ -124,6 +130,26 @@ func AttachS3ToPodSpec(ps *v1.PodSpec, ss api.S3Source) {
 		Name:  backupenv.AWSS3Bucket,
 		Value: ss.S3Bucket,
 	})
+
+	if len(ss.CASecret) != 0 {
+		ps.Containers[0].VolumeMounts = append(ps.Containers[0].VolumeMounts, v1.VolumeMount{
+			Name:      s3CAVolName,
+			MountPath: s3CADir,
+			ReadOnly:  true,
+		})
+		ps.Volumes = append(ps.Volumes, v1.Volume{
+			Name: s3CAVolName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: ss.CASecret,
+				},
+			},
+		})
+		ps.Containers[0].Env = append(ps.Containers[0].Env, v1.EnvVar{
+			Name:  backupenv.AWSCABundle,
+			Value: path.Join(s3CADir, api.S3CABundleFileName),
+		})
+	}
 }
 
 // AttachABSToPodSpec attaches ABS credentials to a Pod
@@ -149,6 +175,30 @@ func AttachABSToPodSpec(ps *v1.PodSpec, ws api.ABSSource) {
 	})
 }
 
+// AttachGCSToPodSpec attaches GCS credentials to a Pod
+func AttachGCSToPodSpec(ps *v1.PodSpec, gs api.GCSSource) {
+	ps.Containers[0].VolumeMounts = append(ps.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      gcsSecretVolName,
+		MountPath: gcsCredentialDir,
+		ReadOnly:  true,
+	})
+	ps.Volumes = append(ps.Volumes, v1.Volume{
+		Name: gcsSecretVolName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: gs.GCSSecret,
+			},
+		},
+	})
+	ps.Containers[0].Env = append(ps.Containers[0].Env, v1.EnvVar{
+		Name:  backupenv.GCSBucket,
+		Value: gs.GCSBucket,
+	}, v1.EnvVar{
+		Name:  backupenv.GCSCredentials,
+		Value: path.Join(gcsCredentialDir, api.GCSServiceAccountJSON),
+	})
+}
+
 // AttachSwiftToPodSpec attaches Swift credentials to a Pod
 func AttachSwiftToPodSpec(ps *v1.PodSpec, s api.SwiftSource) {
 	identityEndpointSelector := v1.SecretKeySelector{
@@ -195,7 +245,7 @@ func AttachSwiftToPodSpec(ps *v1.PodSpec, s api.SwiftSource) {
 	})
 }
 
-func NewBackupPodTemplate(clusterName, account string, sp api.ClusterSpec) v1.PodTemplateSpec {
+func NewBackupPodTemplate(clusterName string, clusterUID types.UID, account string, sp api.ClusterSpec) v1.PodTemplateSpec {
 	b, err := json.Marshal(sp)
 	if err != nil {
 		panic("unexpected json error " + err.Error())
@@ -217,6 +267,9 @@ func NewBackupPodTemplate(clusterName, account string, sp api.ClusterSpec) v1.Po
 				}, {
 					Name:  backupenv.ClusterSpec,
 					Value: string(b),
+				}, {
+					Name:  backupenv.ClusterUID,
+					Value: string(clusterUID),
 				}},
 			},
 		},
@@ -388,3 +441,84 @@ func BackupSidecarLabels(clusterName string) map[string]string {
 		"etcd_cluster": clusterName,
 	}
 }
+
+const (
+	backupVerificationAppField  = "etcd_backup_verification"
+	backupVerificationMember    = "verify"
+	backupVerificationPeerURL   = "http://127.0.0.1:2380"
+	backupVerificationClientURL = "http://127.0.0.1:2379"
+	// BackupVerificationContainerName is the name of the container of the verification pod
+	// that terminates with the key count of the restored backup as its termination message.
+	BackupVerificationContainerName = "verify-backup"
+)
+
+func BackupVerificationPodName(clusterName string) string {
+	return fmt.Sprintf("%s-backup-verification", clusterName)
+}
+
+// NewBackupVerificationPod returns a Pod manifest that fetches the backup from backupURL,
+// with the credentials of the backup API auth secret authSecret if set,
+// and restores it into a throwaway single-node etcd only serving clients on localhost.
+// The verification container counts the keys of the restored etcd at the revision rev,
+// and writes the count and the revision of the restored etcd, separated by a space, to its termination message.
+// The owner reference is only set if it has a UID.
+func NewBackupVerificationPod(clusterName, baseImage, version string, rev int64, backupURL *url.URL, authSecret string, owner metav1.OwnerReference) *v1.Pod {
+	// The key count is in the "count" field of the JSON range response, which is omitted if there is no key.
+	// The revision of the restored etcd is in the "revision" field of the response header.
+	verifyCmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl snapshot restore %[1]s"+
+		" --name %[2]s"+
+		" --initial-cluster %[2]s=%[3]s"+
+		" --initial-advertise-peer-urls %[3]s"+
+		" --data-dir %[4]s"+
+		" && (etcd --name %[2]s --data-dir %[4]s"+
+		" --listen-peer-urls %[3]s --listen-client-urls %[5]s --advertise-client-urls %[5]s &)"+
+		" && until ETCDCTL_API=3 etcdctl --endpoints %[5]s endpoint health; do sleep 1; done"+
+		" && resp=$(ETCDCTL_API=3 etcdctl --endpoints %[5]s get '' --from-key --keys-only --limit 1 --rev %[6]d -w json)"+
+		` && count=$(echo "$resp" | grep -o '"count":[0-9]*' | cut -d: -f2)`+
+		` && revision=$(echo "$resp" | grep -o '"revision":[0-9]*' | cut -d: -f2)`+
+		" && echo -n ${count:-0} ${revision} > /dev/termination-log",
+		backupFile, backupVerificationMember, backupVerificationPeerURL, dataDir, backupVerificationClientURL, rev)
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: BackupVerificationPodName(clusterName),
+			Labels: map[string]string{
+				"app":          backupVerificationAppField,
+				"etcd_cluster": clusterName,
+			},
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{
+				Name:  "fetch-backup",
+				Image: "tutum/curl",
+				Command: []string{
+					"/bin/sh", "-ec",
+					fmt.Sprintf("curl --compressed --fail%s -o %s '%s'", backupAPIAuthCurlFlags(authSecret), backupFile, backupURL.String()),
+				},
+				VolumeMounts: etcdVolumeMounts(),
+			}},
+			Containers: []v1.Container{{
+				Name:  BackupVerificationContainerName,
+				Image: ImageName(baseImage, version),
+				Command: []string{
+					"/bin/sh", "-ec",
+					verifyCmd,
+				},
+				VolumeMounts:             etcdVolumeMounts(),
+				TerminationMessagePolicy: v1.TerminationMessageFallbackToLogsOnError,
+			}},
+			RestartPolicy: v1.RestartPolicyNever,
+			Volumes: []v1.Volume{{
+				Name:         etcdVolumeName,
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			}},
+		},
+	}
+	if len(authSecret) != 0 {
+		addBackupAPIAuthToPodSpec(&pod.Spec, &pod.Spec.InitContainers[0], authSecret)
+	}
+	if len(owner.UID) != 0 {
+		addOwnerRefToObject(pod.GetObjectMeta(), owner)
+	}
+	return pod
+}
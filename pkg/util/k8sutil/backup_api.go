@@ -0,0 +1,185 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/backupapi"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	backupAPIAuthVolName = "backup-api-auth"
+	backupAPIAuthDir     = "/etc/etcd-backup-api-auth"
+)
+
+// BackupAPIAuthSecretName returns the name of the secret holding the credentials
+// the clients of the backup sidecar API of the cluster authenticate with.
+func BackupAPIAuthSecretName(clusterName string) string {
+	return fmt.Sprintf("%s-backup-sidecar-auth", clusterName)
+}
+
+// RestoreAuthSecretName returns the name of the secret holding the credentials
+// the seed member of the restore CR authenticates to the restore operator with.
+func RestoreAuthSecretName(restoreName string) string {
+	return fmt.Sprintf("%s-restore-auth", restoreName)
+}
+
+// NewBackupAPIServerTLSConfig returns the TLS config of a backup API server
+// serving the certificate of serverSecret and verifying the client certificates with the CA bundle of clientCASecret, if set.
+func NewBackupAPIServerTLSConfig(kubecli kubernetes.Interface, ns, serverSecret, clientCASecret string) (*tls.Config, error) {
+	se, err := kubecli.CoreV1().Secrets(ns).Get(serverSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup API server secret (%s): %v", serverSecret, err)
+	}
+	var clientCA []byte
+	if len(clientCASecret) != 0 {
+		cse, err := kubecli.CoreV1().Secrets(ns).Get(clientCASecret, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get backup API client CA secret (%s): %v", clientCASecret, err)
+		}
+		clientCA = cse.Data[api.BackupAPICAFileName]
+		if len(clientCA) == 0 {
+			return nil, fmt.Errorf("backup API client CA secret (%s) has no %s", clientCASecret, api.BackupAPICAFileName)
+		}
+	}
+	tc, err := backupapi.NewServerTLSConfig(se.Data[api.BackupAPICertFileName], se.Data[api.BackupAPIKeyFileName], clientCA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup API server secret (%s): %v", serverSecret, err)
+	}
+	return tc, nil
+}
+
+// EnsureBackupAPIAuthSecret makes sure that the auth secret of the given name holds a bearer token
+// and the CA bundle of the server secret of a backup API, for its clients to authenticate with.
+// The token of an existing auth secret is kept.
+func EnsureBackupAPIAuthSecret(kubecli kubernetes.Interface, ns, name, serverSecret string, owner metav1.OwnerReference) error {
+	sse, err := kubecli.CoreV1().Secrets(ns).Get(serverSecret, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get backup API server secret (%s): %v", serverSecret, err)
+	}
+	ca := sse.Data[api.BackupAPICAFileName]
+	if len(ca) == 0 {
+		return fmt.Errorf("backup API server secret (%s) has no %s", serverSecret, api.BackupAPICAFileName)
+	}
+
+	secrets := kubecli.CoreV1().Secrets(ns)
+	se, err := secrets.Get(name, metav1.GetOptions{})
+	if err != nil && !IsKubernetesResourceNotFoundError(err) {
+		return fmt.Errorf("failed to get backup API auth secret (%s): %v", name, err)
+	}
+	if err == nil {
+		if len(se.Data[api.BackupAPIAuthToken]) != 0 && bytes.Equal(se.Data[api.BackupAPICAFileName], ca) {
+			return nil
+		}
+		if se.Data == nil {
+			se.Data = map[string][]byte{}
+		}
+		if len(se.Data[api.BackupAPIAuthToken]) == 0 {
+			token, err := backupapi.NewToken()
+			if err != nil {
+				return err
+			}
+			se.Data[api.BackupAPIAuthToken] = []byte(token)
+		}
+		// The CA bundle of the server secret has been rotated.
+		se.Data[api.BackupAPICAFileName] = ca
+		if _, err := secrets.Update(se); err != nil {
+			return fmt.Errorf("failed to update backup API auth secret (%s): %v", name, err)
+		}
+		return nil
+	}
+
+	token, err := backupapi.NewToken()
+	if err != nil {
+		return err
+	}
+	se = &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Data: map[string][]byte{
+			api.BackupAPIAuthToken:  []byte(token),
+			api.BackupAPICAFileName: ca,
+		},
+	}
+	addOwnerRefToObject(se.GetObjectMeta(), owner)
+	if _, err := secrets.Create(se); err != nil {
+		return fmt.Errorf("failed to create backup API auth secret (%s): %v", name, err)
+	}
+	return nil
+}
+
+// GetBackupAPIAuthToken returns the bearer token of the backup API auth secret of the given name.
+func GetBackupAPIAuthToken(kubecli kubernetes.Interface, ns, name string) (string, error) {
+	se, err := kubecli.CoreV1().Secrets(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get backup API auth secret (%s): %v", name, err)
+	}
+	token := string(se.Data[api.BackupAPIAuthToken])
+	if len(token) == 0 {
+		return "", fmt.Errorf("backup API auth secret (%s) has no %s", name, api.BackupAPIAuthToken)
+	}
+	return token, nil
+}
+
+// NewBackupAPIClient returns an HTTP client of a backup API that authenticates
+// with the credentials of the backup API auth secret of the given name.
+func NewBackupAPIClient(kubecli kubernetes.Interface, ns, name string) (*http.Client, error) {
+	se, err := kubecli.CoreV1().Secrets(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup API auth secret (%s): %v", name, err)
+	}
+	c, err := backupapi.NewClient(se.Data[api.BackupAPICAFileName], string(se.Data[api.BackupAPIAuthToken]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup API auth secret (%s): %v", name, err)
+	}
+	return c, nil
+}
+
+// backupAPIAuthCurlFlags returns the curl flags that authenticate a request to a backup API
+// with the auth secret mounted by addBackupAPIAuthToPodSpec, or no flags if authSecret is empty.
+func backupAPIAuthCurlFlags(authSecret string) string {
+	if len(authSecret) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` --cacert %[1]s/%[2]s -H "Authorization: Bearer $(cat %[1]s/%[3]s)"`,
+		backupAPIAuthDir, api.BackupAPICAFileName, api.BackupAPIAuthToken)
+}
+
+// addBackupAPIAuthToPodSpec mounts the backup API auth secret into the given container of the pod spec.
+func addBackupAPIAuthToPodSpec(ps *v1.PodSpec, c *v1.Container, authSecret string) {
+	c.VolumeMounts = append(c.VolumeMounts, v1.VolumeMount{
+		Name:      backupAPIAuthVolName,
+		MountPath: backupAPIAuthDir,
+		ReadOnly:  true,
+	})
+	ps.Volumes = append(ps.Volumes, v1.Volume{
+		Name: backupAPIAuthVolName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: authSecret,
+			},
+		},
+	})
+}
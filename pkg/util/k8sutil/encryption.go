@@ -0,0 +1,39 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sutil
+
+import (
+	"fmt"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/backup/encryption"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GetEncryptionKeyring returns the keyring built from the keys in the secret of the given encryption policy.
+func GetEncryptionKeyring(kubecli kubernetes.Interface, ns string, ep *api.EncryptionPolicy) (*encryption.Keyring, error) {
+	secret, err := kubecli.CoreV1().Secrets(ns).Get(ep.Secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption secret (%s): %v", ep.Secret, err)
+	}
+	kr, err := encryption.NewKeyring(secret.Data, ep.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption secret (%s): %v", ep.Secret, err)
+	}
+	kr.SetAllowUnencrypted(ep.AllowUnencrypted)
+	return kr, nil
+}
@@ -50,6 +50,14 @@ func ReplacingDeadMemberEvent(memberName string, cl *api.EtcdCluster) *v1.Event
 	return event
 }
 
+func RestartingDeadMemberEvent(memberName string, cl *api.EtcdCluster) *v1.Event {
+	event := newClusterEvent(cl)
+	event.Type = v1.EventTypeNormal
+	event.Reason = "Restarting Dead Member"
+	event.Message = fmt.Sprintf("The dead member %s is being restarted on its persistent volume", memberName)
+	return event
+}
+
 func MemberUpgradedEvent(memberName, oldVersion, newVersion string, cl *api.EtcdCluster) *v1.Event {
 	event := newClusterEvent(cl)
 	event.Type = v1.EventTypeNormal
@@ -58,6 +66,22 @@ func MemberUpgradedEvent(memberName, oldVersion, newVersion string, cl *api.Etcd
 	return event
 }
 
+func StorageNearlyFullEvent(memberName string, dbSize, quota int64, cl *api.EtcdCluster) *v1.Event {
+	event := newClusterEvent(cl)
+	event.Type = v1.EventTypeWarning
+	event.Reason = "Storage Nearly Full"
+	event.Message = fmt.Sprintf("Database of member %s uses %d of %d bytes of the backend quota", memberName, dbSize, quota)
+	return event
+}
+
+func VolumeExpandedEvent(memberName string, sizeInMB int, cl *api.EtcdCluster) *v1.Event {
+	event := newClusterEvent(cl)
+	event.Type = v1.EventTypeNormal
+	event.Reason = "Volume Expanded"
+	event.Message = fmt.Sprintf("Member %s restarted to use the backend quota of its volume expanded to %dMi", memberName, sizeInMB)
+	return event
+}
+
 func newClusterEvent(cl *api.EtcdCluster) *v1.Event {
 	t := time.Now()
 	return &v1.Event{
@@ -20,6 +20,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -48,8 +49,12 @@ const (
 
 	etcdVolumeMountDir       = "/var/etcd"
 	dataDir                  = etcdVolumeMountDir + "/data"
+	etcdWALVolumeMountDir    = "/var/etcd-wal"
+	walDir                   = etcdWALVolumeMountDir + "/wal"
 	backupFile               = "/var/etcd/latest.backup"
 	etcdVersionAnnotationKey = "etcd.version"
+	etcdQuotaAnnotationKey   = "etcd.quota-backend-bytes"
+	etcdQuotaEnvName         = "ETCD_QUOTA_BACKEND_BYTES"
 	peerTLSDir               = "/etc/etcdtls/member/peer-tls"
 	peerTLSVolume            = "member-peer-tls"
 	serverTLSDir             = "/etc/etcdtls/member/server-tls"
@@ -60,6 +65,9 @@ const (
 
 const TolerateUnreadyEndpointsAnnotation = "service.alpha.kubernetes.io/tolerate-unready-endpoints"
 
+// maxQuotaBackendBytes is the largest backend quota recommended by etcd.
+const maxQuotaBackendBytes = 8 * 1024 * 1024 * 1024
+
 func GetEtcdVersion(pod *v1.Pod) string {
 	return pod.Annotations[etcdVersionAnnotationKey]
 }
@@ -68,6 +76,30 @@ func SetEtcdVersion(pod *v1.Pod, version string) {
 	pod.Annotations[etcdVersionAnnotationKey] = version
 }
 
+// GetQuotaBackendBytes returns the backend quota the etcd member in the pod was started with.
+func GetQuotaBackendBytes(pod *v1.Pod) string {
+	return pod.Annotations[etcdQuotaAnnotationKey]
+}
+
+// QuotaBackendBytes returns the etcd backend quota derived from the size of the data PV,
+// or 0 if the quota is not managed by the operator.
+func QuotaBackendBytes(cs api.ClusterSpec) int64 {
+	if cs.Pod == nil || cs.Pod.PV == nil {
+		return 0
+	}
+	for _, e := range cs.Pod.EtcdEnv {
+		if e.Name == etcdQuotaEnvName {
+			return 0
+		}
+	}
+	size := int64(cs.Pod.PV.VolumeSizeInMB) * 1024 * 1024
+	quota := size * int64(100-cs.Pod.StorageQuota.Headroom()) / 100
+	if quota > maxQuotaBackendBytes {
+		quota = maxQuotaBackendBytes
+	}
+	return quota
+}
+
 func GetPodNames(pods []*v1.Pod) []string {
 	if len(pods) == 0 {
 		return nil
@@ -79,14 +111,26 @@ func GetPodNames(pods []*v1.Pod) []string {
 	return res
 }
 
-func makeRestoreInitContainers(backupURL *url.URL, token, baseImage, version string, m *etcdutil.Member) []v1.Container {
+func makeRestoreInitContainers(backupURL *url.URL, authSecret, token, baseImage, version string, m *etcdutil.Member, withWAL bool) []v1.Container {
+	restoreCmd := fmt.Sprintf("ETCDCTL_API=3 etcdctl snapshot restore %[1]s"+
+		" --name %[2]s"+
+		" --initial-cluster %[2]s=%[3]s"+
+		" --initial-cluster-token %[4]s"+
+		" --initial-advertise-peer-urls %[3]s"+
+		" --data-dir %[5]s", backupFile, m.Name, m.PeerURL(), token, dataDir)
+	restoreMounts := etcdVolumeMounts()
+	if withWAL {
+		// etcdctl restores the WAL inside the data dir. Move it to the dedicated WAL volume.
+		restoreCmd += fmt.Sprintf(" && rm -rf %[2]s && mv %[1]s/member/wal %[2]s", dataDir, walDir)
+		restoreMounts = append(restoreMounts, etcdWALVolumeMounts()...)
+	}
 	return []v1.Container{
 		{
 			Name:  "fetch-backup",
 			Image: "tutum/curl",
 			Command: []string{
 				"/bin/sh", "-ec",
-				fmt.Sprintf("curl -o %s %s", backupFile, backupURL.String()),
+				fmt.Sprintf("curl --compressed%s -o %s %s", backupAPIAuthCurlFlags(authSecret), backupFile, backupURL.String()),
 			},
 			VolumeMounts: etcdVolumeMounts(),
 		},
@@ -95,14 +139,9 @@ func makeRestoreInitContainers(backupURL *url.URL, token, baseImage, version str
 			Image: ImageName(baseImage, version),
 			Command: []string{
 				"/bin/sh", "-ec",
-				fmt.Sprintf("ETCDCTL_API=3 etcdctl snapshot restore %[1]s"+
-					" --name %[2]s"+
-					" --initial-cluster %[2]s=%[3]s"+
-					" --initial-cluster-token %[4]s"+
-					" --initial-advertise-peer-urls %[3]s"+
-					" --data-dir %[5]s", backupFile, m.Name, m.PeerURL(), token, dataDir),
+				restoreCmd,
 			},
-			VolumeMounts: etcdVolumeMounts(),
+			VolumeMounts: restoreMounts,
 		},
 	}
 }
@@ -218,8 +257,36 @@ func AddEtcdVolumeToPod(pod *v1.Pod, m *etcdutil.Member, claimName string) {
 	}
 }
 
-func addRecoveryToPod(pod *v1.Pod, token string, m *etcdutil.Member, cs api.ClusterSpec, backupURL *url.URL) {
-	pod.Spec.InitContainers = makeRestoreInitContainers(backupURL, token, cs.BaseImage, cs.Version, m)
+// GetEtcdVolumeClaimName returns the name of the PVC backing the etcd data volume of the pod,
+// or an empty string if the data volume is not a PVC.
+func GetEtcdVolumeClaimName(pod *v1.Pod) string {
+	for _, v := range pod.Spec.Volumes {
+		if v.Name == etcdVolumeName && v.PersistentVolumeClaim != nil {
+			return v.PersistentVolumeClaim.ClaimName
+		}
+	}
+	return ""
+}
+
+// AddEtcdWALVolumeToPod adds the PVC holding the etcd WAL to the pod.
+func AddEtcdWALVolumeToPod(pod *v1.Pod, claimName string) {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{Name: etcdWALVolumeName, VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claimName}}})
+}
+
+// WALClaimName returns the name of the PVC holding the etcd WAL that pairs with the given data PVC.
+func WALClaimName(dataClaimName string) string {
+	return strings.TrimSuffix(dataClaimName, "-pvc") + "-wal-pvc"
+}
+
+func addRecoveryToPod(pod *v1.Pod, token string, m *etcdutil.Member, cs api.ClusterSpec, backupURL *url.URL, authSecret string) {
+	pod.Spec.InitContainers = makeRestoreInitContainers(backupURL, authSecret, token, cs.BaseImage, cs.Version, m, isWALEnabled(cs))
+	if len(authSecret) != 0 {
+		addBackupAPIAuthToPodSpec(&pod.Spec, &pod.Spec.InitContainers[0], authSecret)
+	}
+}
+
+func isWALEnabled(cs api.ClusterSpec) bool {
+	return cs.Pod != nil && cs.Pod.WAL != nil
 }
 
 func addOwnerRefToObject(o metav1.Object, r metav1.OwnerReference) {
@@ -227,18 +294,28 @@ func addOwnerRefToObject(o metav1.Object, r metav1.OwnerReference) {
 }
 
 // NewSeedMemberPod returns a Pod manifest for a seed member.
-// It's special that it has new token, and might need recovery init containers
-func NewSeedMemberPod(clusterName string, ms etcdutil.MemberSet, m *etcdutil.Member, cs api.ClusterSpec, owner metav1.OwnerReference, backupURL *url.URL) *v1.Pod {
+// It's special that it has new token, and might need recovery init containers.
+// The init containers fetch the backup with the credentials of the backup API auth secret authSecret, if set.
+func NewSeedMemberPod(clusterName string, ms etcdutil.MemberSet, m *etcdutil.Member, cs api.ClusterSpec, owner metav1.OwnerReference, backupURL *url.URL, authSecret string) *v1.Pod {
 	token := uuid.New()
 	pod := NewEtcdPod(m, ms.PeerURLPairs(), clusterName, "new", token, cs, owner)
 	if backupURL != nil {
-		addRecoveryToPod(pod, token, m, cs, backupURL)
+		addRecoveryToPod(pod, token, m, cs, backupURL, authSecret)
 	}
 	return pod
 }
 
 // NewPVC create the PVC specification from parameters.
 func NewPVC(pvcName string, cs api.ClusterSpec, clusterName, namespace string, owner metav1.OwnerReference) *v1.PersistentVolumeClaim {
+	return newPVC(pvcName, cs.Pod.PV, clusterName, namespace, owner)
+}
+
+// NewWALPVC create the specification of the PVC holding the etcd WAL from parameters.
+func NewWALPVC(pvcName string, cs api.ClusterSpec, clusterName, namespace string, owner metav1.OwnerReference) *v1.PersistentVolumeClaim {
+	return newPVC(pvcName, cs.Pod.WAL, clusterName, namespace, owner)
+}
+
+func newPVC(pvcName string, pv *api.PVSource, clusterName, namespace string, owner metav1.OwnerReference) *v1.PersistentVolumeClaim {
 	name := pvcName
 	pvc := &v1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
@@ -255,14 +332,14 @@ func NewPVC(pvcName string, cs api.ClusterSpec, clusterName, namespace string, o
 			},
 			Resources: v1.ResourceRequirements{
 				Requests: v1.ResourceList{
-					v1.ResourceStorage: resource.MustParse(fmt.Sprintf("%dMi", cs.Pod.PV.VolumeSizeInMB)),
+					v1.ResourceStorage: resource.MustParse(fmt.Sprintf("%dMi", pv.VolumeSizeInMB)),
 				},
 			},
 		},
 	}
 
-	if len(cs.Pod.PV.StorageClass) != 0 {
-		pvc.Spec.StorageClassName = &cs.Pod.PV.StorageClass
+	if len(pv.StorageClass) != 0 {
+		pvc.Spec.StorageClassName = &pv.StorageClass
 	}
 	addOwnerRefToObject(pvc.GetObjectMeta(), owner)
 
@@ -280,6 +357,13 @@ func NewEtcdPod(m *etcdutil.Member, initialCluster []string, clusterName, state,
 	if m.SecureClient {
 		commands += fmt.Sprintf(" --client-cert-auth=true --trusted-ca-file=%[1]s/server-ca.crt --cert-file=%[1]s/server.crt --key-file=%[1]s/server.key", serverTLSDir)
 	}
+	if isWALEnabled(cs) {
+		commands += fmt.Sprintf(" --wal-dir=%s", walDir)
+	}
+	quota := QuotaBackendBytes(cs)
+	if quota > 0 {
+		commands += fmt.Sprintf(" --quota-backend-bytes=%d", quota)
+	}
 	if state == "new" {
 		commands = fmt.Sprintf("%s --initial-cluster-token=%s", commands, token)
 	}
@@ -299,6 +383,9 @@ func NewEtcdPod(m *etcdutil.Member, initialCluster []string, clusterName, state,
 	if cs.Pod != nil {
 		container = containerWithRequirements(container, cs.Pod.Resources)
 	}
+	if isWALEnabled(cs) {
+		container.VolumeMounts = append(container.VolumeMounts, etcdWALVolumeMounts()...)
+	}
 
 	volumes := []v1.Volume{}
 
@@ -347,6 +434,9 @@ func NewEtcdPod(m *etcdutil.Member, initialCluster []string, clusterName, state,
 	applyPodPolicy(clusterName, pod, cs.Pod)
 
 	SetEtcdVersion(pod, cs.Version)
+	if quota > 0 {
+		pod.Annotations[etcdQuotaAnnotationKey] = strconv.FormatInt(quota, 10)
+	}
 
 	addOwnerRefToObject(pod.GetObjectMeta(), owner)
 	return pod
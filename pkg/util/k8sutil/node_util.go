@@ -18,6 +18,9 @@ import (
 	"k8s.io/api/core/v1"
 )
 
+// ZoneLabel is the label of a node telling the failure domain zone it runs in.
+const ZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+
 // IsNodeReady checks if the Node condition is ready.
 func IsNodeReady(n v1.Node) bool {
 	for _, cd := range n.Status.Conditions {
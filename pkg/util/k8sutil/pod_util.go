@@ -17,6 +17,7 @@ package k8sutil
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
 	"github.com/coreos/etcd-operator/pkg/util/etcdutil"
@@ -26,7 +27,8 @@ import (
 )
 
 const (
-	etcdVolumeName = "etcd-data"
+	etcdVolumeName    = "etcd-data"
+	etcdWALVolumeName = "etcd-wal"
 )
 
 func etcdVolumeMounts() []v1.VolumeMount {
@@ -35,6 +37,12 @@ func etcdVolumeMounts() []v1.VolumeMount {
 	}
 }
 
+func etcdWALVolumeMounts() []v1.VolumeMount {
+	return []v1.VolumeMount{
+		{Name: etcdWALVolumeName, MountPath: etcdWALVolumeMountDir},
+	}
+}
+
 func etcdContainer(commands, baseImage, version string) v1.Container {
 	c := v1.Container{
 		Command: []string{"/bin/sh", "-ec", commands},
@@ -167,6 +175,15 @@ func IsPodReady(pod *v1.Pod) bool {
 	return condition != nil && condition.Status == v1.ConditionTrue
 }
 
+// PodReadySince returns the time the pod last became ready, or false if it is not ready.
+func PodReadySince(pod *v1.Pod) (time.Time, bool) {
+	condition := getPodReadyCondition(&pod.Status)
+	if condition == nil || condition.Status != v1.ConditionTrue {
+		return time.Time{}, false
+	}
+	return condition.LastTransitionTime.Time, true
+}
+
 func getPodReadyCondition(status *v1.PodStatus) *v1.PodCondition {
 	for i := range status.Conditions {
 		if status.Conditions[i].Type == v1.PodReady {
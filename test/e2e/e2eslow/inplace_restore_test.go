@@ -0,0 +1,148 @@
+// Copyright 2017 The etcd-operator Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2eslow
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/retryutil"
+	"github.com/coreos/etcd-operator/test/e2e/e2eutil"
+	"github.com/coreos/etcd-operator/test/e2e/framework"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestInPlaceRestore tests that the restore-operator restores the backup of an etcd cluster into the same cluster,
+// rolling back a delete made after the backup.
+func TestInPlaceRestore(t *testing.T) {
+	if os.Getenv("AWS_TEST_ENABLED") != "true" {
+		t.Skip("skipping test since AWS_TEST_ENABLED is not set.")
+	}
+	f := framework.Global
+
+	// The backup sidecar of the cluster takes the safety backup of the restore.
+	cl := e2eutil.ClusterWithBackup(e2eutil.NewCluster("inplace-restore-", 3), e2eutil.NewS3BackupPolicy(true))
+	testEtcd, err := e2eutil.CreateCluster(t, f.CRClient, f.Namespace, cl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := e2eutil.DeleteCluster(t, f.CRClient, f.KubeClient, testEtcd); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	names, err := e2eutil.WaitUntilSizeReached(t, f.CRClient, 3, 6, testEtcd)
+	if err != nil {
+		t.Fatalf("failed to create 3 members etcd cluster: %v", err)
+	}
+	if err := e2eutil.WaitBackupPodUp(t, f.KubeClient, f.Namespace, testEtcd.Name, 6); err != nil {
+		t.Fatalf("failed to create backup pod: %v", err)
+	}
+	pod, err := f.KubeClient.CoreV1().Pods(f.Namespace).Get(names[0], metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e2eutil.PutDataToEtcd(fmt.Sprintf("http://%s:2379", pod.Status.PodIP)); err != nil {
+		t.Fatal(err)
+	}
+
+	eb, err := f.CRClient.EtcdV1beta2().EtcdBackups(f.Namespace).Create(e2eutil.NewS3Backup(testEtcd.Name))
+	if err != nil {
+		t.Fatalf("failed to create etcd backup cr: %v", err)
+	}
+	defer func() {
+		if err := f.CRClient.EtcdV1beta2().EtcdBackups(f.Namespace).Delete(eb.Name, nil); err != nil {
+			t.Fatalf("failed to delete etcd backup cr: %v", err)
+		}
+	}()
+	s3Path := ""
+	err = retryutil.Retry(time.Second, 4, func() (bool, error) {
+		reb, err := f.CRClient.EtcdV1beta2().EtcdBackups(f.Namespace).Get(eb.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to retrieve backup CR: %v", err)
+		}
+		if reb.Status.Succeeded {
+			s3Path = reb.Status.S3Path
+			return true, nil
+		} else if len(reb.Status.Reason) != 0 {
+			return false, fmt.Errorf("backup failed with reason: %v ", reb.Status.Reason)
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to verify backup: %v", err)
+	}
+
+	if err := e2eutil.DeleteDataFromEtcd(fmt.Sprintf("http://%s:2379", pod.Status.PodIP)); err != nil {
+		t.Fatal(err)
+	}
+
+	restoreSource := api.RestoreSource{S3: e2eutil.NewS3RestoreSource(s3Path, os.Getenv("TEST_AWS_SECRET"))}
+	er := e2eutil.NewEtcdRestore("", "3.1.8", 3, restoreSource)
+	er.Name = testEtcd.Name
+	er.Spec.InPlace = true
+	er, err = f.CRClient.EtcdV1beta2().EtcdRestores(f.Namespace).Create(er)
+	if err != nil {
+		t.Fatalf("failed to create etcd restore cr: %v", err)
+	}
+	defer func() {
+		if err := f.CRClient.EtcdV1beta2().EtcdRestores(f.Namespace).Delete(er.Name, nil); err != nil {
+			t.Fatalf("failed to delete etcd restore cr: %v", err)
+		}
+	}()
+	// The restore waits for the cluster to be paused, backed up, restored and grown back.
+	err = retryutil.Retry(10*time.Second, 60, func() (bool, error) {
+		er, err = f.CRClient.EtcdV1beta2().EtcdRestores(f.Namespace).Get(er.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to retrieve restore CR: %v", err)
+		}
+		if er.Status.Succeeded {
+			return true, nil
+		} else if len(er.Status.Reason) != 0 {
+			return false, fmt.Errorf("restore failed with reason: %v ", er.Status.Reason)
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to verify restore succeeded: %v", err)
+	}
+	for _, ct := range []api.RestoreConditionType{
+		api.RestoreConditionPaused,
+		api.RestoreConditionSafetyBackupTaken,
+		api.RestoreConditionMembersStopped,
+		api.RestoreConditionSeedRestored,
+		api.RestoreConditionBackupsRolledBack,
+		api.RestoreConditionResumed,
+		api.RestoreConditionMembersRegrown,
+	} {
+		if !er.Status.HasCondition(ct) {
+			t.Errorf("restore CR has no condition %s: %v", ct, er.Status.Conditions)
+		}
+	}
+
+	names, err = e2eutil.WaitUntilSizeReached(t, f.CRClient, 3, 6, testEtcd)
+	if err != nil {
+		t.Fatalf("failed to see restored etcd cluster reach 3 members: %v", err)
+	}
+	pod, err = f.KubeClient.CoreV1().Pods(f.Namespace).Get(names[0], metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2eutil.CheckEtcdData(t, fmt.Sprintf("http://%s:2379", pod.Status.PodIP))
+}
@@ -15,9 +15,141 @@
 package e2eslow
 
 import (
+	"fmt"
+	"math/rand"
+	"os"
 	"testing"
+	"time"
+
+	api "github.com/coreos/etcd-operator/pkg/apis/etcd/v1beta2"
+	"github.com/coreos/etcd-operator/pkg/util/retryutil"
+	"github.com/coreos/etcd-operator/test/e2e/e2eutil"
+	"github.com/coreos/etcd-operator/test/e2e/framework"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestTLS(t *testing.T) {
 	TLSTestCommon(t, false)
 }
+
+// TestTLSRestore tests that the restore-operator restores the backup of an etcd cluster into a cluster with static TLS.
+func TestTLSRestore(t *testing.T) {
+	if os.Getenv("AWS_TEST_ENABLED") != "true" {
+		t.Skip("skipping test since AWS_TEST_ENABLED is not set.")
+	}
+	f := framework.Global
+
+	testEtcd, err := e2eutil.CreateCluster(t, f.CRClient, f.Namespace, e2eutil.NewCluster("tls-restore-src-", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := e2eutil.DeleteCluster(t, f.CRClient, f.KubeClient, testEtcd); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	names, err := e2eutil.WaitUntilSizeReached(t, f.CRClient, 3, 6, testEtcd)
+	if err != nil {
+		t.Fatalf("failed to create 3 members etcd cluster: %v", err)
+	}
+	pod, err := f.KubeClient.CoreV1().Pods(f.Namespace).Get(names[0], metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e2eutil.PutDataToEtcd(fmt.Sprintf("http://%s:2379", pod.Status.PodIP)); err != nil {
+		t.Fatal(err)
+	}
+
+	eb, err := f.CRClient.EtcdV1beta2().EtcdBackups(f.Namespace).Create(e2eutil.NewS3Backup(testEtcd.Name))
+	if err != nil {
+		t.Fatalf("failed to create etcd backup cr: %v", err)
+	}
+	defer func() {
+		if err := f.CRClient.EtcdV1beta2().EtcdBackups(f.Namespace).Delete(eb.Name, nil); err != nil {
+			t.Fatalf("failed to delete etcd backup cr: %v", err)
+		}
+	}()
+	s3Path := ""
+	err = retryutil.Retry(time.Second, 4, func() (bool, error) {
+		reb, err := f.CRClient.EtcdV1beta2().EtcdBackups(f.Namespace).Get(eb.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to retrieve backup CR: %v", err)
+		}
+		if reb.Status.Succeeded {
+			s3Path = reb.Status.S3Path
+			return true, nil
+		} else if len(reb.Status.Reason) != 0 {
+			return false, fmt.Errorf("backup failed with reason: %v ", reb.Status.Reason)
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to verify backup: %v", err)
+	}
+
+	// The certs of the restored cluster are issued for its name, so it can't be generated.
+	clusterName := fmt.Sprintf("tls-restore-%d", rand.Uint64())
+	tp, certsDir, cleanup := prepareTLSSecrets(t, clusterName)
+	defer cleanup()
+
+	restoreSource := api.RestoreSource{S3: e2eutil.NewS3RestoreSource(s3Path, os.Getenv("TEST_AWS_SECRET"))}
+	er := e2eutil.NewEtcdRestore("", "3.1.8", 3, restoreSource)
+	er.Name = clusterName
+	er.Spec.ClusterSpec.TLS = tp
+	er, err = f.CRClient.EtcdV1beta2().EtcdRestores(f.Namespace).Create(er)
+	if err != nil {
+		t.Fatalf("failed to create etcd restore cr: %v", err)
+	}
+	defer func() {
+		if err := f.CRClient.EtcdV1beta2().EtcdRestores(f.Namespace).Delete(er.Name, nil); err != nil {
+			t.Fatalf("failed to delete etcd restore cr: %v", err)
+		}
+	}()
+	err = retryutil.Retry(time.Second, 5, func() (bool, error) {
+		er, err := f.CRClient.EtcdV1beta2().EtcdRestores(f.Namespace).Get(er.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to retrieve restore CR: %v", err)
+		}
+		if er.Status.Succeeded {
+			return true, nil
+		} else if len(er.Status.Reason) != 0 {
+			return false, fmt.Errorf("restore failed with reason: %v ", er.Status.Reason)
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to verify restore succeeded: %v", err)
+	}
+
+	restoredCluster := &api.EtcdCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: f.Namespace,
+		},
+		Spec: api.ClusterSpec{
+			Size: 3,
+			TLS:  tp,
+		},
+	}
+	defer func() {
+		if err := e2eutil.DeleteCluster(t, f.CRClient, f.KubeClient, restoredCluster); err != nil {
+			t.Fatalf("failed to delete restored cluster(%v): %v", restoredCluster.Name, err)
+		}
+	}()
+	names, err = e2eutil.WaitUntilSizeReached(t, f.CRClient, 3, 6, restoredCluster)
+	if err != nil {
+		t.Fatalf("failed to see restored etcd cluster(%v) reach 3 members: %v", restoredCluster.Name, err)
+	}
+
+	// The member certs are issued for the DNS names of the members, not their pod IPs.
+	pod, err = f.KubeClient.CoreV1().Pods(f.Namespace).Get(names[0], metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc, err := e2eutil.NewClientTLSConfig(certsDir, fmt.Sprintf("%s.%s.%s.svc", pod.Name, clusterName, f.Namespace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2eutil.CheckEtcdDataWithTLS(t, fmt.Sprintf("https://%s:2379", pod.Status.PodIP), tc)
+}
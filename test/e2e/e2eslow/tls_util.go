@@ -28,36 +28,65 @@ import (
 
 func TLSTestCommon(t *testing.T, selfHosted bool) {
 	f := framework.Global
-	suffix := fmt.Sprintf("-%d", rand.Uint64())
-	clusterName := "tls-test" + suffix
-	memberPeerTLSSecret := "etcd-peer-tls" + suffix
-	memberClientTLSSecret := "etcd-server-tls" + suffix
-	operatorClientTLSSecret := "etcd-client-tls" + suffix
+	clusterName := fmt.Sprintf("tls-test-%d", rand.Uint64())
+	tp, _, cleanup := prepareTLSSecrets(t, clusterName)
+	defer cleanup()
+
+	c := e2eutil.NewCluster("", 3)
+	c.Name = clusterName
+	c.Spec.TLS = tp
+	if selfHosted {
+		c = e2eutil.ClusterWithSelfHosted(c, &api.SelfHostedPolicy{})
+	}
+	c, err := e2eutil.CreateCluster(t, f.CRClient, f.Namespace, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err := e2eutil.DeleteCluster(t, f.CRClient, f.KubeClient, c); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	_, err = e2eutil.WaitUntilSizeReached(t, f.CRClient, 3, 6, c)
+	if err != nil {
+		t.Fatalf("failed to create 3 members etcd cluster: %v", err)
+	}
+	// TODO: use client key/certs to talk to secure etcd cluster.
+}
+
+// prepareTLSSecrets creates the peer, server and operator TLS secrets of the cluster clusterName,
+// and returns the static TLS policy using them and the directory holding the operator client certs.
+// cleanup deletes the secrets and the directory.
+func prepareTLSSecrets(t *testing.T, clusterName string) (tp *api.TLSPolicy, certsDir string, cleanup func()) {
+	f := framework.Global
+	memberPeerTLSSecret := clusterName + "-peer-tls"
+	memberClientTLSSecret := clusterName + "-server-tls"
+	operatorClientTLSSecret := clusterName + "-client-tls"
 
 	err := e2eutil.PreparePeerTLSSecret(clusterName, f.Namespace, memberPeerTLSSecret)
 	if err != nil {
 		t.Fatal(err)
 	}
-	certsDir, err := ioutil.TempDir("", "etcd-operator-tls-")
+	certsDir, err = ioutil.TempDir("", "etcd-operator-tls-")
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer os.RemoveAll(certsDir)
 	err = e2eutil.PrepareClientTLSSecret(certsDir, clusterName, f.Namespace, memberClientTLSSecret, operatorClientTLSSecret)
 	if err != nil {
+		os.RemoveAll(certsDir)
 		t.Fatal(err)
 	}
-
-	defer func() {
+	cleanup = func() {
+		os.RemoveAll(certsDir)
 		err := e2eutil.DeleteSecrets(f.KubeClient, f.Namespace, memberPeerTLSSecret, memberClientTLSSecret, operatorClientTLSSecret)
 		if err != nil {
 			t.Fatal(err)
 		}
-	}()
+	}
 
-	c := e2eutil.NewCluster("", 3)
-	c.Name = clusterName
-	c.Spec.TLS = &api.TLSPolicy{
+	tp = &api.TLSPolicy{
 		Static: &api.StaticTLS{
 			Member: &api.MemberSecret{
 				PeerSecret:   memberPeerTLSSecret,
@@ -66,23 +95,5 @@ func TLSTestCommon(t *testing.T, selfHosted bool) {
 			OperatorSecret: operatorClientTLSSecret,
 		},
 	}
-	if selfHosted {
-		c = e2eutil.ClusterWithSelfHosted(c, &api.SelfHostedPolicy{})
-	}
-	c, err = e2eutil.CreateCluster(t, f.CRClient, f.Namespace, c)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	defer func() {
-		if err := e2eutil.DeleteCluster(t, f.CRClient, f.KubeClient, c); err != nil {
-			t.Fatal(err)
-		}
-	}()
-
-	_, err = e2eutil.WaitUntilSizeReached(t, f.CRClient, 3, 6, c)
-	if err != nil {
-		t.Fatalf("failed to create 3 members etcd cluster: %v", err)
-	}
-	// TODO: use client key/certs to talk to secure etcd cluster.
+	return tp, certsDir, cleanup
 }
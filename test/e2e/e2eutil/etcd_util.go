@@ -16,6 +16,7 @@ package e2eutil
 
 import (
 	"context"
+	"crypto/tls"
 	"testing"
 
 	"github.com/coreos/etcd-operator/pkg/util/constants"
@@ -28,7 +29,12 @@ const (
 )
 
 func PutDataToEtcd(url string) error {
-	etcdcli, err := createEtcdClient(url)
+	return PutDataToEtcdWithTLS(url, nil)
+}
+
+// PutDataToEtcdWithTLS puts the test data to the etcd member at url, using tc to connect if it is not nil.
+func PutDataToEtcdWithTLS(url string, tc *tls.Config) error {
+	etcdcli, err := createEtcdClient(url, tc)
 	if err != nil {
 		return err
 	}
@@ -39,8 +45,26 @@ func PutDataToEtcd(url string) error {
 	return err
 }
 
+// DeleteDataFromEtcd deletes the test data from the etcd member at url.
+func DeleteDataFromEtcd(url string) error {
+	etcdcli, err := createEtcdClient(url, nil)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultRequestTimeout)
+	_, err = etcdcli.Delete(ctx, etcdKeyFoo)
+	cancel()
+	etcdcli.Close()
+	return err
+}
+
 func CheckEtcdData(t *testing.T, url string) {
-	etcdcli, err := createEtcdClient(url)
+	CheckEtcdDataWithTLS(t, url, nil)
+}
+
+// CheckEtcdDataWithTLS checks the test data in the etcd member at url, using tc to connect if it is not nil.
+func CheckEtcdDataWithTLS(t *testing.T, url string, tc *tls.Config) {
+	etcdcli, err := createEtcdClient(url, tc)
 	if err != nil {
 		t.Fatalf("failed to create etcd client:%v", err)
 	}
@@ -61,10 +85,11 @@ func CheckEtcdData(t *testing.T, url string) {
 	}
 }
 
-func createEtcdClient(addr string) (*clientv3.Client, error) {
+func createEtcdClient(addr string, tc *tls.Config) (*clientv3.Client, error) {
 	cfg := clientv3.Config{
 		Endpoints:   []string{addr},
 		DialTimeout: constants.DefaultDialTimeout,
+		TLS:         tc,
 	}
 	c, err := clientv3.New(cfg)
 	if err != nil {
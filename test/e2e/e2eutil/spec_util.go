@@ -53,8 +53,9 @@ func NewS3BackupPolicy(cleanup bool) *api.BackupPolicy {
 		StorageType:            api.BackupStorageTypeS3,
 		StorageSource: api.StorageSource{
 			S3: &api.S3Source{
-				S3Bucket:  os.Getenv("TEST_S3_BUCKET"),
-				AWSSecret: os.Getenv("TEST_AWS_SECRET"),
+				S3Bucket:   os.Getenv("TEST_S3_BUCKET"),
+				AWSSecret:  os.Getenv("TEST_AWS_SECRET"),
+				S3Endpoint: NewS3Endpoint(),
 			},
 		},
 		AutoDelete: cleanup,
@@ -76,8 +77,9 @@ func NewS3Backup(clusterName string) *api.EtcdBackup {
 			StorageType: api.BackupStorageTypeS3,
 			BackupStorageSource: api.BackupStorageSource{
 				S3: &api.S3Source{
-					S3Bucket:  os.Getenv("TEST_S3_BUCKET"),
-					AWSSecret: os.Getenv("TEST_AWS_SECRET"),
+					S3Bucket:   os.Getenv("TEST_S3_BUCKET"),
+					AWSSecret:  os.Getenv("TEST_AWS_SECRET"),
+					S3Endpoint: NewS3Endpoint(),
 				},
 			},
 		},
@@ -87,8 +89,23 @@ func NewS3Backup(clusterName string) *api.EtcdBackup {
 // NewS3RestoreSource returns an S3RestoreSource with the specified path and secret
 func NewS3RestoreSource(path, awsSecret string) *api.S3RestoreSource {
 	return &api.S3RestoreSource{
-		Path:      path,
-		AWSSecret: awsSecret,
+		Path:       path,
+		AWSSecret:  awsSecret,
+		S3Endpoint: NewS3Endpoint(),
+	}
+}
+
+// NewS3Endpoint returns the S3-compatible endpoint set by TEST_S3_ENDPOINT.
+// An empty endpoint means AWS S3.
+func NewS3Endpoint() api.S3Endpoint {
+	ep := os.Getenv("TEST_S3_ENDPOINT")
+	if len(ep) == 0 {
+		return api.S3Endpoint{}
+	}
+	return api.S3Endpoint{
+		Endpoint:       ep,
+		Region:         os.Getenv("TEST_S3_REGION"),
+		ForcePathStyle: true,
 	}
 }
 
@@ -11,6 +11,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
@@ -23,6 +24,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"time"
+
+	"github.com/coreos/etcd/pkg/transport"
 )
 
 func PreparePeerTLSSecret(clusterName, ns, secretName string) error {
@@ -92,6 +95,22 @@ func PrepareClientTLSSecret(dir, clusterName, ns, mSecret, oSecret string) error
 	return cmd.Run()
 }
 
+// NewClientTLSConfig returns the TLS config of a client of the etcd member with the DNS name serverName,
+// using the operator client certs written to dir by PrepareClientTLSSecret.
+func NewClientTLSConfig(dir, serverName string) (*tls.Config, error) {
+	tlsInfo := transport.TLSInfo{
+		CertFile:      filepath.Join(dir, "etcd-client.crt"),
+		KeyFile:       filepath.Join(dir, "etcd-client.key"),
+		TrustedCAFile: filepath.Join(dir, "etcd-client-ca.crt"),
+	}
+	tc, err := tlsInfo.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	tc.ServerName = serverName
+	return tc, nil
+}
+
 func prepareTLSCerts(certPath, keyPath, caPath string, hosts []string) error {
 	err := prepareKeyAndCert(certPath, keyPath, hosts)
 	if err != nil {
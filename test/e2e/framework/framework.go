@@ -26,6 +26,7 @@ import (
 
 	"github.com/coreos/etcd-operator/pkg/client"
 	"github.com/coreos/etcd-operator/pkg/generated/clientset/versioned"
+	"github.com/coreos/etcd-operator/pkg/util/awsutil/s3factory"
 	"github.com/coreos/etcd-operator/pkg/util/constants"
 	"github.com/coreos/etcd-operator/pkg/util/k8sutil"
 	"github.com/coreos/etcd-operator/pkg/util/probe"
@@ -341,9 +342,11 @@ func (f *Framework) setupAWS() error {
 	if err := os.Setenv("AWS_CONFIG_FILE", os.Getenv("AWS_CONFIG")); err != nil {
 		return err
 	}
-	sess, err := session.NewSessionWithOptions(session.Options{
+	so := session.Options{
 		SharedConfigState: session.SharedConfigEnable,
-	})
+	}
+	s3factory.ApplyS3Endpoint(&so, e2eutil.NewS3Endpoint(), nil)
+	sess, err := session.NewSessionWithOptions(so)
 	if err != nil {
 		return err
 	}